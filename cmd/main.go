@@ -1,44 +1,210 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"atlassian-mcp/internal/audit"
+	"atlassian-mcp/internal/client"
 	"atlassian-mcp/internal/config"
 	"atlassian-mcp/internal/handler"
-	"atlassian-mcp/internal/types"
+	"atlassian-mcp/internal/logging"
+	"atlassian-mcp/internal/oauth"
+	"atlassian-mcp/internal/server"
 )
 
 func main() {
-	if config.Email == "" || config.Token == "" || config.Domain == "" {
-		fmt.Fprintln(os.Stderr, "Error: ATLASSIAN_EMAIL, ATLASSIAN_API_TOKEN, and ATLASSIAN_DOMAIN environment variables must be set")
+	if err := config.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size for large messages
-	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	transport := flag.String("transport", config.Transport, "transport to serve: stdio or http")
+	addr := flag.String("addr", config.HTTPAddr, "listen address for the http transport")
+	login := flag.Bool("login", false, "authorize with OAuth again even if a token is stored")
+	flag.Parse()
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
+	logCloser, err := logging.Setup(logging.Options{
+		Level:   config.LogLevel,
+		File:    config.LogFile,
+		Secrets: secrets(),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	defer logCloser.Close()
+
+	auditCloser, err := audit.Setup(audit.Options{
+		File:       config.AuditLog,
+		HashBodies: config.AuditHashBodies,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	defer auditCloser.Close()
+	slog.Info("starting server", "transport", *transport, "sites", len(config.Profiles))
+
+	var cache *client.Cache
+	if config.Cache {
+		cache = client.NewCache()
+	}
+	cassette, err := newCassette()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	var sites []*client.Client
+	for _, p := range config.Profiles {
+		if config.CassetteMode == config.CassetteReplay && p.AuthMethod == config.AuthOAuth {
+			fmt.Fprintf(os.Stderr, "Error: site %s: cassette replay does not support OAuth; use basic or bearer auth\n", p.Name)
+			os.Exit(1)
+		}
+		c, err := newClient(context.Background(), p, *login)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: site %s: %v\n", p.Name, err)
+			os.Exit(1)
 		}
+		c.Site = p.Name
+		c.Cache = cache
+		if cassette != nil {
+			c.HTTP = cassette.HTTPClient()
+		}
+		slog.Info("site configured", "site", p.Name, "deployment", p.Deployment, "domain", p.Domain)
+		sites = append(sites, c)
+	}
+	srv := server.New(handler.New(sites...), config.MaxConcurrentRequests)
 
-		var req types.Request
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			continue
+	switch *transport {
+	case "stdio":
+		if err := srv.Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading input:", err)
+			os.Exit(1)
+		}
+	case "http":
+		if err := serveHTTP(srv, *addr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
 		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown transport %q (use stdio or http)\n", *transport)
+		os.Exit(1)
+	}
+}
+
+// newCassette returns the cassette that records or replays API calls, or
+// nil when ATLASSIAN_CASSETTE is not set.
+func newCassette() (*client.Cassette, error) {
+	switch config.CassetteMode {
+	case config.CassetteRecord:
+		slog.Warn("recording Atlassian API calls; fixtures hold response data", "dir", config.CassetteDir)
+		return client.RecordCassette(config.CassetteDir)
+	case config.CassetteReplay:
+		slog.Info("replaying Atlassian API calls", "dir", config.CassetteDir)
+		return client.ReplayCassette(config.CassetteDir)
+	}
+	return nil, nil
+}
 
-		resp := handler.HandleRequest(req)
-		if resp.ID == nil && resp.Result == nil && resp.Error == nil {
-			// Skip empty responses (notifications)
-			continue
+// secrets lists the configured credentials, which are redacted from logs.
+func secrets() []string {
+	list := []string{config.HTTPToken, config.OAuthClientSecret, config.OAuthPassphrase}
+	for _, p := range config.Profiles {
+		list = append(list, p.Token)
+	}
+	return list
+}
+
+// newClient builds the Atlassian client of a profile for its deployment and
+// auth method. With OAuth, the user is asked to authorize access when no
+// token is stored yet.
+func newClient(ctx context.Context, p config.Profile, login bool) (*client.Client, error) {
+	if p.Deployment == config.DeploymentDataCenter {
+		var auth client.Auth = client.BearerAuth{Token: p.Token}
+		if p.AuthMethod == config.AuthBasic {
+			auth = client.BasicAuth{Email: p.Email, Token: p.Token}
+		}
+		return client.NewDataCenter(p.JiraURL, p.ConfluenceURL, auth), nil
+	}
+	switch p.AuthMethod {
+	case config.AuthBearer:
+		return client.New(p.Domain, client.BearerAuth{Token: p.Token}), nil
+	case config.AuthOAuth:
+		provider, err := oauth.New(oauth.Options{
+			ClientID:     config.OAuthClientID,
+			ClientSecret: config.OAuthClientSecret,
+			RedirectURL:  config.OAuthRedirectURL,
+			Scopes:       config.OAuthScopes,
+			TokenFile:    p.OAuthTokenFile,
+			Passphrase:   config.OAuthPassphrase,
+		})
+		if err != nil {
+			return nil, err
 		}
+		if login || !provider.LoggedIn() {
+			err := provider.Login(ctx, func(authorizeURL string) {
+				fmt.Fprintf(os.Stderr, "Open this URL in a browser to authorize atlassian-mcp for %s:\n%s\n", p.Domain, authorizeURL)
+			})
+			if err != nil {
+				return nil, err
+			}
+			slog.Info("oauth authorization stored", "site", p.Name, "file", p.OAuthTokenFile)
+		}
+		cloudID, err := provider.CloudID(ctx, p.Domain)
+		if err != nil {
+			return nil, err
+		}
+		return client.NewGateway(cloudID, provider), nil
+	default:
+		return client.New(p.Domain, client.BasicAuth{Email: p.Email, Token: p.Token}), nil
+	}
+}
 
-		respBytes, _ := json.Marshal(resp)
-		fmt.Println(string(respBytes))
+// serveHTTP runs the Streamable HTTP transport until interrupted.
+func serveHTTP(srv *server.Server, addr string) error {
+	if config.HTTPToken == "" {
+		slog.Warn("ATLASSIAN_HTTP_TOKEN is not set; the HTTP endpoint accepts unauthenticated requests")
 	}
+
+	mcp := srv.NewHTTPHandler(config.HTTPToken)
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", mcp)
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "url", "http://"+addr+"/mcp")
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	mcp.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
 }
@@ -0,0 +1,209 @@
+package adf
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ToStorage converts an Atlassian Document Format document to the Confluence
+// storage format, the XHTML body format of Confluence Data Center.
+//
+// Panels, expands, code blocks and status badges become the equivalent
+// macros, task lists become ac:task-list, mentions link to the user with
+// that username, and media reference the page attachment they were uploaded
+// as, or the external image URL.
+func ToStorage(doc map[string]any) string {
+	return storageBlocks(doc)
+}
+
+// storageBlocks renders the children of a node.
+func storageBlocks(node map[string]any) string {
+	content, ok := node["content"].([]any)
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, child := range content {
+		if childMap, ok := child.(map[string]any); ok {
+			sb.WriteString(storageNode(childMap))
+		}
+	}
+	return sb.String()
+}
+
+// storageNode converts a single ADF node to storage format.
+func storageNode(node map[string]any) string {
+	nodeType, _ := node["type"].(string)
+	attrs, _ := node["attrs"].(map[string]any)
+
+	switch nodeType {
+	case "paragraph":
+		return "<p>" + storageBlocks(node) + "</p>"
+	case "heading":
+		level := min(max(intAttr(attrs, "level", 1), 1), 6)
+		return fmt.Sprintf("<h%d>%s</h%d>", level, storageBlocks(node), level)
+	case "bulletList":
+		return "<ul>" + storageBlocks(node) + "</ul>"
+	case "orderedList":
+		if order := intAttr(attrs, "order", 1); order != 1 {
+			return fmt.Sprintf(`<ol start="%d">%s</ol>`, order, storageBlocks(node))
+		}
+		return "<ol>" + storageBlocks(node) + "</ol>"
+	case "listItem":
+		return "<li>" + storageBlocks(node) + "</li>"
+	case "taskList":
+		return "<ac:task-list>" + storageBlocks(node) + "</ac:task-list>"
+	case "taskItem":
+		status := "incomplete"
+		if attrs["state"] == "DONE" {
+			status = "complete"
+		}
+		return fmt.Sprintf("<ac:task><ac:task-status>%s</ac:task-status><ac:task-body>%s</ac:task-body></ac:task>", status, storageBlocks(node))
+	case "codeBlock":
+		var params string
+		if lang, _ := attrs["language"].(string); lang != "" {
+			params = storageParam("language", lang)
+		}
+		body := strings.ReplaceAll(plainText(node), "]]>", "]]]]><![CDATA[>")
+		return `<ac:structured-macro ac:name="code">` + params + "<ac:plain-text-body><![CDATA[" + body + "]]></ac:plain-text-body></ac:structured-macro>"
+	case "blockquote":
+		return "<blockquote>" + storageBlocks(node) + "</blockquote>"
+	case "rule":
+		return "<hr />"
+	case "panel":
+		panelType, _ := attrs["panelType"].(string)
+		return storageMacro(panelMacros[panelType], "", storageBlocks(node))
+	case "expand", "nestedExpand":
+		var params string
+		if title, _ := attrs["title"].(string); title != "" {
+			params = storageParam("title", title)
+		}
+		return storageMacro("expand", params, storageBlocks(node))
+	case "table":
+		return "<table><tbody>" + storageBlocks(node) + "</tbody></table>"
+	case "tableRow":
+		return "<tr>" + storageBlocks(node) + "</tr>"
+	case "tableHeader":
+		return "<th>" + storageBlocks(node) + "</th>"
+	case "tableCell":
+		return "<td>" + storageBlocks(node) + "</td>"
+	case "mediaSingle":
+		return "<p>" + storageBlocks(node) + "</p>"
+	case "media":
+		if name, _ := attrs["filename"].(string); name != "" {
+			return fmt.Sprintf(`<ac:image><ri:attachment ri:filename="%s" /></ac:image>`, html.EscapeString(name))
+		}
+		if url, _ := attrs["url"].(string); url != "" {
+			return fmt.Sprintf(`<ac:image><ri:url ri:value="%s" /></ac:image>`, html.EscapeString(url))
+		}
+		alt, _ := attrs["alt"].(string)
+		if alt == "" {
+			alt = "attachment"
+		}
+		return html.EscapeString("[" + alt + "]")
+	case "text":
+		text, _ := node["text"].(string)
+		marks, _ := node["marks"].([]any)
+		return storageMarks(html.EscapeString(text), marks)
+	case "hardBreak":
+		return "<br />"
+	case "mention":
+		if id, _ := attrs["id"].(string); id != "" {
+			return fmt.Sprintf(`<ac:link><ri:user ri:username="%s" /></ac:link>`, html.EscapeString(id))
+		}
+		text, _ := attrs["text"].(string)
+		return html.EscapeString(text)
+	case "emoji":
+		if text, _ := attrs["text"].(string); text != "" {
+			return html.EscapeString(text)
+		}
+		shortName, _ := attrs["shortName"].(string)
+		return html.EscapeString(shortName)
+	case "status":
+		text, _ := attrs["text"].(string)
+		color, _ := attrs["color"].(string)
+		params := storageParam("title", text)
+		if colour := statusColours[color]; colour != "" {
+			params += storageParam("colour", colour)
+		}
+		return `<ac:structured-macro ac:name="status">` + params + "</ac:structured-macro>"
+	case "date":
+		timestamp, _ := attrs["timestamp"].(string)
+		return fmt.Sprintf(`<time datetime="%s" />`, html.EscapeString(FormatTimestamp(timestamp)))
+	case "inlineCard":
+		url, _ := attrs["url"].(string)
+		url = html.EscapeString(url)
+		return fmt.Sprintf(`<a href="%s">%s</a>`, url, url)
+	default:
+		return storageBlocks(node)
+	}
+}
+
+// panelMacros maps ADF panel types to the Confluence macros closest to them.
+var panelMacros = map[string]string{
+	"info":    "info",
+	"note":    "note",
+	"warning": "warning",
+	"error":   "warning",
+	"success": "tip",
+}
+
+// statusColours maps ADF status colors to status macro colours.
+var statusColours = map[string]string{
+	"neutral": "Grey",
+	"purple":  "Purple",
+	"blue":    "Blue",
+	"green":   "Green",
+	"yellow":  "Yellow",
+	"red":     "Red",
+}
+
+// storageMacro renders a macro with a rich text body. An unknown name falls
+// back to the info macro.
+func storageMacro(name, params, body string) string {
+	if name == "" {
+		name = "info"
+	}
+	return fmt.Sprintf(`<ac:structured-macro ac:name="%s">%s<ac:rich-text-body>%s</ac:rich-text-body></ac:structured-macro>`, name, params, body)
+}
+
+func storageParam(name, value string) string {
+	return fmt.Sprintf(`<ac:parameter ac:name="%s">%s</ac:parameter>`, name, html.EscapeString(value))
+}
+
+// storageMarks applies formatting marks to escaped text.
+func storageMarks(text string, marks []any) string {
+	result := text
+	for _, mark := range marks {
+		markMap, ok := mark.(map[string]any)
+		if !ok {
+			continue
+		}
+		attrs, _ := markMap["attrs"].(map[string]any)
+		switch markType, _ := markMap["type"].(string); markType {
+		case "code":
+			result = "<code>" + result + "</code>"
+		case "em":
+			result = "<em>" + result + "</em>"
+		case "strong":
+			result = "<strong>" + result + "</strong>"
+		case "strike":
+			result = "<s>" + result + "</s>"
+		case "underline":
+			result = "<u>" + result + "</u>"
+		case "subsup":
+			if t, _ := attrs["type"].(string); t == "sub" || t == "sup" {
+				result = "<" + t + ">" + result + "</" + t + ">"
+			}
+		case "textColor":
+			color, _ := attrs["color"].(string)
+			result = fmt.Sprintf(`<span style="color: %s">%s</span>`, html.EscapeString(color), result)
+		case "link":
+			href, _ := attrs["href"].(string)
+			result = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), result)
+		}
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package adf
+
+import "testing"
+
+func TestToStorage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"Heading", "# Title", "<h1>Title</h1>"},
+		{
+			name:     "Marks",
+			markdown: "**bold** _em_ `code` [x](https://e.com)",
+			want:     `<p><strong>bold</strong> <em>em</em> <code>code</code> <a href="https://e.com">x</a></p>`,
+		},
+		{
+			name:     "Nested_Bullets",
+			markdown: "- a\n  - b",
+			want:     "<ul><li><p>a</p><ul><li><p>b</p></li></ul></li></ul>",
+		},
+		{
+			name:     "Code_Block",
+			markdown: "```go\nfmt.Println()\n```",
+			want:     `<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">go</ac:parameter><ac:plain-text-body><![CDATA[fmt.Println()]]></ac:plain-text-body></ac:structured-macro>`,
+		},
+		{
+			name:     "Table",
+			markdown: "| a |\n|---|\n| 1 |",
+			want:     "<table><tbody><tr><th><p>a</p></th></tr><tr><td><p>1</p></td></tr></tbody></table>",
+		},
+		{
+			name:     "Tasks",
+			markdown: "- [x] done",
+			want:     "<ac:task-list><ac:task><ac:task-status>complete</ac:task-status><ac:task-body>done</ac:task-body></ac:task></ac:task-list>",
+		},
+		{"Escaped_Text", "x < y & z", "<p>x &lt; y &amp; z</p>"},
+		{"Rule", "---", "<hr />"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ToStorage(FromMarkdown(tt.markdown)); got != tt.want {
+				t.Errorf("ToStorage(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
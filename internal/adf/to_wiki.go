@@ -0,0 +1,324 @@
+package adf
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// ToWiki converts an Atlassian Document Format document to Jira wiki markup,
+// the body format of the Jira Data Center REST API v2.
+//
+// Nodes without a wiki equivalent degrade to the closest markup:
+//   - panels and expands become {panel} blocks
+//   - task items become bullets, done ones marked with (/)
+//   - status badges become bold text, dates ISO dates
+//   - mentions become [~username]
+//   - media become !filename! once uploaded, or !url! for external images
+func ToWiki(doc map[string]any) string {
+	return strings.TrimSpace(wikiBlocks(doc))
+}
+
+// wikiBlocks renders the block children of a node separated by blank lines.
+func wikiBlocks(node map[string]any) string {
+	content, ok := node["content"].([]any)
+	if !ok {
+		return ""
+	}
+
+	var parts []string
+	for _, child := range content {
+		childMap, ok := child.(map[string]any)
+		if !ok {
+			continue
+		}
+		if rendered := wikiNode(childMap); rendered != "" {
+			parts = append(parts, rendered)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// wikiNode converts a single ADF node to wiki markup.
+func wikiNode(node map[string]any) string {
+	nodeType, _ := node["type"].(string)
+	attrs, _ := node["attrs"].(map[string]any)
+
+	switch nodeType {
+	case "paragraph":
+		return wikiInline(node)
+	case "heading":
+		level := min(max(intAttr(attrs, "level", 1), 1), 6)
+		return fmt.Sprintf("h%d. %s", level, wikiInline(node))
+	case "bulletList", "orderedList", "taskList":
+		return wikiList(node, "")
+	case "codeBlock":
+		lang, _ := attrs["language"].(string)
+		if lang != "" {
+			return fmt.Sprintf("{code:%s}\n%s\n{code}", lang, plainText(node))
+		}
+		return fmt.Sprintf("{code}\n%s\n{code}", plainText(node))
+	case "blockquote":
+		return fmt.Sprintf("{quote}\n%s\n{quote}", wikiBlocks(node))
+	case "rule":
+		return "----"
+	case "panel", "expand", "nestedExpand":
+		title, _ := attrs["title"].(string)
+		if panelType, _ := attrs["panelType"].(string); panelType != "" {
+			title = strings.ToUpper(panelType[:1]) + panelType[1:]
+		}
+		if title == "" {
+			return fmt.Sprintf("{panel}\n%s\n{panel}", wikiBlocks(node))
+		}
+		return fmt.Sprintf("{panel:title=%s}\n%s\n{panel}", escapeWiki(title), wikiBlocks(node))
+	case "table":
+		return wikiTable(node)
+	case "mediaSingle", "mediaGroup":
+		var images []string
+		content, _ := node["content"].([]any)
+		for _, child := range content {
+			if childMap, ok := child.(map[string]any); ok {
+				images = append(images, wikiNode(childMap))
+			}
+		}
+		return strings.Join(images, " ")
+	case "media":
+		if name, _ := attrs["filename"].(string); name != "" {
+			return "!" + name + "!"
+		}
+		if url, _ := attrs["url"].(string); url != "" {
+			return "!" + url + "!"
+		}
+		alt, _ := attrs["alt"].(string)
+		return "[" + escapeWiki(cmp.Or(alt, "attachment")) + "]"
+	default:
+		return wikiInlineNode(node)
+	}
+}
+
+// wikiList renders a list whose items are prefixed with prefix plus the
+// marker of the list type, so nested lists of any type stack their markers.
+func wikiList(node map[string]any, prefix string) string {
+	nodeType, _ := node["type"].(string)
+	marker := "*"
+	if nodeType == "orderedList" {
+		marker = "#"
+	}
+	prefix += marker
+
+	var lines []string
+	content, _ := node["content"].([]any)
+	for _, item := range content {
+		itemMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if itemType, _ := itemMap["type"].(string); itemType == "taskItem" {
+			text := wikiInline(itemMap)
+			if attrs, ok := itemMap["attrs"].(map[string]any); ok && attrs["state"] == "DONE" {
+				text = "(/) " + text
+			}
+			lines = append(lines, prefix+" "+text)
+			continue
+		}
+
+		var text []string
+		var nested []string
+		children, _ := itemMap["content"].([]any)
+		for _, child := range children {
+			childMap, ok := child.(map[string]any)
+			if !ok {
+				continue
+			}
+			switch childType, _ := childMap["type"].(string); childType {
+			case "bulletList", "orderedList", "taskList":
+				nested = append(nested, wikiList(childMap, prefix))
+			default:
+				text = append(text, wikiNode(childMap))
+			}
+		}
+		lines = append(lines, prefix+" "+strings.Join(text, " "))
+		lines = append(lines, nested...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wikiTable renders a table, with || separating header cells.
+func wikiTable(node map[string]any) string {
+	var rows []string
+	content, _ := node["content"].([]any)
+	for _, row := range content {
+		rowMap, ok := row.(map[string]any)
+		if !ok {
+			continue
+		}
+		var sb strings.Builder
+		sep := "|"
+		cells, _ := rowMap["content"].([]any)
+		for _, cell := range cells {
+			cellMap, ok := cell.(map[string]any)
+			if !ok {
+				continue
+			}
+			sep = "|"
+			if cellType, _ := cellMap["type"].(string); cellType == "tableHeader" {
+				sep = "||"
+			}
+			text := strings.ReplaceAll(wikiBlocks(cellMap), "\n\n", "\\\\ ")
+			sb.WriteString(sep + cmp.Or(text, " "))
+		}
+		line := sb.String() + sep
+		rows = append(rows, line)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// wikiInline renders the inline children of a node.
+func wikiInline(node map[string]any) string {
+	content, ok := node["content"].([]any)
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, child := range content {
+		if childMap, ok := child.(map[string]any); ok {
+			sb.WriteString(wikiInlineNode(childMap))
+		}
+	}
+	return sb.String()
+}
+
+// wikiInlineNode converts an inline ADF node to wiki markup.
+func wikiInlineNode(node map[string]any) string {
+	nodeType, _ := node["type"].(string)
+	attrs, _ := node["attrs"].(map[string]any)
+
+	switch nodeType {
+	case "text":
+		text, _ := node["text"].(string)
+		marks, _ := node["marks"].([]any)
+		return wikiMarks(text, marks)
+	case "hardBreak":
+		return "\n"
+	case "mention":
+		id, _ := attrs["id"].(string)
+		if id == "" {
+			text, _ := attrs["text"].(string)
+			return escapeWiki(text)
+		}
+		return "[~" + id + "]"
+	case "emoji":
+		if text, _ := attrs["text"].(string); text != "" {
+			return text
+		}
+		shortName, _ := attrs["shortName"].(string)
+		return shortName
+	case "status":
+		text, _ := attrs["text"].(string)
+		if text == "" {
+			return ""
+		}
+		return "*" + escapeWiki(strings.ToUpper(text)) + "*"
+	case "date":
+		timestamp, _ := attrs["timestamp"].(string)
+		return FormatTimestamp(timestamp)
+	case "inlineCard":
+		url, _ := attrs["url"].(string)
+		return "[" + url + "]"
+	default:
+		return wikiInline(node)
+	}
+}
+
+// wikiMarks escapes text and applies its formatting marks.
+func wikiMarks(text string, marks []any) string {
+	var code bool
+	var href, color, subsup string
+	var wrap []string
+	for _, mark := range marks {
+		markMap, ok := mark.(map[string]any)
+		if !ok {
+			continue
+		}
+		attrs, _ := markMap["attrs"].(map[string]any)
+		switch markType, _ := markMap["type"].(string); markType {
+		case "code":
+			code = true
+		case "link":
+			href, _ = attrs["href"].(string)
+		case "em":
+			wrap = append(wrap, "_")
+		case "strong":
+			wrap = append(wrap, "*")
+		case "strike":
+			wrap = append(wrap, "-")
+		case "underline":
+			wrap = append(wrap, "+")
+		case "textColor":
+			color, _ = attrs["color"].(string)
+		case "subsup":
+			subsup, _ = attrs["type"].(string)
+		}
+	}
+
+	result := escapeWiki(text)
+	if code {
+		result = "{{" + result + "}}"
+	}
+	for _, w := range wrap {
+		result = w + result + w
+	}
+	switch subsup {
+	case "sub":
+		result = "~" + result + "~"
+	case "sup":
+		result = "^" + result + "^"
+	}
+	if color != "" {
+		result = fmt.Sprintf("{color:%s}%s{color}", color, result)
+	}
+	if href != "" {
+		result = fmt.Sprintf("[%s|%s]", result, href)
+	}
+	return result
+}
+
+// wikiEscaper backslash-escapes the characters that start wiki markup.
+var wikiEscaper = strings.NewReplacer(
+	`\`, `\\`, `{`, `\{`, `}`, `\}`, `[`, `\[`, `]`, `\]`, `|`, `\|`,
+	`*`, `\*`, `_`, `\_`, `^`, `\^`, `~`, `\~`, `+`, `\+`, `!`, `\!`,
+)
+
+func escapeWiki(text string) string {
+	return wikiEscaper.Replace(text)
+}
+
+// plainText returns the text of a node's children without marks, for code.
+func plainText(node map[string]any) string {
+	content, ok := node["content"].([]any)
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, child := range content {
+		if childMap, ok := child.(map[string]any); ok {
+			text, _ := childMap["text"].(string)
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
+}
+
+// intAttr reads a numeric attribute, which is an int in documents built by
+// FromMarkdown and a float64 in parsed JSON.
+func intAttr(attrs map[string]any, key string, def int) int {
+	switch v := attrs[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return def
+}
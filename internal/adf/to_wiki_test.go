@@ -0,0 +1,32 @@
+package adf
+
+import "testing"
+
+func TestToWiki(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"Heading", "# Title", "h1. Title"},
+		{"Marks", "**bold** _em_ `code` [x](https://e.com)", "*bold* _em_ {{code}} [x|https://e.com]"},
+		{"Nested_Bullets", "- a\n  - b\n- c", "* a\n** b\n* c"},
+		{"Ordered_List", "1. one\n2. two", "# one\n# two"},
+		{"Code_Block", "```go\nfmt.Println()\n```", "{code:go}\nfmt.Println()\n{code}"},
+		{"Blockquote", "> quoted", "{quote}\nquoted\n{quote}"},
+		{"Table", "| a | b |\n|---|---|\n| 1 | 2 |", "||a||b||\n|1|2|"},
+		{"Escaped_Markup", "a [b] {c} | d", `a \[b\] \{c\} \| d`},
+		{"Rule", "---", "----"},
+		{"Tasks", "- [x] done\n- [ ] todo", "* (/) done\n* todo"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ToWiki(FromMarkdown(tt.markdown)); got != tt.want {
+				t.Errorf("ToWiki(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
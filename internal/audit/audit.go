@@ -0,0 +1,321 @@
+// Package audit records every write operation to an append-only JSONL file so
+// changes made by the agent can be reviewed afterwards.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Result values of an Entry.
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// ErrDisabled is returned by Recent when no audit file is configured.
+var ErrDisabled = errors.New("audit log is disabled; set ATLASSIAN_AUDIT_LOG to enable it")
+
+// bodyKeys are params and field names holding document content, which can be
+// large or sensitive. They are hashed when Options.HashBodies is set.
+var bodyKeys = []string{"body", "description"}
+
+// Entry is one audit record.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Tool   string    `json:"tool"`
+	Verb   string    `json:"verb"`
+	Site   string    `json:"site,omitempty"`
+	Target string    `json:"target,omitempty"`
+	// Params are the verb params without checksums, which are kept apart.
+	Params    any               `json:"params,omitempty"`
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// Before holds the values of the written fields as read during checksum
+	// verification, just before the write.
+	Before map[string]string `json:"before,omitempty"`
+	Result string            `json:"result"`
+	Error  string            `json:"error,omitempty"`
+	// Output is the structured result of a successful write, e.g. the created
+	// key or fresh checksums.
+	Output map[string]any `json:"output,omitempty"`
+}
+
+// Options configures Setup.
+type Options struct {
+	// File is the audit file path. Auditing is disabled when empty.
+	File string
+	// HashBodies replaces body and description values with their SHA-256,
+	// as well as the request payload and field values of dry runs.
+	HashBodies bool
+}
+
+// Log appends entries to an audit file.
+type Log struct {
+	mu         sync.Mutex
+	file       *os.File
+	hashBodies bool
+}
+
+// Open opens or creates the audit file for appending.
+func Open(opts Options) (*Log, error) {
+	f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{file: f, hashBodies: opts.HashBodies}, nil
+}
+
+// Close closes the audit file.
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+// Write appends e as one JSON line.
+func (l *Log) Write(e Entry) error {
+	if l.hashBodies {
+		e.Params = hashParams(e.Params)
+		e.Before = hashBeforeBodies(e.Before)
+		e.Output = hashOutput(e.Output)
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Recent returns up to n of the latest entries, newest first. Lines that
+// cannot be parsed are skipped.
+func (l *Log) Recent(n int) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var e Entry
+			if json.Unmarshal(line, &e) == nil {
+				entries = append(entries, e)
+				if len(entries) > n {
+					entries = entries[1:]
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// current is the audit log installed by Setup; nil when auditing is disabled.
+var current *Log
+
+// Setup installs the audit log described by opts. The returned closer
+// releases the audit file, if any.
+func Setup(opts Options) (io.Closer, error) {
+	if opts.File == "" {
+		current = nil
+		return io.NopCloser(nil), nil
+	}
+	l, err := Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	current = l
+	return l, nil
+}
+
+// Enabled reports whether an audit file is configured.
+func Enabled() bool {
+	return current != nil
+}
+
+// Write appends e to the installed audit log. It does nothing when auditing
+// is disabled.
+func Write(e Entry) error {
+	if current == nil {
+		return nil
+	}
+	return current.Write(e)
+}
+
+// Recent returns up to n of the latest entries of the installed audit log,
+// newest first.
+func Recent(n int) ([]Entry, error) {
+	if current == nil {
+		return nil, ErrDisabled
+	}
+	return current.Recent(n)
+}
+
+// before collects pre-write field values for the write in progress.
+type before struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+type beforeKey struct{}
+
+// NewContext returns a context that collects the values captured by
+// CaptureBefore during one write.
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, beforeKey{}, &before{values: map[string]string{}})
+}
+
+// CaptureBefore records the current value of a field about to be written. It
+// does nothing outside a context from NewContext.
+func CaptureBefore(ctx context.Context, field, value string) {
+	b, ok := ctx.Value(beforeKey{}).(*before)
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values[field] = value
+}
+
+// Before returns the values captured in ctx, or nil if there are none.
+func Before(ctx context.Context) map[string]string {
+	b, ok := ctx.Value(beforeKey{}).(*before)
+	if !ok {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.values) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(b.values))
+	for k, v := range b.values {
+		values[k] = v
+	}
+	return values
+}
+
+// hashParams hashes the bodies in params. Params that are not a JSON object
+// are kept as the raw string sent, which may be a body itself, so the whole
+// string is hashed.
+func hashParams(params any) any {
+	if s, ok := params.(string); ok {
+		return hash(s)
+	}
+	return hashBodies(params)
+}
+
+// hashOutput hashes the bodies in the output of a write. The output of a dry
+// run also holds the whole request payload and the old and new values of
+// each changed field, which are hashed too.
+func hashOutput(output map[string]any) map[string]any {
+	if output == nil {
+		return nil
+	}
+	// Round-trip through JSON so typed values, such as the changes of a dry
+	// run, can be walked
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+
+	if payload, ok := out["payload"]; ok {
+		out["payload"] = hashValue(payload)
+	}
+	if changes, ok := out["changes"].([]any); ok {
+		for _, c := range changes {
+			change, _ := c.(map[string]any)
+			for _, k := range []string{"before", "after", "diff"} {
+				if v, ok := change[k]; ok {
+					change[k] = hashValue(v)
+				}
+			}
+		}
+	}
+	return hashBodies(out).(map[string]any)
+}
+
+// hashBodies returns a copy of v with body values replaced by their hash, at
+// any depth. Bodies sent as documents, such as ADF, are hashed whole.
+func hashBodies(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if slices.Contains(bodyKeys, k) && val != nil {
+				out[k] = hashValue(val)
+				continue
+			}
+			out[k] = hashBodies(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = hashBodies(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func hashBeforeBodies(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		if slices.Contains(bodyKeys, k) {
+			v = hash(v)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// hashValue hashes a string, or the JSON encoding of any other value.
+func hashValue(v any) string {
+	if s, ok := v.(string); ok {
+		return hash(s)
+	}
+	data, _ := json.Marshal(v)
+	return hash(string(data))
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
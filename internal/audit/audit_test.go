@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLog_Recent(t *testing.T) {
+	t.Parallel()
+	l, err := Open(Options{File: filepath.Join(t.TempDir(), "audit.jsonl")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for _, verb := range []string{"jira_add_comment", "jira_update_issue", "confluence_create_page"} {
+		if err := l.Write(Entry{Time: time.Now(), Verb: verb, Result: ResultSuccess}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{1, []string{"confluence_create_page"}},
+		{2, []string{"confluence_create_page", "jira_update_issue"}},
+		{10, []string{"confluence_create_page", "jira_update_issue", "jira_add_comment"}},
+	}
+	for _, tt := range tests {
+		entries, err := l.Recent(tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Verb)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Recent(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLog_HashBodies(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Options{File: path, HashBodies: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	err = l.Write(Entry{
+		Verb:   "jira_update_issue",
+		Params: map[string]any{"issue": "PROJ-1", "fields": map[string]any{"description": "secret plan", "summary": "Title"}},
+		Before: map[string]string{"description": "old plan", "summary": "Old title"},
+		Result: ResultSuccess,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(data)
+	for _, hidden := range []string{"secret plan", "old plan"} {
+		if strings.Contains(line, hidden) {
+			t.Errorf("audit line contains %q: %s", hidden, line)
+		}
+	}
+	for _, kept := range []string{"PROJ-1", `"summary":"Title"`, "Old title", "sha256:"} {
+		if !strings.Contains(line, kept) {
+			t.Errorf("audit line missing %q: %s", kept, line)
+		}
+	}
+}
+
+func TestLog_HashRawParams(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Options{File: path, HashBodies: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Params that are not a JSON object are kept as sent
+	if err := l.Write(Entry{Verb: "jira_add_comment", Params: "secret plan", Result: ResultError}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := string(data); strings.Contains(line, "secret plan") || !strings.Contains(line, `"params":"sha256:`) {
+		t.Errorf("audit line = %s, want hashed params", line)
+	}
+}
+
+func TestLog_HashDryRunOutput(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Options{File: path, HashBodies: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	adf := map[string]any{
+		"type":    "doc",
+		"content": []any{map[string]any{"type": "text", "text": "secret plan"}},
+	}
+	err = l.Write(Entry{
+		Verb:   "jira_update_issue",
+		Params: map[string]any{"issue": "PROJ-1", "fields": map[string]any{"description": adf}},
+		Result: ResultSuccess,
+		Output: map[string]any{
+			"dryRun":   true,
+			"endpoint": "/rest/api/3/issue/PROJ-1",
+			"payload":  map[string]any{"fields": map[string]any{"description": adf}},
+			"changes": []map[string]any{
+				{"field": "description", "before": "old plan", "after": "secret plan", "diff": "-old plan\n+secret plan"},
+			},
+			"page": map[string]any{"id": "1", "body": adf},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(data)
+	for _, hidden := range []string{"secret plan", "old plan"} {
+		if strings.Contains(line, hidden) {
+			t.Errorf("audit line contains %q: %s", hidden, line)
+		}
+	}
+	for _, kept := range []string{"PROJ-1", `"dryRun":true`, `"field":"description"`, "sha256:"} {
+		if !strings.Contains(line, kept) {
+			t.Errorf("audit line missing %q: %s", kept, line)
+		}
+	}
+}
+
+func TestCaptureBefore(t *testing.T) {
+	t.Parallel()
+
+	// Without a collecting context, capturing is a no-op
+	CaptureBefore(context.Background(), "summary", "ignored")
+	if got := Before(context.Background()); got != nil {
+		t.Errorf("Before() without NewContext = %v, want nil", got)
+	}
+
+	ctx := NewContext(context.Background())
+	if got := Before(ctx); got != nil {
+		t.Errorf("Before() with nothing captured = %v, want nil", got)
+	}
+	CaptureBefore(ctx, "summary", "Old title")
+	CaptureBefore(ctx, "labels", "a,b")
+	got := Before(ctx)
+	if got["summary"] != "Old title" || got["labels"] != "a,b" || len(got) != 2 {
+		t.Errorf("Before() = %v", got)
+	}
+}
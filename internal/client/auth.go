@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Auth authorizes requests to Atlassian.
+type Auth interface {
+	// Authorize sets the credentials of req.
+	Authorize(req *http.Request) error
+}
+
+// Refresher is implemented by auth providers whose credentials expire. When
+// Atlassian rejects a request with 401, the client calls Refresh once and
+// sends the request again.
+type Refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// BasicAuth authenticates with an account email and API token.
+type BasicAuth struct {
+	Email string
+	Token string
+}
+
+func (a BasicAuth) Authorize(req *http.Request) error {
+	req.SetBasicAuth(a.Email, a.Token)
+	return nil
+}
+
+// BearerAuth authenticates with a bearer token, such as a scoped API token or
+// a Data Center personal access token.
+type BearerAuth struct {
+	Token string
+}
+
+func (a BearerAuth) Authorize(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// authError reports that a request could not be authorized. It is never
+// retried since sending the request again would fail the same way.
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string { return "failed to authorize request: " + e.err.Error() }
+
+func (e *authError) Unwrap() error { return e.err }
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"atlassian-mcp/internal/logging"
+)
+
+const (
+	// maxCacheEntries bounds the responses kept by a Cache.
+	maxCacheEntries = 1000
+
+	// defaultCacheTTL applies to endpoints without a rule in cacheTTLs,
+	// such as issues, pages and comments.
+	defaultCacheTTL = time.Minute
+)
+
+// cacheTTLs sets how long responses stay fresh, by endpoint. The first
+// matching rule wins. Users, projects, spaces and issue types rarely change;
+// search results change with every write anywhere on the site.
+var cacheTTLs = []struct {
+	path *regexp.Regexp
+	ttl  time.Duration
+}{
+	{searchPath, 30 * time.Second},
+	{regexp.MustCompile(`/(user|myself|project|space|spaces|issuetype)\b`), 10 * time.Minute},
+}
+
+// searchPath matches the search endpoints of Jira and Confluence.
+var searchPath = regexp.MustCompile(`/search\b`)
+
+// cacheTargetPatterns extract the issue or page an endpoint belongs to, so a
+// write can invalidate every cached read of it.
+var cacheTargetPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`/issue/([A-Za-z][A-Za-z0-9_]*-\d+|\d+)(?:[/?]|$)`),
+	regexp.MustCompile(`/(?:pages|content)/(\d+)(?:[/?]|$)`),
+}
+
+// Cache keeps GET responses for reuse by later calls. Responses are fresh for
+// the TTL of their endpoint; stale responses with an ETag are revalidated
+// with a conditional request. Entries are keyed by method, URL and site, so
+// one Cache can serve the clients of several sites. It is safe for
+// concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	now     func() time.Time
+	// writes counts invalidations, so a read that raced a write is not
+	// stored with the state from before it.
+	writes uint64
+}
+
+type cacheEntry struct {
+	site    string
+	target  string // issue or page the response belongs to, or ""
+	search  bool
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// NewCache returns an empty cache.
+func NewCache() *Cache {
+	return &Cache{entries: map[string]*cacheEntry{}, now: time.Now}
+}
+
+type noCacheKey struct{}
+
+// NoCache marks calls made with the returned context as needing the current
+// state, such as reads that verify checksums before a write. They bypass the
+// cache, and their responses are not stored.
+func NoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func bypassCache(ctx context.Context) bool {
+	bypass, _ := ctx.Value(noCacheKey{}).(bool)
+	return bypass
+}
+
+// cacheTTL returns how long a response from url stays fresh.
+func cacheTTL(url string) time.Duration {
+	path, _, _ := strings.Cut(url, "?")
+	for _, rule := range cacheTTLs {
+		if rule.path.MatchString(path) {
+			return rule.ttl
+		}
+	}
+	return defaultCacheTTL
+}
+
+// cacheTarget returns the issue key or page ID that url belongs to.
+func cacheTarget(url string) string {
+	for _, pattern := range cacheTargetPatterns {
+		if m := pattern.FindStringSubmatch(url); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func cacheKey(site, method, url string) string {
+	return site + "\x00" + method + " " + url
+}
+
+// get returns the entry for key, whether it is still fresh, and the write
+// count to pass to put.
+func (c *Cache) get(key string) (cacheEntry, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false, c.writes
+	}
+	return *e, c.now().Before(e.expires), c.writes
+}
+
+// put stores a response read when the write count was writes, unless a
+// write has happened since. Responses without an ETag are dropped once
+// stale; with one they are kept for revalidation until evicted.
+func (c *Cache) put(key string, e cacheEntry, ttl time.Duration, writes uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if writes != c.writes {
+		return
+	}
+	now := c.now()
+	e.expires = now.Add(ttl)
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		c.evict(now)
+	}
+	c.entries[key] = &e
+}
+
+// evict drops stale entries without an ETag or, when there are none, the
+// entry closest to expiry. Callers hold c.mu.
+func (c *Cache) evict(now time.Time) {
+	var oldest string
+	for k, e := range c.entries {
+		if !now.Before(e.expires) && e.etag == "" {
+			delete(c.entries, k)
+			continue
+		}
+		if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+			oldest = k
+		}
+	}
+	if len(c.entries) >= maxCacheEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+// invalidate drops what a successful write to url on site may have changed:
+// the reads of the issue or page written to, and every search. Writes to
+// endpoints that name neither, such as new issues or Confluence comments,
+// drop all entries of the site.
+func (c *Cache) invalidate(site, url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes++
+	target := cacheTarget(url)
+	for k, e := range c.entries {
+		if e.site == site && (target == "" || e.target == target || e.search) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// cachedGet serves a GET from the cache, revalidating stale entries that
+// carry an ETag, and stores fresh responses.
+func (c *Client) cachedGet(ctx context.Context, svc Service, url string) ([]byte, error) {
+	key := cacheKey(c.Site, http.MethodGet, url)
+	cached, fresh, writes := c.Cache.get(key)
+	if fresh {
+		logging.FromContext(ctx).DebugContext(ctx, "atlassian cache hit", "url", url)
+		return cached.body, nil
+	}
+
+	var header http.Header
+	if cached.etag != "" {
+		header = http.Header{"If-None-Match": {cached.etag}}
+	}
+	body, resp, err := c.exchange(ctx, svc, http.MethodGet, url, nil, header)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := cacheTTL(url)
+	if resp.StatusCode == http.StatusNotModified {
+		logging.FromContext(ctx).DebugContext(ctx, "atlassian cache revalidated", "url", url)
+		c.Cache.put(key, cached, ttl, writes)
+		return cached.body, nil
+	}
+	path, _, _ := strings.Cut(url, "?")
+	c.Cache.put(key, cacheEntry{
+		site:   c.Site,
+		target: cacheTarget(url),
+		search: searchPath.MatchString(path),
+		body:   body,
+		etag:   resp.Header.Get("ETag"),
+	}, ttl, writes)
+	return body, nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		url  string
+		want time.Duration
+	}{
+		{"https://x.atlassian.net/rest/api/3/issue/PROJ-1?expand=renderedFields", time.Minute},
+		{"https://x.atlassian.net/wiki/api/v2/pages/123?body-format=atlas_doc_format", time.Minute},
+		{"https://x.atlassian.net/wiki/rest/api/search?cql=space%3DDEV", 30 * time.Second},
+		{"https://x.atlassian.net/rest/api/3/user/picker?query=jo", 10 * time.Minute},
+		{"https://x.atlassian.net/rest/api/3/project/search", 30 * time.Second},
+		{"https://x.atlassian.net/rest/api/3/project/PROJ", 10 * time.Minute},
+		{"https://x.atlassian.net/wiki/api/v2/spaces", 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.url, func(t *testing.T) {
+			t.Parallel()
+			if got := cacheTTL(tt.url); got != tt.want {
+				t.Errorf("cacheTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheTarget(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://x.atlassian.net/rest/api/3/issue/PROJ-1?expand=renderedFields", "PROJ-1"},
+		{"https://x.atlassian.net/rest/api/3/issue/PROJ-1/comment", "PROJ-1"},
+		{"https://x.atlassian.net/rest/api/3/issue/PROJ-12", "PROJ-12"},
+		{"https://x.atlassian.net/rest/api/3/issue", ""},
+		{"https://x.atlassian.net/wiki/api/v2/pages/123/footer-comments", "123"},
+		{"https://wiki.example.com/rest/api/content/123?expand=version", "123"},
+		{"https://wiki.example.com/rest/api/content/search?cql=x", ""},
+		{"https://x.atlassian.net/wiki/api/v2/footer-comments", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.url, func(t *testing.T) {
+			t.Parallel()
+			if got := cacheTarget(tt.url); got != tt.want {
+				t.Errorf("cacheTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Cache(t *testing.T) {
+	t.Parallel()
+	var gets, conditional atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		gets.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"key":"` + r.URL.Path + `"}`))
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	cache := NewCache()
+	cache.now = func() time.Time { return now }
+	c := &Client{Site: "company", JiraURL: ts.URL, Auth: BasicAuth{}, HTTP: ts.Client(), Cache: cache}
+	other := &Client{Site: "customer", JiraURL: ts.URL, Auth: BasicAuth{}, HTTP: ts.Client(), Cache: cache}
+	ctx := context.Background()
+
+	steps := []struct {
+		name            string
+		run             func() error
+		wantGets        int32
+		wantConditional int32
+	}{
+		{"first read", func() error { _, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1"); return err }, 1, 0},
+		{"cached read", func() error { _, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1"); return err }, 1, 0},
+		{"other site", func() error { _, err := other.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1"); return err }, 2, 0},
+		{"checksum read", func() error { _, err := c.Request(NoCache(ctx), Jira, "/rest/api/3/issue/PROJ-1"); return err }, 3, 0},
+		{"stale read revalidated", func() error {
+			now = now.Add(2 * time.Minute)
+			_, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1")
+			return err
+		}, 4, 1},
+		{"revalidated read cached", func() error { _, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1"); return err }, 4, 1},
+		{"write to other issue", func() error { _, err := c.Put(ctx, Jira, "/rest/api/3/issue/PROJ-2", []byte(`{}`)); return err }, 4, 1},
+		{"read after unrelated write", func() error { _, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1"); return err }, 4, 1},
+		{"write to issue", func() error { _, err := c.Put(ctx, Jira, "/rest/api/3/issue/PROJ-1", []byte(`{}`)); return err }, 4, 1},
+		{"read after write", func() error { _, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1"); return err }, 5, 1},
+		{"idempotent post keeps cache", func() error {
+			_, err := c.Post(Idempotent(ctx), Jira, "/rest/api/3/search/jql", []byte(`{}`))
+			return err
+		}, 5, 1},
+		{"read after search", func() error { _, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1"); return err }, 5, 1},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		if got := gets.Load(); got != step.wantGets {
+			t.Errorf("%s: GET requests = %d, want %d", step.name, got, step.wantGets)
+		}
+		if got := conditional.Load(); got != step.wantConditional {
+			t.Errorf("%s: conditional requests = %d, want %d", step.name, got, step.wantConditional)
+		}
+	}
+
+	body, err := c.Request(ctx, Jira, "/rest/api/3/issue/PROJ-1")
+	if err != nil || string(body) != `{"key":"/rest/api/3/issue/PROJ-1"}` {
+		t.Errorf("cached body = %s, %v", body, err)
+	}
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// scrubbedHeaders are never written to a cassette.
+var scrubbedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Cassette records HTTP exchanges to a directory of fixtures, one JSON file
+// per exchange, or replays them without network access. Credentials are
+// scrubbed from recorded headers. Replayed requests are matched by method,
+// URL and body; repeated requests are answered with the responses recorded
+// for them in order, the last one once they run out.
+type Cassette struct {
+	dir string
+	// next sends the requests being recorded; nil when replaying.
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	recorded int
+	tracks   map[string][]*fixture
+	played   map[string]int
+}
+
+// fixture is one recorded exchange.
+type fixture struct {
+	Request  fixtureMessage `json:"request"`
+	Response fixtureMessage `json:"response"`
+}
+
+type fixtureMessage struct {
+	Method string      `json:"method,omitempty"`
+	URL    string      `json:"url,omitempty"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	// JSON holds a JSON body, kept readable; Body holds any other.
+	JSON json.RawMessage `json:"json,omitempty"`
+	Body []byte          `json:"body,omitempty"`
+}
+
+// RecordCassette returns a cassette that sends requests and saves each
+// exchange to dir, after the fixtures already there.
+func RecordCassette(dir string) (*Cassette, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	fixtures, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &Cassette{dir: dir, next: defaultTransport, recorded: len(fixtures)}, nil
+}
+
+// ReplayCassette returns a cassette that answers requests from the fixtures
+// in dir.
+func ReplayCassette(dir string) (*Cassette, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no cassette fixtures in %s", dir)
+	}
+	sort.Strings(files)
+
+	k := &Cassette{dir: dir, tracks: map[string][]*fixture{}, played: map[string]int{}}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("invalid cassette fixture %s: %w", filepath.Base(file), err)
+		}
+		key := exchangeKey(f.Request.Method, f.Request.URL, f.Request.Header, f.Request.body())
+		k.tracks[key] = append(k.tracks[key], &f)
+	}
+	return k, nil
+}
+
+// HTTPClient returns an HTTP client that sends requests through k, for the
+// HTTP field of a Client. Calls are logged like those of HTTPClient.
+func (k *Cassette) HTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   HTTPClient.Timeout,
+		Transport: &loggingTransport{base: k},
+	}
+}
+
+// RoundTrip records or replays one exchange.
+func (k *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if k.next == nil {
+		return k.replay(req, reqBody)
+	}
+
+	sent := req.Clone(req.Context())
+	if reqBody != nil {
+		sent.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	resp, err := k.next.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := k.record(req, reqBody, resp, respBody); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (k *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	url := req.URL.String()
+	key := exchangeKey(req.Method, url, req.Header, body)
+
+	k.mu.Lock()
+	track := k.tracks[key]
+	n := k.played[key]
+	k.played[key]++
+	k.mu.Unlock()
+
+	if len(track) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s in cassette %s", req.Method, url, k.dir)
+	}
+	f := track[min(n, len(track)-1)]
+	respBody := f.Response.body()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Response.Status, http.StatusText(f.Response.Status)),
+		StatusCode:    f.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+func (k *Cassette) record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) error {
+	f := fixture{
+		Request:  fixtureMessage{Method: req.Method, URL: req.URL.String(), Header: scrub(req.Header)},
+		Response: fixtureMessage{Status: resp.StatusCode, Header: scrub(resp.Header)},
+	}
+	f.Request.setBody(reqBody)
+	f.Response.setBody(respBody)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette fixture")
+	}
+
+	k.mu.Lock()
+	k.recorded++
+	name := fmt.Sprintf("%04d-%s.json", k.recorded, fixtureSlug(req))
+	k.mu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(k.dir, name), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write cassette fixture: %w", err)
+	}
+	return nil
+}
+
+func (m *fixtureMessage) setBody(body []byte) {
+	if len(body) > 0 && json.Valid(body) {
+		m.JSON = body
+		return
+	}
+	m.Body = body
+}
+
+// body returns the message body, compacting JSON bodies indented for the
+// fixture.
+func (m fixtureMessage) body() []byte {
+	if m.JSON == nil {
+		return m.Body
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, m.JSON) != nil {
+		return m.JSON
+	}
+	return compact.Bytes()
+}
+
+// exchangeKey identifies a request for replay. JSON bodies are compared
+// without their formatting; multipart bodies are left out, since their
+// boundaries are random.
+func exchangeKey(method, url string, header http.Header, body []byte) string {
+	if strings.HasPrefix(header.Get("Content-Type"), "multipart/") {
+		body = nil
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		body = compact.Bytes()
+	}
+	return method + " " + url + "\n" + string(body)
+}
+
+func scrub(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range scrubbedHeaders {
+		h.Del(name)
+	}
+	return h
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// fixtureSlug names a fixture after the method and path of its request.
+func fixtureSlug(req *http.Request) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(req.Method+" "+req.URL.Path), "-"), "-")
+	if len(slug) > 80 {
+		slug = strings.TrimRight(slug[:80], "-")
+	}
+	return slug
+}
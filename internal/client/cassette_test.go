@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCassette_RecordReplay(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"call":` + strconv.Itoa(int(n)) + `}`))
+	}))
+
+	dir := t.TempDir()
+	recorder, err := RecordCassette(dir)
+	if err != nil {
+		t.Fatalf("RecordCassette() error = %v", err)
+	}
+	c := &Client{JiraURL: ts.URL, Auth: BearerAuth{Token: "s3cret-token"}, HTTP: recorder.HTTPClient()}
+	ctx := context.Background()
+	for _, step := range []struct{ method, body string }{
+		{http.MethodGet, ""},
+		{http.MethodPut, `{"fields":{"summary":"x"}}`},
+		{http.MethodGet, ""},
+	} {
+		var body []byte
+		if step.body != "" {
+			body = []byte(step.body)
+		}
+		if _, err := c.do(ctx, Jira, step.method, c.URL(Jira, "/rest/api/3/issue/PROJ-1"), body); err != nil {
+			t.Fatalf("recording %s error = %v", step.method, err)
+		}
+	}
+	ts.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 3 {
+		t.Fatalf("recorded %d fixtures, want 3", len(files))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "s3cret-token") || strings.Contains(string(data), "session=secret") {
+			t.Errorf("%s holds credentials:\n%s", filepath.Base(file), data)
+		}
+	}
+	if got := filepath.Base(files[0]); got != "0001-get-rest-api-3-issue-proj-1.json" {
+		t.Errorf("fixture name = %q", got)
+	}
+
+	player, err := ReplayCassette(dir)
+	if err != nil {
+		t.Fatalf("ReplayCassette() error = %v", err)
+	}
+	c = &Client{JiraURL: ts.URL, Auth: BearerAuth{}, HTTP: player.HTTPClient()}
+	tests := []struct {
+		method, body string
+		want         string
+		wantErr      bool
+	}{
+		{http.MethodGet, "", `{"call":1}`, false},
+		{http.MethodPut, "{\n  \"fields\": {\"summary\": \"x\"}\n}", "", false},
+		{http.MethodGet, "", `{"call":3}`, false},
+		// Responses run out: the last one repeats
+		{http.MethodGet, "", `{"call":3}`, false},
+		{http.MethodPut, `{"fields":{"summary":"y"}}`, "", true},
+	}
+	for _, tt := range tests {
+		var body []byte
+		if tt.body != "" {
+			body = []byte(tt.body)
+		}
+		got, err := c.do(ctx, Jira, tt.method, c.URL(Jira, "/rest/api/3/issue/PROJ-1"), body)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("replay %s %s error = %v, wantErr %v", tt.method, tt.body, err, tt.wantErr)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("replay %s = %s, want %s", tt.method, got, tt.want)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("server calls = %d, want 3 (replay must not reach the network)", n)
+	}
+}
+
+func TestReplayCassette_Empty(t *testing.T) {
+	t.Parallel()
+	if _, err := ReplayCassette(t.TempDir()); err == nil {
+		t.Error("ReplayCassette() of an empty directory succeeded")
+	}
+}
@@ -2,26 +2,80 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
-	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/logging"
 )
 
-// HTTPClient is the shared HTTP client with timeout and TLS hardening.
+// HTTPClient is the default HTTP client of a Client, with timeout and TLS
+// hardening. Every call through it is logged.
 var HTTPClient = &http.Client{
-	Timeout: 30 * time.Second,
-	Transport: &http.Transport{
-		TLSClientConfig: &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		},
+	Timeout:   30 * time.Second,
+	Transport: &loggingTransport{base: defaultTransport},
+}
+
+// defaultTransport sends the requests of HTTPClient.
+var defaultTransport = &http.Transport{
+	TLSClientConfig: &tls.Config{
+		MinVersion: tls.VersionTLS12,
 	},
 }
 
+// maxLoggedBody caps how much of an error response body is logged.
+const maxLoggedBody = 1024
+
+// loggingTransport logs each Atlassian API call with its endpoint, status
+// and latency. Error response bodies are included as received, since the
+// APIError returned to the agent keeps only sanitized, truncated messages and
+// the request ID. Headers are never logged.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	attrs := []slog.Attr{
+		slog.String("http_method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("endpoint", req.URL.RequestURI()),
+		slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+	}
+	logger := logging.FromContext(ctx)
+
+	switch {
+	case err != nil:
+		level := slog.LevelError
+		if ctx.Err() != nil {
+			level = slog.LevelDebug
+		}
+		logger.LogAttrs(ctx, level, "atlassian request failed", append(attrs, slog.String("error", err.Error()))...)
+	case resp.StatusCode >= 400:
+		// Peek at the body without consuming it for the caller
+		peek, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+		logger.LogAttrs(ctx, slog.LevelWarn, "atlassian request returned error",
+			append(attrs, slog.Int("status", resp.StatusCode), slog.String("body", string(peek)))...)
+	default:
+		logger.LogAttrs(ctx, slog.LevelDebug, "atlassian request", append(attrs, slog.Int("status", resp.StatusCode))...)
+	}
+	return resp, err
+}
+
 // Service identifies which Atlassian service to use.
 type Service string
 
@@ -30,15 +84,6 @@ const (
 	Confluence Service = "confluence"
 )
 
-func baseURL(svc Service) string {
-	switch svc {
-	case Confluence:
-		return config.ConfluenceBaseURL()
-	default:
-		return config.JiraBaseURL()
-	}
-}
-
 func serviceName(svc Service) string {
 	switch svc {
 	case Confluence:
@@ -48,114 +93,359 @@ func serviceName(svc Service) string {
 	}
 }
 
-func authHeader() string {
-	return "Basic " + base64.StdEncoding.EncodeToString([]byte(config.Email+":"+config.Token))
+// Client calls the Jira and Confluence REST APIs of one Atlassian site.
+type Client struct {
+	// Site names the credential profile of the site, shown with content
+	// read from it.
+	Site string
+	// JiraURL and ConfluenceURL are the base URLs that API endpoints are
+	// appended to, e.g. https://example.atlassian.net and
+	// https://example.atlassian.net/wiki.
+	JiraURL       string
+	ConfluenceURL string
+	// Auth authorizes every request.
+	Auth Auth
+	// DataCenter selects the REST APIs of Jira Data Center and Confluence
+	// Data Center or Server: Jira /rest/api/2 with wiki markup bodies and
+	// Confluence /rest/api/content with storage format bodies.
+	DataCenter bool
+	// HTTP sends requests. Nil uses HTTPClient.
+	HTTP *http.Client
+	// Cache serves repeated GET requests when set; see Cache.
+	Cache *Cache
+
+	// retry is the retry policy; the zero policy makes one attempt.
+	retry retryPolicy
+	// limits paces the requests to each service; services without one are
+	// not limited.
+	limits map[Service]*limiter
 }
 
-func handleStatusCode(svc Service, statusCode int) error {
-	name := serviceName(svc)
-	switch statusCode {
-	case 400:
-		return fmt.Errorf("bad request (HTTP 400)")
-	case 401:
-		return fmt.Errorf("authentication failed (HTTP 401)")
-	case 403:
-		return fmt.Errorf("access denied (HTTP 403)")
-	case 404:
-		return fmt.Errorf("not found or no permission (HTTP 404)")
-	default:
-		return fmt.Errorf("%s API error (HTTP %d)", name, statusCode)
+// New returns a client for the Cloud site at domain, e.g.
+// example.atlassian.net, that retries as configured.
+func New(domain string, auth Auth) *Client {
+	return &Client{
+		JiraURL:       "https://" + domain,
+		ConfluenceURL: "https://" + domain + "/wiki",
+		Auth:          auth,
+		HTTP:          HTTPClient,
+		retry:         configuredPolicy(),
+		limits:        configuredLimiters(),
 	}
 }
 
-// Request performs a GET request to the specified service.
-func Request(svc Service, endpoint string) ([]byte, error) {
-	url := baseURL(svc) + endpoint
+// gatewayURL is the Atlassian API gateway that OAuth requests go through.
+const gatewayURL = "https://api.atlassian.com/ex"
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request")
+// NewGateway returns a client for the Cloud site with cloudID that sends
+// requests through the Atlassian API gateway, as OAuth access tokens require.
+func NewGateway(cloudID string, auth Auth) *Client {
+	return &Client{
+		JiraURL:       gatewayURL + "/jira/" + cloudID,
+		ConfluenceURL: gatewayURL + "/confluence/" + cloudID + "/wiki",
+		Auth:          auth,
+		HTTP:          HTTPClient,
+		retry:         configuredPolicy(),
+		limits:        configuredLimiters(),
 	}
+}
 
-	req.Header.Set("Authorization", authHeader())
-	req.Header.Set("Accept", "application/json")
+// NewDataCenter returns a client for self-hosted Jira and Confluence at the
+// given base URLs, e.g. https://jira.example.com and
+// https://confluence.example.com, that retries as configured.
+func NewDataCenter(jiraURL, confluenceURL string, auth Auth) *Client {
+	return &Client{
+		JiraURL:       strings.TrimSuffix(jiraURL, "/"),
+		ConfluenceURL: strings.TrimSuffix(confluenceURL, "/"),
+		Auth:          auth,
+		DataCenter:    true,
+		HTTP:          HTTPClient,
+		retry:         configuredPolicy(),
+		limits:        configuredLimiters(),
+	}
+}
+
+// URL returns the absolute URL of an endpoint of svc.
+func (c *Client) URL(svc Service, endpoint string) string {
+	if svc == Confluence {
+		return c.ConfluenceURL + endpoint
+	}
+	return c.JiraURL + endpoint
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP == nil {
+		return HTTPClient
+	}
+	return c.HTTP
+}
 
-	resp, err := HTTPClient.Do(req)
+// Do authorizes req and sends it, for requests the JSON helpers don't cover
+// such as multipart uploads, once the rate limits of its service allow. Like
+// the JSON helpers, it refreshes expired credentials once on a 401 and sends
+// req again, provided its body can be replayed through GetBody. The caller
+// closes the response body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.doWith(c.httpClient(), req)
+}
+
+// doWith is Do sending through hc.
+func (c *Client) doWith(hc *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := c.roundTrip(hc, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to %s", serviceName(svc))
+		return nil, err
 	}
-	defer resp.Body.Close()
+	r, ok := c.Auth.(Refresher)
+	if !ok || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	// Expired credentials: the request was not applied, so it can be sent
+	// again once they are refreshed
+	if err := r.Refresh(req.Context()); err != nil {
+		return nil, &authError{err}
+	}
+	again := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if again.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("failed to create request")
+		}
+	}
+	return c.roundTrip(hc, again)
+}
 
-	body, err := io.ReadAll(resp.Body)
+// roundTrip authorizes req and sends it once through hc.
+func (c *Client) roundTrip(hc *http.Client, req *http.Request) (*http.Response, error) {
+	if err := c.Auth.Authorize(req); err != nil {
+		return nil, &authError{err}
+	}
+	svc := c.service(req.URL.String())
+	release, err := c.limits[svc].acquire(req.Context())
+	if err != nil {
+		return nil, connectError(req.Context(), svc)
+	}
+	resp, err := hc.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response")
+		release()
+		return nil, err
+	}
+	c.limits[svc].observe(resp.Header, resp.StatusCode)
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	if req.Method != http.MethodGet && resp.StatusCode < 300 && c.Cache != nil {
+		c.Cache.invalidate(c.Site, req.URL.String())
 	}
+	return resp, nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, handleStatusCode(svc, resp.StatusCode)
+// service returns the service that url belongs to.
+func (c *Client) service(url string) Service {
+	if c.ConfluenceURL != "" && strings.HasPrefix(url, c.ConfluenceURL+"/") {
+		return Confluence
 	}
+	return Jira
+}
 
-	return body, nil
+// releasingBody ends a request for the limiter once its body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
 }
 
-// Post performs a POST request to the specified service.
-func Post(svc Service, endpoint string, body []byte) ([]byte, error) {
-	url := baseURL(svc) + endpoint
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+// Location returns where url redirects to without following the redirect,
+// or "" when it doesn't redirect. Like Do, it waits for the rate limits and
+// refreshes expired credentials once.
+func (c *Client) Location(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request")
+		return "", fmt.Errorf("failed to create request")
 	}
-
-	req.Header.Set("Authorization", authHeader())
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := HTTPClient.Do(req)
+	noRedirect := *c.httpClient()
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := c.doWith(&noRedirect, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to %s", serviceName(svc))
+		return "", err
 	}
-	defer resp.Body.Close()
+	resp.Body.Close()
+	return resp.Header.Get("Location"), nil
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response")
+// connectError reports a transport failure, distinguishing cancellation by the
+// MCP client from genuine connectivity problems.
+func connectError(ctx context.Context, svc Service) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("request cancelled: %w", ctx.Err())
 	}
+	return fmt.Errorf("failed to connect to %s", serviceName(svc))
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, handleStatusCode(svc, resp.StatusCode)
+// Request performs a GET request to the specified service, through the
+// cache unless there is none or ctx is marked with NoCache.
+func (c *Client) Request(ctx context.Context, svc Service, endpoint string) ([]byte, error) {
+	if c.Cache != nil && !bypassCache(ctx) {
+		return c.cachedGet(ctx, svc, c.URL(svc, endpoint))
 	}
+	return c.do(ctx, svc, http.MethodGet, c.URL(svc, endpoint), nil)
+}
 
-	return respBody, nil
+// Post performs a POST request to the specified service. It is only retried
+// when ctx is marked with Idempotent or ATLASSIAN_RETRY_WRITES is set.
+// Unless marked Idempotent, it is a write that invalidates cached reads.
+func (c *Client) Post(ctx context.Context, svc Service, endpoint string, body []byte) ([]byte, error) {
+	return c.write(ctx, svc, http.MethodPost, c.URL(svc, endpoint), body)
+}
+
+// Put performs a PUT request to the specified service. Like Post, it is only
+// retried when ctx is marked with Idempotent or ATLASSIAN_RETRY_WRITES is set.
+func (c *Client) Put(ctx context.Context, svc Service, endpoint string, body []byte) ([]byte, error) {
+	return c.write(ctx, svc, http.MethodPut, c.URL(svc, endpoint), body)
+}
+
+// write sends a request that may change url's issue or page and, once it
+// succeeds, drops the cached reads it affects.
+func (c *Client) write(ctx context.Context, svc Service, method, url string, body []byte) ([]byte, error) {
+	respBody, err := c.do(ctx, svc, method, url, body)
+	if err == nil && c.Cache != nil && !isIdempotent(ctx) {
+		c.Cache.invalidate(c.Site, url)
+	}
+	return respBody, err
 }
 
-// Put performs a PUT request to the specified service.
-func Put(svc Service, endpoint string, body []byte) ([]byte, error) {
-	url := baseURL(svc) + endpoint
+type clientKey struct{}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
+// NewContext returns a copy of ctx carrying c.
+func NewContext(ctx context.Context, c *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// FromContext returns the client carried by ctx, or nil.
+func FromContext(ctx context.Context) *Client {
+	c, _ := ctx.Value(clientKey{}).(*Client)
+	return c
+}
+
+// do sends a request, retrying rate limiting, gateway errors and network
+// failures with backoff when the call may be repeated. Retry-After is honored.
+// Errors report how many attempts were made.
+func (c *Client) do(ctx context.Context, svc Service, method, url string, body []byte) ([]byte, error) {
+	respBody, _, err := c.exchange(ctx, svc, method, url, body, nil)
+	return respBody, err
+}
+
+// exchange is do with extra request headers, returning the final response
+// alongside its body. A 304 answer to a conditional request succeeds with
+// an empty body.
+func (c *Client) exchange(ctx context.Context, svc Service, method, url string, body []byte, header http.Header) ([]byte, *http.Response, error) {
+	if strings.HasPrefix(url, "/") {
+		// No base URL: a Data Center deployment may run only one service
+		return nil, nil, fmt.Errorf("%s is not configured", serviceName(svc))
+	}
+	policy := c.retry
+	ctx = logging.NewContext(ctx, "service", string(svc))
+	retry := canRetry(ctx, method)
+	start := time.Now()
+	refreshed := false
+
+	for attempt := 1; ; attempt++ {
+		respBody, resp, err := c.send(ctx, svc, method, url, body, header)
+		if err == nil {
+			return respBody, resp, nil
+		}
+		var authErr *authError
+		if errors.As(err, &authErr) {
+			return nil, nil, err
+		}
+		// Expired credentials: the request was not applied, so even a POST
+		// can be sent again once they are refreshed
+		if r, ok := c.Auth.(Refresher); ok && resp != nil && resp.StatusCode == http.StatusUnauthorized && !refreshed {
+			refreshed = true
+			if err := r.Refresh(ctx); err != nil {
+				return nil, nil, &authError{err}
+			}
+			continue
+		}
+		if ctx.Err() != nil || !retry || attempt >= policy.maxAttempts {
+			return nil, nil, attemptsError(err, attempt)
+		}
+
+		delay := policy.backoff(attempt)
+		if resp != nil {
+			if !retryableStatus(resp.StatusCode) {
+				return nil, nil, attemptsError(err, attempt)
+			}
+			if d, ok := retryAfter(resp.Header, time.Now()); ok {
+				delay = d
+			}
+		}
+		if time.Since(start)+delay > policy.deadline {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+				apiErr.RetryAfter = delay
+			}
+			return nil, nil, attemptsError(err, attempt)
+		}
+		if !waitRetry(ctx, delay, attempt, err.Error()) {
+			return nil, nil, attemptsError(connectError(ctx, svc), attempt)
+		}
+	}
+}
+
+// send makes one attempt at a request. The response is returned alongside the
+// error for non-2xx statuses so the caller can decide whether to retry.
+func (c *Client) send(ctx context.Context, svc Service, method, url string, body []byte, header http.Header) ([]byte, *http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request")
+		return nil, nil, fmt.Errorf("failed to create request")
 	}
 
-	req.Header.Set("Authorization", authHeader())
+	if err := c.Auth.Authorize(req); err != nil {
+		return nil, nil, &authError{err}
+	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
 
-	resp, err := HTTPClient.Do(req)
+	release, err := c.limits[svc].acquire(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to %s", serviceName(svc))
+		return nil, nil, connectError(ctx, svc)
+	}
+	defer release()
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, nil, connectError(ctx, svc)
 	}
 	defer resp.Body.Close()
+	c.limits[svc].observe(resp.Header, resp.StatusCode)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response")
+		return nil, nil, fmt.Errorf("failed to read response")
 	}
 
+	if resp.StatusCode == http.StatusNotModified && header.Get("If-None-Match") != "" {
+		return nil, resp, nil
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, handleStatusCode(svc, resp.StatusCode)
+		return nil, resp, NewAPIError(svc, resp, respBody)
 	}
 
-	return respBody, nil
+	return respBody, resp, nil
 }
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// maxErrorMessage caps each message taken from an error response.
+	maxErrorMessage = 300
+
+	// maxErrorMessages caps how many messages an APIError reports.
+	maxErrorMessages = 10
+)
+
+// APIError is an error response from Atlassian, with the messages parsed from
+// its body so the agent can see what to fix.
+type APIError struct {
+	Service    Service
+	StatusCode int
+	// Messages are errors not tied to a field.
+	Messages []string
+	// Fields maps field names to their error messages, e.g.
+	// "customfield_10011": "Epic Name is required".
+	Fields map[string]string
+	// RequestID identifies the request in Atlassian's logs, for support.
+	RequestID string
+	// Attempts is how many times the request was sent.
+	Attempts int
+	// RetryAfter is set when retries gave up because Atlassian asked to wait
+	// longer than the retry deadline allows.
+	RetryAfter time.Duration
+}
+
+// NewAPIError builds an APIError from a non-2xx response and its body.
+// Messages are sanitized and truncated since they are shown to the agent.
+func NewAPIError(svc Service, resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		Service:    svc,
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Arequestid"),
+		Attempts:   1,
+	}
+	if e.RequestID == "" {
+		e.RequestID = resp.Header.Get("Atl-Traceid")
+	}
+	e.Messages, e.Fields = parseErrorBody(body)
+	return e
+}
+
+// Error renders the status, then the messages and field errors, then the
+// request ID, on one line.
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(statusText(e.Service, e.StatusCode))
+	if e.Attempts > 1 {
+		sb.WriteString(fmt.Sprintf(" (after %d attempts)", e.Attempts))
+	}
+	if e.RetryAfter > 0 {
+		sb.WriteString(", retry after " + e.RetryAfter.Round(time.Second).String())
+	}
+
+	details := append([]string(nil), e.Messages...)
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		details = append(details, name+": "+e.Fields[name])
+	}
+	if len(details) > maxErrorMessages {
+		details = append(details[:maxErrorMessages], fmt.Sprintf("and %d more", len(details)-maxErrorMessages))
+	}
+	if len(details) > 0 {
+		sb.WriteString(": ")
+		sb.WriteString(strings.Join(details, "; "))
+	}
+
+	if e.RequestID != "" {
+		sb.WriteString(" [request ID " + e.RequestID + "]")
+	}
+	return sb.String()
+}
+
+// statusText describes a status code without the response details.
+func statusText(svc Service, statusCode int) string {
+	switch statusCode {
+	case 400:
+		return "bad request (HTTP 400)"
+	case 401:
+		return "authentication failed (HTTP 401)"
+	case 403:
+		return "access denied (HTTP 403)"
+	case 404:
+		return "not found or no permission (HTTP 404)"
+	case 429:
+		return fmt.Sprintf("rate limited by %s (HTTP 429)", serviceName(svc))
+	default:
+		return fmt.Sprintf("%s API error (HTTP %d)", serviceName(svc), statusCode)
+	}
+}
+
+// parseErrorBody extracts messages from the error shapes Atlassian returns:
+//
+//	Jira:            {"errorMessages": [...], "errors": {"field": "message"}}
+//	Confluence v2:   {"errors": [{"title": "...", "detail": "..."}]}
+//	Confluence v1:   {"message": "..."}
+//
+// Bodies that are not JSON, such as proxy error pages, yield nothing.
+func parseErrorBody(body []byte) ([]string, map[string]string) {
+	var shape struct {
+		ErrorMessages []string        `json:"errorMessages"`
+		Errors        json.RawMessage `json:"errors"`
+		Message       string          `json:"message"`
+	}
+	if json.Unmarshal(body, &shape) != nil {
+		return nil, nil
+	}
+
+	var messages []string
+	add := func(s string) {
+		if s = sanitize(s); s != "" {
+			messages = append(messages, s)
+		}
+	}
+	for _, m := range shape.ErrorMessages {
+		add(m)
+	}
+	add(shape.Message)
+
+	var fields map[string]string
+	var fieldErrors map[string]any
+	var listErrors []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	switch {
+	case json.Unmarshal(shape.Errors, &fieldErrors) == nil:
+		for name, v := range fieldErrors {
+			msg, ok := v.(string)
+			if !ok {
+				data, _ := json.Marshal(v)
+				msg = string(data)
+			}
+			if msg = sanitize(msg); msg != "" {
+				if fields == nil {
+					fields = make(map[string]string)
+				}
+				fields[sanitize(name)] = msg
+			}
+		}
+	case json.Unmarshal(shape.Errors, &listErrors) == nil:
+		for _, e := range listErrors {
+			if e.Detail != "" && e.Detail != e.Title {
+				add(e.Title + ": " + e.Detail)
+			} else {
+				add(e.Title)
+			}
+		}
+	}
+	return messages, fields
+}
+
+// sanitize collapses whitespace, drops control characters and truncates s to
+// maxErrorMessage runes.
+func sanitize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxErrorMessage {
+		return string(runes[:maxErrorMessage]) + "…"
+	}
+	return s
+}
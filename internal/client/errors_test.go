@@ -0,0 +1,103 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		svc    Service
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{
+			"jira field error",
+			Jira, 400, http.Header{"X-Arequestid": {"abc123"}},
+			`{"errorMessages":[],"errors":{"customfield_10011":"Epic Name is required"}}`,
+			"bad request (HTTP 400): customfield_10011: Epic Name is required [request ID abc123]",
+		},
+		{
+			"jira messages and fields",
+			Jira, 400, nil,
+			`{"errorMessages":["Issue type is invalid"],"errors":{"summary":"Summary is required","labels":"Too long"}}`,
+			"bad request (HTTP 400): Issue type is invalid; labels: Too long; summary: Summary is required",
+		},
+		{
+			"confluence v2",
+			Confluence, 409, http.Header{"Atl-Traceid": {"trace1"}},
+			`{"errors":[{"status":409,"code":"CONFLICT","title":"Version must be incremented","detail":null}]}`,
+			"Confluence API error (HTTP 409): Version must be incremented [request ID trace1]",
+		},
+		{
+			"confluence v1",
+			Confluence, 403, nil,
+			`{"statusCode":403,"message":"User not permitted to edit page"}`,
+			"access denied (HTTP 403): User not permitted to edit page",
+		},
+		{
+			"html body",
+			Jira, 502, nil,
+			`<html><body>Bad Gateway</body></html>`,
+			"Jira API error (HTTP 502)",
+		},
+		{
+			"sanitized",
+			Jira, 400, nil,
+			`{"errorMessages":["line one\n\tline\u0007 two"]}`,
+			"bad request (HTTP 400): line one line two",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := &http.Response{StatusCode: tt.status, Header: tt.header}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			if got := NewAPIError(tt.svc, resp, []byte(tt.body)).Error(); got != tt.want {
+				t.Errorf("Error() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_Truncated(t *testing.T) {
+	t.Parallel()
+	var fields []string
+	for i := range maxErrorMessages + 2 {
+		fields = append(fields, fmt.Sprintf(`"f%02d":%q`, i, strings.Repeat("x", maxErrorMessage+10)))
+	}
+	resp := &http.Response{StatusCode: 400, Header: http.Header{}}
+	err := NewAPIError(Jira, resp, []byte(`{"errors":{`+strings.Join(fields, ",")+`}}`))
+
+	got := err.Error()
+	if !strings.HasSuffix(got, "; and 2 more") {
+		t.Errorf("Error() does not end with the count of dropped messages: %s", got)
+	}
+	if strings.Contains(got, strings.Repeat("x", maxErrorMessage+1)) {
+		t.Error("Error() contains a message longer than maxErrorMessage")
+	}
+}
+
+func TestAPIError_Attempts(t *testing.T) {
+	t.Parallel()
+	resp := &http.Response{StatusCode: 503, Header: http.Header{}}
+	err := fmt.Errorf("failed to update page: %w", attemptsError(NewAPIError(Confluence, resp, nil), 3))
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Fatalf("errors.As(%v) did not find the APIError", err)
+	}
+	if want := "failed to update page: Confluence API error (HTTP 503) (after 3 attempts)"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err, want)
+	}
+}
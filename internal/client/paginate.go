@@ -0,0 +1,318 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// DefaultPageLimit is how many results a list call returns when no limit
+	// is given.
+	DefaultPageLimit = 50
+
+	// MaxPageLimit caps the results returned by one list call.
+	MaxPageLimit = 500
+
+	// maxPageSize caps the results requested from Atlassian per API call.
+	maxPageSize = 100
+)
+
+// ErrInvalidCursor is returned for a cursor that no list call produced.
+var ErrInvalidCursor = errors.New("invalid cursor: pass the nextCursor of a previous result unchanged")
+
+// PageRequest selects the results of a list call.
+type PageRequest struct {
+	// Limit is the maximum number of results, DefaultPageLimit when zero.
+	Limit int
+	// Cursor continues after a previous Page; empty starts from the beginning.
+	Cursor string
+}
+
+// limit returns the effective result limit.
+func (r PageRequest) limit() int {
+	if r.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	return min(r.Limit, MaxPageLimit)
+}
+
+// Page is a run of results from a paginated list.
+type Page struct {
+	Items []json.RawMessage
+	// Next is the cursor for the results after these, or "" at the end.
+	Next string
+	// Total counts the results on all pages, or -1 when the API doesn't say.
+	Total int
+}
+
+// Decode unmarshals the items into v, which must point to a slice.
+func (p Page) Decode(v any) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, item := range p.Items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(item)
+	}
+	buf.WriteByte(']')
+	return json.Unmarshal(buf.Bytes(), v)
+}
+
+// Summary tells the agent how to fetch the next results, or returns "" at the
+// end of the list.
+func (p Page) Summary() string {
+	if p.Next == "" {
+		return ""
+	}
+	if p.Total >= 0 {
+		return fmt.Sprintf("\n_Showing %d of %d results. To continue, repeat the call with \"cursor\": %q._\n", len(p.Items), p.Total, p.Next)
+	}
+	return fmt.Sprintf("\n_More results available. To continue, repeat the call with \"cursor\": %q._\n", p.Next)
+}
+
+// Annotate adds nextCursor and total to structured result data.
+func (p Page) Annotate(data map[string]any) map[string]any {
+	if p.Next != "" {
+		data["nextCursor"] = p.Next
+	}
+	if p.Total >= 0 {
+		data["total"] = p.Total
+	}
+	return data
+}
+
+// Pager fetches up to size results of a list starting at cursor.
+type Pager func(ctx context.Context, cursor string, size int) (Page, error)
+
+// Pages iterates over the pages of a list from req.Cursor, requesting no more
+// than the limit of req in total. Iteration stops after the first error.
+func (p Pager) Pages(ctx context.Context, req PageRequest) iter.Seq2[Page, error] {
+	return func(yield func(Page, error) bool) {
+		cursor := req.Cursor
+		for remaining := req.limit(); remaining > 0; {
+			page, err := p(ctx, cursor, min(remaining, maxPageSize))
+			if err != nil {
+				yield(Page{}, err)
+				return
+			}
+			if !yield(page, nil) || page.Next == "" || len(page.Items) == 0 {
+				return
+			}
+			remaining -= len(page.Items)
+			cursor = page.Next
+		}
+	}
+}
+
+// Collect gathers the results selected by req into one Page whose Next
+// continues after the last result.
+func (p Pager) Collect(ctx context.Context, req PageRequest) (Page, error) {
+	result := Page{Total: -1}
+	for page, err := range p.Pages(ctx, req) {
+		if err != nil {
+			return Page{}, err
+		}
+		result.Items = append(result.Items, page.Items...)
+		result.Next = page.Next
+		if page.Total >= 0 {
+			result.Total = page.Total
+		}
+	}
+	return result, nil
+}
+
+// Offset paginates Jira endpoints that take startAt and maxResults and report
+// total or isLast. itemsKey names the array of results. Cursors are offsets.
+func (c *Client) Offset(svc Service, endpoint, itemsKey string) Pager {
+	return offsetPager(c.getter(svc), endpoint, itemsKey)
+}
+
+func offsetPager(get fetchFunc, endpoint, itemsKey string) Pager {
+	return func(ctx context.Context, cursor string, size int) (Page, error) {
+		start := 0
+		if cursor != "" {
+			n, err := strconv.Atoi(cursor)
+			if err != nil || n < 0 {
+				return Page{}, ErrInvalidCursor
+			}
+			start = n
+		}
+
+		u, err := withQuery(endpoint, url.Values{"startAt": {strconv.Itoa(start)}, "maxResults": {strconv.Itoa(size)}})
+		if err != nil {
+			return Page{}, err
+		}
+		body, err := get(ctx, u, nil)
+		if err != nil {
+			return Page{}, err
+		}
+
+		var response map[string]json.RawMessage
+		if err := json.Unmarshal(body, &response); err != nil {
+			return Page{}, fmt.Errorf("failed to parse response")
+		}
+		page := Page{Total: -1}
+		if err := json.Unmarshal(response[itemsKey], &page.Items); err != nil && response[itemsKey] != nil {
+			return Page{}, fmt.Errorf("failed to parse response")
+		}
+
+		end := start + len(page.Items)
+		more := false
+		var total int
+		if json.Unmarshal(response["total"], &total) == nil {
+			page.Total = total
+			more = end < total
+		}
+		var isLast bool
+		if json.Unmarshal(response["isLast"], &isLast) == nil {
+			more = !isLast
+		}
+		if more && len(page.Items) > 0 {
+			page.Next = strconv.Itoa(end)
+		}
+		return page, nil
+	}
+}
+
+// Token paginates Jira's enhanced search, which takes maxResults and
+// nextPageToken in a POST body. Searches are reads, so they are retried like
+// GETs. Cursors are Jira's page tokens.
+func (c *Client) Token(svc Service, endpoint string, payload map[string]any, itemsKey string) Pager {
+	post := func(ctx context.Context, endpoint string, body []byte) ([]byte, error) {
+		return c.Post(Idempotent(ctx), svc, endpoint, body)
+	}
+	return tokenPager(post, endpoint, payload, itemsKey)
+}
+
+func tokenPager(post fetchFunc, endpoint string, payload map[string]any, itemsKey string) Pager {
+	return func(ctx context.Context, cursor string, size int) (Page, error) {
+		req := maps.Clone(payload)
+		req["maxResults"] = size
+		if cursor != "" {
+			req["nextPageToken"] = cursor
+		}
+		reqBody, err := json.Marshal(req)
+		if err != nil {
+			return Page{}, fmt.Errorf("failed to marshal request")
+		}
+
+		body, err := post(ctx, endpoint, reqBody)
+		if err != nil {
+			return Page{}, err
+		}
+
+		var response map[string]json.RawMessage
+		if err := json.Unmarshal(body, &response); err != nil {
+			return Page{}, fmt.Errorf("failed to parse response")
+		}
+		page := Page{Total: -1}
+		if err := json.Unmarshal(response[itemsKey], &page.Items); err != nil && response[itemsKey] != nil {
+			return Page{}, fmt.Errorf("failed to parse response")
+		}
+
+		var isLast bool
+		_ = json.Unmarshal(response["isLast"], &isLast)
+		if !isLast {
+			_ = json.Unmarshal(response["nextPageToken"], &page.Next)
+		}
+		return page, nil
+	}
+}
+
+// Links paginates Confluence endpoints that take limit and point to the next
+// page with _links.next. Cursors hold the query parameters of that link that
+// endpoint doesn't set, so a cursor cannot point the call elsewhere.
+func (c *Client) Links(svc Service, endpoint, itemsKey string) Pager {
+	return linksPager(c.getter(svc), endpoint, itemsKey)
+}
+
+func linksPager(get fetchFunc, endpoint, itemsKey string) Pager {
+	return func(ctx context.Context, cursor string, size int) (Page, error) {
+		base, err := url.Parse(endpoint)
+		if err != nil {
+			return Page{}, fmt.Errorf("invalid endpoint")
+		}
+		own := base.Query()
+
+		extra := url.Values{}
+		if cursor != "" {
+			if extra, err = url.ParseQuery(cursor); err != nil {
+				return Page{}, ErrInvalidCursor
+			}
+		}
+		for k, v := range own {
+			extra[k] = v
+		}
+		extra.Set("limit", strconv.Itoa(size))
+		u := *base
+		u.RawQuery = extra.Encode()
+
+		body, err := get(ctx, u.String(), nil)
+		if err != nil {
+			return Page{}, err
+		}
+
+		var response map[string]json.RawMessage
+		if err := json.Unmarshal(body, &response); err != nil {
+			return Page{}, fmt.Errorf("failed to parse response")
+		}
+		page := Page{Total: -1}
+		if err := json.Unmarshal(response[itemsKey], &page.Items); err != nil && response[itemsKey] != nil {
+			return Page{}, fmt.Errorf("failed to parse response")
+		}
+		var total int
+		if json.Unmarshal(response["totalSize"], &total) == nil {
+			page.Total = total
+		}
+		var links struct {
+			Next string `json:"next"`
+		}
+		_ = json.Unmarshal(response["_links"], &links)
+
+		if links.Next != "" {
+			next, err := url.Parse(links.Next)
+			if err != nil {
+				return Page{}, fmt.Errorf("failed to parse next page link")
+			}
+			q := next.Query()
+			for k := range own {
+				q.Del(k)
+			}
+			q.Del("limit")
+			page.Next = q.Encode()
+		}
+		return page, nil
+	}
+}
+
+// fetchFunc sends a request for one page to an endpoint of a service.
+type fetchFunc func(ctx context.Context, endpoint string, body []byte) ([]byte, error)
+
+// getter fetches pages of svc with GET.
+func (c *Client) getter(svc Service) fetchFunc {
+	return func(ctx context.Context, endpoint string, _ []byte) ([]byte, error) {
+		return c.Request(ctx, svc, endpoint)
+	}
+}
+
+// withQuery sets params on the query string of endpoint.
+func withQuery(endpoint string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint")
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
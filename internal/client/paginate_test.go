@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// items returns n JSON numbers starting at from.
+func items(from, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = strconv.Itoa(from + i)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// offsetServer serves total results with startAt and maxResults.
+func offsetServer(total int, calls *[]string) fetchFunc {
+	return func(_ context.Context, endpoint string, _ []byte) ([]byte, error) {
+		*calls = append(*calls, endpoint)
+		u, _ := url.Parse(endpoint)
+		start, _ := strconv.Atoi(u.Query().Get("startAt"))
+		size, _ := strconv.Atoi(u.Query().Get("maxResults"))
+		n := max(min(size, total-start), 0)
+		return fmt.Appendf(nil, `{"total":%d,"comments":%s}`, total, items(start, n)), nil
+	}
+}
+
+func TestOffsetPager(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		total     int
+		req       PageRequest
+		wantItems int
+		wantNext  string
+		wantCalls int
+	}{
+		{"one page", 3, PageRequest{}, 3, "", 1},
+		{"limit", 80, PageRequest{Limit: 20}, 20, "20", 1},
+		{"several pages", 250, PageRequest{Limit: 220}, 220, "220", 3},
+		{"cursor", 80, PageRequest{Cursor: "50"}, 30, "", 1},
+		{"past the end", 10, PageRequest{Cursor: "10"}, 0, "", 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var calls []string
+			pager := offsetPager(offsetServer(tt.total, &calls), "/rest/api/3/issue/X-1/comment?orderBy=created", "comments")
+			page, err := pager.Collect(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if len(page.Items) != tt.wantItems || page.Next != tt.wantNext || page.Total != tt.total {
+				t.Errorf("Collect() = %d items, next %q, total %d; want %d, %q, %d", len(page.Items), page.Next, page.Total, tt.wantItems, tt.wantNext, tt.total)
+			}
+			if len(calls) != tt.wantCalls {
+				t.Errorf("made %d calls, want %d: %v", len(calls), tt.wantCalls, calls)
+			}
+			if !strings.Contains(calls[0], "orderBy=created") {
+				t.Errorf("call %q lost the endpoint's query", calls[0])
+			}
+		})
+	}
+}
+
+func TestOffsetPager_IsLast(t *testing.T) {
+	t.Parallel()
+	get := func(_ context.Context, _ string, _ []byte) ([]byte, error) {
+		return []byte(`{"isLast":false,"values":[1,2]}`), nil
+	}
+	page, err := offsetPager(get, "/rest/api/3/project/search", "values")(context.Background(), "4", 2)
+	if err != nil {
+		t.Fatalf("pager error = %v", err)
+	}
+	if page.Next != "6" || page.Total != -1 {
+		t.Errorf("page = next %q, total %d; want \"6\", -1", page.Next, page.Total)
+	}
+}
+
+func TestTokenPager(t *testing.T) {
+	t.Parallel()
+	var bodies []map[string]any
+	post := func(_ context.Context, _ string, body []byte) ([]byte, error) {
+		var req map[string]any
+		_ = json.Unmarshal(body, &req)
+		bodies = append(bodies, req)
+		if req["nextPageToken"] == nil {
+			return []byte(`{"issues":[1,2],"nextPageToken":"t2"}`), nil
+		}
+		return []byte(`{"issues":[3],"nextPageToken":"t3","isLast":true}`), nil
+	}
+	payload := map[string]any{"jql": "project = X"}
+
+	page, err := tokenPager(post, "/rest/api/3/search/jql", payload, "issues").Collect(context.Background(), PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var got []int
+	if err := page.Decode(&got); err != nil || fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("Decode() = %v, %v; want [1 2 3]", got, err)
+	}
+	if page.Next != "" {
+		t.Errorf("Next = %q after the last page", page.Next)
+	}
+	if len(bodies) != 2 || bodies[1]["nextPageToken"] != "t2" || bodies[1]["jql"] != "project = X" {
+		t.Errorf("request bodies = %v", bodies)
+	}
+	if _, ok := payload["maxResults"]; ok {
+		t.Error("pager modified the payload")
+	}
+}
+
+func TestLinksPager(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	get := func(_ context.Context, endpoint string, _ []byte) ([]byte, error) {
+		calls = append(calls, endpoint)
+		u, _ := url.Parse(endpoint)
+		if u.Query().Get("cursor") == "" {
+			return []byte(`{"results":[1,2],"totalSize":3,"_links":{"next":"/wiki/rest/api/search?cql=type%3Dpage&limit=2&cursor=abc"}}`), nil
+		}
+		return []byte(`{"results":[3],"_links":{}}`), nil
+	}
+	pager := linksPager(get, "/wiki/rest/api/search?cql=type%3Dpage", "results")
+
+	first, err := pager(context.Background(), "", 2)
+	if err != nil {
+		t.Fatalf("pager error = %v", err)
+	}
+	if first.Next != "cursor=abc" || first.Total != 3 {
+		t.Errorf("first page = next %q, total %d; want \"cursor=abc\", 3", first.Next, first.Total)
+	}
+
+	second, err := pager(context.Background(), "cql=other&cursor=abc", 2)
+	if err != nil {
+		t.Fatalf("pager error = %v", err)
+	}
+	if len(second.Items) != 1 || second.Next != "" {
+		t.Errorf("second page = %d items, next %q", len(second.Items), second.Next)
+	}
+	if want := "/wiki/rest/api/search?cql=type%3Dpage&cursor=abc&limit=2"; calls[1] != want {
+		t.Errorf("second call = %q, want %q", calls[1], want)
+	}
+}
+
+func TestPager_InvalidCursor(t *testing.T) {
+	t.Parallel()
+	get := func(_ context.Context, _ string, _ []byte) ([]byte, error) {
+		t.Error("pager made a call with an invalid cursor")
+		return nil, nil
+	}
+	tests := []struct {
+		name   string
+		pager  Pager
+		cursor string
+	}{
+		{"offset not a number", offsetPager(get, "/x", "values"), "abc"},
+		{"offset negative", offsetPager(get, "/x", "values"), "-5"},
+		{"links bad query", linksPager(get, "/x", "results"), "%zz"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := tt.pager.Collect(context.Background(), PageRequest{Cursor: tt.cursor}); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Collect() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestPage_Summary(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		page     Page
+		want     string
+		wantData map[string]any
+	}{
+		{"end", Page{Items: make([]json.RawMessage, 2), Total: 2}, "", map[string]any{"total": 2}},
+		{"with total", Page{Items: make([]json.RawMessage, 2), Next: "2", Total: 5}, `Showing 2 of 5 results. To continue, repeat the call with "cursor": "2".`, map[string]any{"total": 5, "nextCursor": "2"}},
+		{"without total", Page{Next: "t2", Total: -1}, `More results available. To continue, repeat the call with "cursor": "t2".`, map[string]any{"nextCursor": "t2"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := tt.page.Summary()
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+			if data := tt.page.Annotate(map[string]any{}); fmt.Sprint(data) != fmt.Sprint(tt.wantData) {
+				t.Errorf("Annotate() = %v, want %v", data, tt.wantData)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/logging"
+)
+
+// maxRateLimitPause caps how long a rate limit response can hold back the
+// requests of a service, in case of a bogus reset time.
+const maxRateLimitPause = 5 * time.Minute
+
+// limiter paces the requests to one service of a site. A token bucket bounds
+// the request rate and a semaphore the requests in flight. Requests over
+// either limit wait for their turn instead of failing. Rate limit headers
+// tighten the pace: once Atlassian reports the budget spent, every request
+// waits until it resets. A nil limiter lets everything through.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second; 0 for no limit
+	burst  float64
+	tokens float64
+	last   time.Time
+	// pausedUntil holds back all requests after a 429 or an exhausted budget.
+	pausedUntil time.Time
+	now         func() time.Time
+
+	slots chan struct{} // nil for no limit
+}
+
+// newLimiter returns a limiter allowing rate requests per second, in bursts
+// of up to one second's worth, and maxInFlight at once. Zero disables
+// either limit.
+func newLimiter(rate float64, maxInFlight int) *limiter {
+	l := &limiter{now: time.Now}
+	l.setRate(rate)
+	l.tokens = l.burst
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// configuredLimiters returns new limiters set by ATLASSIAN_JIRA_RATE_LIMIT,
+// ATLASSIAN_JIRA_MAX_IN_FLIGHT and their Confluence counterparts, for one
+// client.
+func configuredLimiters() map[Service]*limiter {
+	return map[Service]*limiter{
+		Jira:       newLimiter(config.JiraRateLimit, config.JiraMaxInFlight),
+		Confluence: newLimiter(config.ConfluenceRateLimit, config.ConfluenceMaxInFlight),
+	}
+}
+
+// setRate changes the rate and the burst that comes with it. Callers hold
+// l.mu or own l.
+func (l *limiter) setRate(rate float64) {
+	l.rate = rate
+	l.burst = math.Max(1, math.Ceil(rate))
+	l.tokens = math.Min(l.tokens, l.burst)
+}
+
+// acquire waits until a request may be sent and returns the function that
+// ends it. It fails only when ctx ends first.
+func (l *limiter) acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return release, nil
+		}
+		level := slog.LevelDebug
+		if delay >= time.Second {
+			level = slog.LevelInfo
+		}
+		logging.FromContext(ctx).LogAttrs(ctx, level, "waiting for rate limit", slog.Int64("delay_ms", delay.Milliseconds()))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token, returning 0, or returns how long to wait before
+// trying again.
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return 0
+	}
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// observe adapts the limiter to the rate limit headers of a response:
+//   - Retry-After on a 429 or 503 pauses all requests for that long.
+//   - X-RateLimit-Remaining: 0 pauses them until X-RateLimit-Reset.
+//   - X-RateLimit-NearLimit (Cloud) empties the bucket, so requests continue
+//     at the steady rate only.
+//   - X-RateLimit-FillRate per X-RateLimit-Interval-Seconds (Data Center)
+//     lowers the rate to the one the server grants.
+func (l *limiter) observe(h http.Header, status int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var until time.Time
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		if d, ok := retryAfter(h, now); ok {
+			until = now.Add(d)
+		}
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		if reset, ok := rateLimitReset(h, now); ok && reset.After(until) {
+			until = reset
+		}
+	}
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = minTime(until, now.Add(maxRateLimitPause))
+	}
+
+	if strings.EqualFold(h.Get("X-RateLimit-NearLimit"), "true") {
+		l.tokens = 0
+	}
+	fill, err1 := strconv.ParseFloat(h.Get("X-RateLimit-FillRate"), 64)
+	interval, err2 := strconv.ParseFloat(h.Get("X-RateLimit-Interval-Seconds"), 64)
+	if err1 == nil && err2 == nil && fill > 0 && interval > 0 {
+		if granted := fill / interval; l.rate <= 0 || granted < l.rate {
+			l.setRate(granted)
+		}
+	}
+}
+
+// rateLimitReset parses X-RateLimit-Reset, given as an ISO 8601 timestamp,
+// Unix seconds or seconds from now.
+func rateLimitReset(h http.Header, now time.Time) (time.Time, bool) {
+	v := h.Get("X-RateLimit-Reset")
+	if v == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04Z07:00", v); err == nil {
+		return t, true
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs >= 0 {
+		if secs > 1e9 {
+			return time.Unix(secs, 0), true
+		}
+		return now.Add(time.Duration(secs) * time.Second), true
+	}
+	return time.Time{}, false
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
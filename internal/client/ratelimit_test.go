@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Reserve(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newLimiter(2, 0)
+	l.now = func() time.Time { return now }
+
+	// A burst of one second's worth passes, then requests are paced
+	for i, want := range []time.Duration{0, 0, 500 * time.Millisecond} {
+		if got := l.reserve(); got != want {
+			t.Errorf("reserve() #%d = %v, want %v", i+1, got, want)
+		}
+	}
+	now = now.Add(500 * time.Millisecond)
+	if got := l.reserve(); got != 0 {
+		t.Errorf("reserve() after refill = %v, want 0", got)
+	}
+
+	if got := newLimiter(0, 0).reserve(); got != 0 {
+		t.Errorf("reserve() without rate limit = %v, want 0", got)
+	}
+}
+
+func TestLimiter_Observe(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		status    int
+		header    http.Header
+		wantPause time.Duration
+		wantRate  float64
+	}{
+		{"ok", 200, http.Header{}, 0, 10},
+		{"429 Retry-After", 429, http.Header{"Retry-After": {"3"}}, 3 * time.Second, 10},
+		{"Retry-After ignored on success", 200, http.Header{"Retry-After": {"3"}}, 0, 10},
+		{"remaining 0 with ISO reset", 200, http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {"2024-01-01T00:00:20Z"},
+		}, 20 * time.Second, 10},
+		{"remaining 0 with seconds reset", 429, http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {"7"},
+		}, 7 * time.Second, 10},
+		{"remaining left", 200, http.Header{
+			"X-Ratelimit-Remaining": {"5"},
+			"X-Ratelimit-Reset":     {"7"},
+		}, 0, 10},
+		{"pause capped", 429, http.Header{"Retry-After": {"3600"}}, maxRateLimitPause, 10},
+		{"data center fill rate", 200, http.Header{
+			"X-Ratelimit-Fillrate":         {"4"},
+			"X-Ratelimit-Interval-Seconds": {"2"},
+		}, 0, 2},
+		{"higher fill rate ignored", 200, http.Header{
+			"X-Ratelimit-Fillrate":         {"40"},
+			"X-Ratelimit-Interval-Seconds": {"1"},
+		}, 0, 10},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			l := newLimiter(10, 0)
+			l.now = func() time.Time { return now }
+			l.observe(tt.header, tt.status)
+
+			var pause time.Duration
+			if l.pausedUntil.After(now) {
+				pause = l.pausedUntil.Sub(now)
+			}
+			if pause != tt.wantPause {
+				t.Errorf("pause = %v, want %v", pause, tt.wantPause)
+			}
+			if l.rate != tt.wantRate {
+				t.Errorf("rate = %v, want %v", l.rate, tt.wantRate)
+			}
+			if got := l.reserve(); got != tt.wantPause {
+				t.Errorf("reserve() = %v, want %v", got, tt.wantPause)
+			}
+		})
+	}
+}
+
+func TestLimiter_NearLimit(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newLimiter(4, 0)
+	l.now = func() time.Time { return now }
+	l.observe(http.Header{"X-Ratelimit-Nearlimit": {"true"}}, 200)
+
+	if got, want := l.reserve(), 250*time.Millisecond; got != want {
+		t.Errorf("reserve() near limit = %v, want %v", got, want)
+	}
+}
+
+func TestLimiter_AcquireCanceled(t *testing.T) {
+	t.Parallel()
+	l := newLimiter(0, 1)
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() with no free slot = %v, want deadline exceeded", err)
+	}
+
+	release()
+	if _, err := l.acquire(context.Background()); err != nil {
+		t.Errorf("acquire() after release = %v", err)
+	}
+}
+
+func TestDo_MaxInFlight(t *testing.T) {
+	t.Parallel()
+	var inFlight, peak atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	c := &Client{Auth: BasicAuth{}, limits: map[Service]*limiter{Jira: newLimiter(0, 2)}}
+	errs := make(chan error)
+	for i := 0; i < 8; i++ {
+		go func() {
+			_, err := c.do(context.Background(), Jira, http.MethodGet, ts.URL, nil)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("do() error = %v", err)
+		}
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak requests in flight = %d, want at most 2", got)
+	}
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/logging"
+)
+
+const (
+	// retryBaseDelay is the backoff before the first retry; it doubles with
+	// each further attempt up to retryMaxDelay.
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// retryPolicy controls how a failed call is retried.
+type retryPolicy struct {
+	maxAttempts int
+	deadline    time.Duration
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// configuredPolicy returns the policy set by ATLASSIAN_RETRY_MAX_ATTEMPTS and
+// ATLASSIAN_RETRY_DEADLINE.
+func configuredPolicy() retryPolicy {
+	return retryPolicy{
+		maxAttempts: config.RetryMaxAttempts,
+		deadline:    config.RetryDeadline,
+		baseDelay:   retryBaseDelay,
+		maxDelay:    retryMaxDelay,
+	}
+}
+
+// backoff returns a jittered delay before retry number attempt: a random
+// duration between half and all of the exponential backoff for that attempt.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.maxDelay
+	if shift := attempt - 1; shift < 30 && p.baseDelay<<shift < p.maxDelay {
+		d = p.baseDelay << shift
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryableStatus reports whether a response status is worth retrying: rate
+// limiting and gateway errors that are usually transient.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+type idempotentKey struct{}
+
+// Idempotent marks calls made with the returned context as safe to retry even
+// when their method is not, such as searches sent as POST.
+func Idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(ctx context.Context) bool {
+	marked, _ := ctx.Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// canRetry reports whether a call may be sent more than once. Reads are
+// idempotent; POSTs and PUTs are retried only when marked with Idempotent or
+// when ATLASSIAN_RETRY_WRITES is set, since an update that timed out may have
+// been applied and bumped the version a resend still carries.
+func canRetry(ctx context.Context, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	return isIdempotent(ctx) || config.RetryWrites
+}
+
+// attemptsError adds the number of attempts to err when the call was retried.
+func attemptsError(err error, attempts int) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.Attempts = attempts
+		return err
+	}
+	if attempts == 1 {
+		return err
+	}
+	return fmt.Errorf("%w (after %d attempts)", err, attempts)
+}
+
+// waitRetry sleeps before the next attempt and reports false if ctx ended
+// first.
+func waitRetry(ctx context.Context, delay time.Duration, attempt int, reason string) bool {
+	logging.FromContext(ctx).LogAttrs(ctx, slog.LevelInfo, "retrying atlassian request",
+		slog.Int("attempt", attempt+1), slog.Int64("delay_ms", delay.Milliseconds()), slog.String("reason", reason))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy retries quickly so tests don't sleep.
+var testPolicy = retryPolicy{maxAttempts: 3, deadline: time.Second, baseDelay: time.Millisecond, maxDelay: 5 * time.Millisecond}
+
+func TestDo_Retry(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		method       string
+		idempotent   bool
+		statuses     []int
+		retryAfter   string
+		wantAttempts int32
+		wantErr      string
+	}{
+		{"success", http.MethodGet, false, []int{200}, "", 1, ""},
+		{"503 then success", http.MethodGet, false, []int{503, 200}, "", 2, ""},
+		{"429 honors Retry-After", http.MethodGet, false, []int{429, 200}, "0", 2, ""},
+		{"put not retried", http.MethodPut, false, []int{502, 204}, "", 1, "(HTTP 502)"},
+		{"idempotent put retried", http.MethodPut, true, []int{502, 204}, "", 2, ""},
+		{"exhausted", http.MethodGet, false, []int{504, 504, 504}, "", 3, "(after 3 attempts)"},
+		{"not retryable", http.MethodGet, false, []int{404}, "", 1, "not found or no permission (HTTP 404)"},
+		{"post not retried", http.MethodPost, false, []int{503, 200}, "", 1, "(HTTP 503)"},
+		{"idempotent post retried", http.MethodPost, true, []int{503, 200}, "", 2, ""},
+		{"Retry-After beyond deadline", http.MethodGet, false, []int{429, 200}, "120", 1, "retry after 2m0s"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var attempts atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				status := tt.statuses[min(int(n), len(tt.statuses))-1]
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+				w.Write([]byte(`{}`))
+			}))
+			defer ts.Close()
+
+			ctx := context.Background()
+			if tt.idempotent {
+				ctx = Idempotent(ctx)
+			}
+			var body []byte
+			if tt.method != http.MethodGet {
+				body = []byte(`{}`)
+			}
+			c := &Client{Auth: BasicAuth{}, retry: testPolicy}
+			_, err := c.do(ctx, Jira, tt.method, ts.URL, body)
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("do() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("do() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDo_NetworkError(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	c := &Client{Auth: BasicAuth{}, retry: testPolicy}
+	_, err := c.do(context.Background(), Confluence, http.MethodGet, url, nil)
+	if err == nil || err.Error() != "failed to connect to Confluence (after 3 attempts)" {
+		t.Errorf("do() error = %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"30", 30 * time.Second, true},
+		{"Wed, 01 Jan 2025 12:00:10 GMT", 10 * time.Second, true},
+		{"Wed, 01 Jan 2025 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			h := http.Header{}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+			got, ok := retryAfter(h, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+	p := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{64, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if d := p.backoff(tt.attempt); d < tt.ceiling/2 || d > tt.ceiling {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, d, tt.ceiling/2, tt.ceiling)
+			}
+		}
+	}
+}
+
+// refreshingAuth counts refreshes and fails Authorize once broken is set.
+type refreshingAuth struct {
+	refreshes atomic.Int32
+	broken    bool
+}
+
+func (a *refreshingAuth) Authorize(req *http.Request) error {
+	if a.broken {
+		return errors.New("token revoked")
+	}
+	req.Header.Set("Authorization", "Bearer "+strconv.Itoa(int(a.refreshes.Load())))
+	return nil
+}
+
+func (a *refreshingAuth) Refresh(context.Context) error {
+	a.refreshes.Add(1)
+	return nil
+}
+
+func TestDo_Refresh(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		method        string
+		fresh         bool // whether the refreshed token is accepted
+		wantAttempts  int32
+		wantRefreshes int32
+		wantErr       string
+	}{
+		{"get", http.MethodGet, true, 2, 1, ""},
+		{"post", http.MethodPost, true, 2, 1, ""},
+		{"refreshed token rejected", http.MethodGet, false, 2, 1, "authentication failed (HTTP 401)"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var attempts atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				if !tt.fresh || r.Header.Get("Authorization") == "Bearer 0" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer ts.Close()
+
+			auth := &refreshingAuth{}
+			c := &Client{Auth: auth, retry: testPolicy}
+			_, err := c.do(context.Background(), Jira, tt.method, ts.URL, []byte(`{}`))
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if got := auth.refreshes.Load(); got != tt.wantRefreshes {
+				t.Errorf("refreshes = %d, want %d", got, tt.wantRefreshes)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("do() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("do() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientDo_Refresh(t *testing.T) {
+	t.Parallel()
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") == "Bearer 0" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	auth := &refreshingAuth{}
+	c := &Client{Auth: auth}
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, bytes.NewBufferString("--upload--"))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200 after refreshing", resp.StatusCode)
+	}
+	if auth.refreshes.Load() != 1 {
+		t.Errorf("refreshes = %d, want 1", auth.refreshes.Load())
+	}
+	if want := []string{"--upload--", "--upload--"}; !slices.Equal(bodies, want) {
+		t.Errorf("bodies = %q, want %q", bodies, want)
+	}
+}
+
+func TestClientLocation_Refresh(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.Header.Get("Authorization") == "Bearer 0" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, "https://media.example.com/file", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	auth := &refreshingAuth{}
+	c := &Client{Auth: auth}
+	location, err := c.Location(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Location() error = %v", err)
+	}
+	if location != "https://media.example.com/file" {
+		t.Errorf("Location() = %q, want the redirect target", location)
+	}
+	if auth.refreshes.Load() != 1 || attempts.Load() != 2 {
+		t.Errorf("refreshes = %d, attempts = %d, want 1 and 2", auth.refreshes.Load(), attempts.Load())
+	}
+}
+
+func TestDo_AuthorizeError(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+	}))
+	defer ts.Close()
+
+	c := &Client{Auth: &refreshingAuth{broken: true}, retry: testPolicy}
+	_, err := c.do(context.Background(), Jira, http.MethodGet, ts.URL, nil)
+	if err == nil || err.Error() != "failed to authorize request: token revoked" {
+		t.Errorf("do() error = %v", err)
+	}
+	if attempts.Load() != 0 {
+		t.Errorf("sent %d requests without credentials", attempts.Load())
+	}
+}
@@ -2,34 +2,189 @@ package config
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// Credentials holds Atlassian API credentials.
+// Profile holds the site, credentials and defaults of one Atlassian site.
+type Profile struct {
+	// Name selects the profile through the site argument of a tool call.
+	Name string
+
+	// Deployment is DeploymentCloud or DeploymentDataCenter. Data Center and
+	// Server sites are reached at JiraURL and ConfluenceURL instead of Domain.
+	Deployment    string
+	Domain        string
+	JiraURL       string
+	ConfluenceURL string
+
+	// AuthMethod selects how requests are authenticated: AuthBasic with
+	// Email and Token, AuthBearer with Token, or AuthOAuth with the OAuth
+	// settings and a refresh token kept in OAuthTokenFile. Data Center
+	// defaults to AuthBearer with a personal access token.
+	AuthMethod     string
+	Email          string
+	Token          string
+	OAuthTokenFile string
+
+	// Project and Space are created in when jira_create_issue or
+	// confluence_create_page name none.
+	Project string
+	Space   string
+}
+
+// Profiles lists the configured sites. The first is the default, used by
+// calls that neither name a site nor refer to a URL of one.
+var Profiles []Profile
+
+// DefaultProfile names the single profile read from the unprefixed
+// variables when ATLASSIAN_PROFILES is not set.
+const DefaultProfile = "default"
+
+// Deployment types accepted by ATLASSIAN_DEPLOYMENT.
+const (
+	DeploymentCloud      = "cloud"
+	DeploymentDataCenter = "datacenter"
+)
+
+// Authentication methods accepted by ATLASSIAN_AUTH.
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthOAuth  = "oauth"
+)
+
+// OAuth 2.0 (3LO) settings, shared by the profiles that use AuthOAuth. The
+// refresh token of each profile is encrypted with OAuthPassphrase.
+var (
+	OAuthClientID     string
+	OAuthClientSecret string
+	OAuthRedirectURL  = defaultOAuthRedirectURL
+	OAuthScopes       []string
+	OAuthPassphrase   string
+)
+
+// MaxConcurrentRequests bounds how many MCP requests are processed at once.
+var MaxConcurrentRequests = defaultMaxConcurrentRequests
+
+// Retry settings. Calls are attempted at most RetryMaxAttempts times within
+// RetryDeadline; RetryWrites allows retrying POST and PUT requests, which
+// Atlassian may apply twice.
 var (
-	Email  string
-	Token  string
-	Domain string
+	RetryMaxAttempts = defaultRetryMaxAttempts
+	RetryDeadline    = defaultRetryDeadline
+	RetryWrites      bool
+)
+
+// Rate limits per service. Each client gets its own limiters, so with one
+// client per site the limits apply per site. Requests beyond RateLimit per
+// second or MaxInFlight at once wait for their turn. Zero disables a limit.
+var (
+	JiraRateLimit         = defaultRateLimit
+	JiraMaxInFlight       = defaultMaxInFlight
+	ConfluenceRateLimit   = defaultRateLimit
+	ConfluenceMaxInFlight = defaultMaxInFlight
+)
+
+// Cache enables the response cache, which serves repeated reads of issues,
+// pages, users and other lookups within their TTL.
+var Cache bool
+
+// Cassette settings. With CassetteMode set, Atlassian API calls are recorded
+// to or replayed from the fixtures in CassetteDir.
+var (
+	CassetteMode string
+	CassetteDir  = defaultCassetteDir
+)
+
+// Cassette modes accepted by ATLASSIAN_CASSETTE.
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// Transport settings. Transport is "stdio" (default) or "http".
+var (
+	Transport = "stdio"
+	HTTPAddr  = defaultHTTPAddr
+	HTTPToken string
+)
+
+// Logging settings. Logs go to stderr unless LogFile is set.
+var (
+	LogLevel = slog.LevelInfo
+	LogFile  string
+)
+
+// Audit settings. Writes are recorded to AuditLog when set; AuditHashBodies
+// stores hashes instead of body and description text.
+var (
+	AuditLog        string
+	AuditHashBodies bool
+)
+
+// ConfirmWrites lists the writes that need human confirmation, as "verb" or
+// "verb:field" entries whose verb part is a path.Match pattern.
+var ConfirmWrites = []string{"confluence_update_page:body", "jira_update_issue:assignee"}
+
+// Tool exposure settings. ToolLayout is "combined" (atlassian_read and
+// atlassian_write) or "per-service" (jira_read, jira_write, confluence_read,
+// confluence_write). ReadOnly hides write tools. AllowedVerbs and DeniedVerbs
+// hold path.Match patterns such as "confluence_*".
+var (
+	ToolLayout   = LayoutCombined
+	ReadOnly     bool
+	AllowedVerbs []string
+	DeniedVerbs  []string
+)
+
+// Tool layouts accepted by ATLASSIAN_TOOL_LAYOUT.
+const (
+	LayoutCombined   = "combined"
+	LayoutPerService = "per-service"
 )
 
 // Pre-compiled regexes for input validation
 var (
 	// Jira patterns
 	issueKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]+-\d+$`)
-	issueURLPattern = regexp.MustCompile(`^https://[a-zA-Z0-9-]+\.atlassian\.net/browse/([A-Z][A-Z0-9]+-\d+)$`)
+	issueURLPattern = regexp.MustCompile(`^https?://` + hostPattern + `(?:/[^\s?#]*)?/browse/([A-Z][A-Z0-9]+-\d+)/?(?:[?#]\S*)?$`)
 
-	// Confluence patterns
-	pageURLPattern = regexp.MustCompile(`^https://([a-zA-Z0-9-]+)\.atlassian\.net/wiki/spaces/([A-Za-z0-9_-]+)/pages/(\d+)(?:/.*)?$`)
-	pageIDPattern  = regexp.MustCompile(`^\d+$`)
+	profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+	// Confluence patterns. Cloud pages live under /wiki; self-hosted sites
+	// may serve Confluence under any context path and also link to pages
+	// with viewpage.action.
+	pageURLPattern     = regexp.MustCompile(`^https?://` + hostPattern + `(?:/[^\s?#]*)?/spaces/([A-Za-z0-9_~-]+)/pages/(\d+)(?:[/?#]\S*)?$`)
+	viewPageURLPattern = regexp.MustCompile(`^https?://` + hostPattern + `(?:/[^\s?#]*)?/pages/viewpage\.action\?(?:\S*&)?pageId=(\d+)(?:[&#]\S*)?$`)
+	pageIDPattern      = regexp.MustCompile(`^\d+$`)
 )
 
+// hostPattern matches the host and optional port of a site URL.
+const hostPattern = `[a-zA-Z0-9.-]+(?::\d+)?`
+
 const (
 	maxIssueKeyLength = 50
 	maxInputLength    = 500
+
+	defaultMaxConcurrentRequests = 8
+	defaultRetryMaxAttempts      = 4
+	defaultRetryDeadline         = time.Minute
+	defaultRateLimit             = 10.0
+	defaultMaxInFlight           = 6
+	defaultHTTPAddr              = "127.0.0.1:8080"
+	defaultCassetteDir           = "cassettes"
+	defaultOAuthRedirectURL      = "http://127.0.0.1:8976/callback"
 )
 
 // loadEnvFile loads environment variables from a .env file in the binary's directory.
@@ -76,37 +231,436 @@ func loadEnvFile() error {
 	return scanner.Err()
 }
 
-func init() {
+// Load reads the settings from the environment and from a .env file next to
+// the binary. Variables already set in the environment take precedence.
+func Load() error {
 	_ = loadEnvFile()
 
-	Email = os.Getenv("ATLASSIAN_EMAIL")
-	Token = os.Getenv("ATLASSIAN_API_TOKEN")
-	Domain = os.Getenv("ATLASSIAN_DOMAIN")
+	OAuthClientID = os.Getenv("ATLASSIAN_OAUTH_CLIENT_ID")
+	OAuthClientSecret = os.Getenv("ATLASSIAN_OAUTH_CLIENT_SECRET")
+	if v := os.Getenv("ATLASSIAN_OAUTH_REDIRECT_URL"); v != "" {
+		OAuthRedirectURL = v
+	}
+	OAuthScopes = splitList(os.Getenv("ATLASSIAN_OAUTH_SCOPES"))
+	OAuthPassphrase = os.Getenv("ATLASSIAN_OAUTH_PASSPHRASE")
+
+	if v := os.Getenv("ATLASSIAN_MAX_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return errors.New("ATLASSIAN_MAX_CONCURRENCY must be a positive integer")
+		}
+		MaxConcurrentRequests = n
+	}
+
+	if v := os.Getenv("ATLASSIAN_RETRY_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return errors.New("ATLASSIAN_RETRY_MAX_ATTEMPTS must be a positive integer")
+		}
+		RetryMaxAttempts = n
+	}
+	if v := os.Getenv("ATLASSIAN_RETRY_DEADLINE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return errors.New("ATLASSIAN_RETRY_DEADLINE must be a duration such as 30s or 2m")
+		}
+		RetryDeadline = d
+	}
+	if v := os.Getenv("ATLASSIAN_RETRY_WRITES"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("ATLASSIAN_RETRY_WRITES must be true or false")
+		}
+		RetryWrites = b
+	}
+
+	for _, limit := range []struct {
+		name string
+		rate *float64
+		max  *int
+	}{
+		{"JIRA", &JiraRateLimit, &JiraMaxInFlight},
+		{"CONFLUENCE", &ConfluenceRateLimit, &ConfluenceMaxInFlight},
+	} {
+		if v := os.Getenv("ATLASSIAN_" + limit.name + "_RATE_LIMIT"); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				return fmt.Errorf("ATLASSIAN_%s_RATE_LIMIT must be a number of requests per second, or 0 for no limit", limit.name)
+			}
+			*limit.rate = f
+		}
+		if v := os.Getenv("ATLASSIAN_" + limit.name + "_MAX_IN_FLIGHT"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("ATLASSIAN_%s_MAX_IN_FLIGHT must be a non-negative integer", limit.name)
+			}
+			*limit.max = n
+		}
+	}
+
+	if v := os.Getenv("ATLASSIAN_CACHE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("ATLASSIAN_CACHE must be true or false")
+		}
+		Cache = b
+	}
+
+	if v := os.Getenv("ATLASSIAN_CASSETTE"); v != "" {
+		if v != CassetteRecord && v != CassetteReplay {
+			return errors.New("ATLASSIAN_CASSETTE must be record or replay")
+		}
+		CassetteMode = v
+	}
+	if v := os.Getenv("ATLASSIAN_CASSETTE_DIR"); v != "" {
+		CassetteDir = v
+	}
+
+	if v := os.Getenv("ATLASSIAN_TRANSPORT"); v != "" {
+		Transport = v
+	}
+	if v := os.Getenv("ATLASSIAN_HTTP_ADDR"); v != "" {
+		HTTPAddr = v
+	}
+	HTTPToken = os.Getenv("ATLASSIAN_HTTP_TOKEN")
+
+	if v := os.Getenv("ATLASSIAN_TOOL_LAYOUT"); v != "" {
+		if v != LayoutCombined && v != LayoutPerService {
+			return errors.New("ATLASSIAN_TOOL_LAYOUT must be combined or per-service")
+		}
+		ToolLayout = v
+	}
+	if v := os.Getenv("ATLASSIAN_READ_ONLY"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("ATLASSIAN_READ_ONLY must be true or false")
+		}
+		ReadOnly = b
+	}
+	if v := os.Getenv("ATLASSIAN_LOG_LEVEL"); v != "" {
+		if err := LogLevel.UnmarshalText([]byte(v)); err != nil {
+			return errors.New("ATLASSIAN_LOG_LEVEL must be debug, info, warn or error")
+		}
+	}
+	LogFile = os.Getenv("ATLASSIAN_LOG_FILE")
+
+	AuditLog = os.Getenv("ATLASSIAN_AUDIT_LOG")
+	if v := os.Getenv("ATLASSIAN_AUDIT_HASH_BODIES"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("ATLASSIAN_AUDIT_HASH_BODIES must be true or false")
+		}
+		AuditHashBodies = b
+	}
+
+	if v, ok := os.LookupEnv("ATLASSIAN_CONFIRM_WRITES"); ok {
+		ConfirmWrites = nil
+		if v != "none" {
+			ConfirmWrites = splitList(v)
+		}
+	}
+	for _, rule := range ConfirmWrites {
+		verb, _, _ := strings.Cut(rule, ":")
+		if _, err := path.Match(verb, ""); err != nil {
+			return fmt.Errorf("invalid confirm rule %q", rule)
+		}
+	}
+
+	AllowedVerbs = splitList(os.Getenv("ATLASSIAN_ALLOWED_VERBS"))
+	DeniedVerbs = splitList(os.Getenv("ATLASSIAN_DENIED_VERBS"))
+	for _, pattern := range slices.Concat(AllowedVerbs, DeniedVerbs) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid verb pattern %q", pattern)
+		}
+	}
+
+	return loadProfiles()
+}
+
+// loadProfiles reads the profiles listed in ATLASSIAN_PROFILES, or the
+// default profile when there is no list. ATLASSIAN_DEFAULT_PROFILE picks
+// the default among listed profiles; otherwise it is the first.
+func loadProfiles() error {
+	names := splitList(os.Getenv("ATLASSIAN_PROFILES"))
+	if len(names) == 0 {
+		p, err := loadProfile(DefaultProfile, "ATLASSIAN_")
+		if err != nil {
+			return err
+		}
+		Profiles = []Profile{p}
+		return nil
+	}
 
-	if Domain != "" {
-		if !strings.HasSuffix(Domain, ".atlassian.net") {
-			fmt.Fprintln(os.Stderr, "Error: ATLASSIAN_DOMAIN must be an atlassian.net domain")
-			os.Exit(1)
+	Profiles = nil
+	for _, name := range names {
+		if !profileNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+		}
+		if _, ok := findProfile(Profiles, name); ok {
+			return fmt.Errorf("profile %q is listed twice", name)
 		}
-		if strings.Contains(Domain, "/") || strings.Contains(Domain, ":") {
-			fmt.Fprintln(os.Stderr, "Error: ATLASSIAN_DOMAIN must be a domain only (no protocol or path)")
-			os.Exit(1)
+		p, err := loadProfile(name, profilePrefix(name))
+		if err != nil {
+			return err
 		}
+		Profiles = append(Profiles, p)
 	}
+
+	if name := os.Getenv("ATLASSIAN_DEFAULT_PROFILE"); name != "" {
+		i := slices.IndexFunc(Profiles, func(p Profile) bool { return p.Name == name })
+		if i < 0 {
+			return fmt.Errorf("ATLASSIAN_DEFAULT_PROFILE %q is not listed in ATLASSIAN_PROFILES", name)
+		}
+		Profiles[0], Profiles[i] = Profiles[i], Profiles[0]
+	}
+	return nil
 }
 
-// JiraBaseURL returns the base URL for Jira API requests.
-func JiraBaseURL() string {
-	return fmt.Sprintf("https://%s", Domain)
+// profilePrefix returns the prefix of the variables of a listed profile,
+// e.g. ATLASSIAN_PROFILE_CUSTOMER_A_ for customer-a.
+func profilePrefix(name string) string {
+	return "ATLASSIAN_PROFILE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
 }
 
-// ConfluenceBaseURL returns the base URL for Confluence API requests.
-func ConfluenceBaseURL() string {
-	return fmt.Sprintf("https://%s/wiki", Domain)
+// loadProfile reads a profile from the variables with prefix. Unset
+// variables of a listed profile fall back to the unprefixed ones, so
+// settings shared by all sites are given once. The site and its credentials
+// are never shared, so a profile cannot send another site's token, and
+// neither is the OAuth token file.
+func loadProfile(name, prefix string) (Profile, error) {
+	getenv := func(suffix string) string {
+		if v := os.Getenv(prefix + suffix); v != "" {
+			return v
+		}
+		return os.Getenv("ATLASSIAN_" + suffix)
+	}
+	own := func(suffix string) string {
+		return os.Getenv(prefix + suffix)
+	}
+
+	p := Profile{
+		Name:       name,
+		Deployment: DeploymentCloud,
+		AuthMethod: AuthBasic,
+		Email:      own("EMAIL"),
+		Token:      own("API_TOKEN"),
+		Domain:     own("DOMAIN"),
+		Project:    getenv("PROJECT"),
+		Space:      getenv("SPACE"),
+	}
+
+	if v := getenv("DEPLOYMENT"); v != "" {
+		if v != DeploymentCloud && v != DeploymentDataCenter {
+			return Profile{}, fmt.Errorf("%sDEPLOYMENT must be cloud or datacenter", prefix)
+		}
+		p.Deployment = v
+	}
+	p.JiraURL = strings.TrimSuffix(own("JIRA_URL"), "/")
+	p.ConfluenceURL = strings.TrimSuffix(own("CONFLUENCE_URL"), "/")
+	for suffix, v := range map[string]string{"JIRA_URL": p.JiraURL, "CONFLUENCE_URL": p.ConfluenceURL} {
+		if v == "" {
+			continue
+		}
+		if u, err := url.Parse(v); err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return Profile{}, fmt.Errorf("%s%s must be an https base URL such as https://jira.example.com", prefix, suffix)
+		}
+	}
+
+	if p.Deployment == DeploymentDataCenter {
+		p.AuthMethod = AuthBearer
+	}
+	if v := getenv("AUTH"); v != "" {
+		if v != AuthBasic && v != AuthBearer && v != AuthOAuth {
+			return Profile{}, fmt.Errorf("%sAUTH must be basic, bearer or oauth", prefix)
+		}
+		p.AuthMethod = v
+	}
+
+	p.OAuthTokenFile = os.Getenv(prefix + "OAUTH_TOKEN_FILE")
+	if p.OAuthTokenFile == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			file := "oauth-token.json"
+			if name != DefaultProfile {
+				file = "oauth-token-" + name + ".json"
+			}
+			p.OAuthTokenFile = filepath.Join(dir, "atlassian-mcp", file)
+		}
+	}
+
+	if p.Domain != "" && p.Deployment == DeploymentCloud {
+		if !strings.HasSuffix(p.Domain, ".atlassian.net") {
+			return Profile{}, fmt.Errorf("%sDOMAIN must be an atlassian.net domain", prefix)
+		}
+		if strings.Contains(p.Domain, "/") || strings.Contains(p.Domain, ":") {
+			return Profile{}, fmt.Errorf("%sDOMAIN must be a domain only (no protocol or path)", prefix)
+		}
+	}
+	return p, p.checkCredentials(prefix)
+}
+
+// checkCredentials reports the settings missing for the profile's
+// AuthMethod, naming the variables with prefix.
+func (p Profile) checkCredentials(prefix string) error {
+	if p.Deployment == DeploymentDataCenter {
+		return p.checkDataCenterCredentials(prefix)
+	}
+	switch p.AuthMethod {
+	case AuthBearer:
+		return missing(prefix, "API_TOKEN", p.Token, "DOMAIN", p.Domain)
+	case AuthOAuth:
+		if p.Domain == "" || OAuthClientID == "" || OAuthPassphrase == "" {
+			return fmt.Errorf("%sDOMAIN, ATLASSIAN_OAUTH_CLIENT_ID and ATLASSIAN_OAUTH_PASSPHRASE environment variables must be set", prefix)
+		}
+		if p.OAuthTokenFile == "" {
+			return fmt.Errorf("%sOAUTH_TOKEN_FILE must be set when there is no user config directory", prefix)
+		}
+	default:
+		return missing(prefix, "EMAIL", p.Email, "API_TOKEN", p.Token, "DOMAIN", p.Domain)
+	}
+	return nil
+}
+
+// checkDataCenterCredentials reports the settings missing for a Data Center
+// deployment, which authenticates with a personal access token or, with
+// AUTH=basic, a username and password.
+func (p Profile) checkDataCenterCredentials(prefix string) error {
+	if p.JiraURL == "" && p.ConfluenceURL == "" {
+		return fmt.Errorf("%sJIRA_URL or %sCONFLUENCE_URL must be set for a Data Center deployment", prefix, prefix)
+	}
+	switch p.AuthMethod {
+	case AuthOAuth:
+		return fmt.Errorf("%sAUTH=oauth is only supported on Cloud; use a personal access token on Data Center", prefix)
+	case AuthBasic:
+		if err := missing(prefix, "EMAIL", p.Email, "API_TOKEN", p.Token); err != nil {
+			return fmt.Errorf("%w (the username and password)", err)
+		}
+	default:
+		if err := missing(prefix, "API_TOKEN", p.Token); err != nil {
+			return fmt.Errorf("%w (a personal access token)", err)
+		}
+	}
+	return nil
+}
+
+// missing reports the variables with prefix that are unset, given as pairs
+// of variable suffix and value.
+func missing(prefix string, vars ...string) error {
+	var names []string
+	for i := 0; i+1 < len(vars); i += 2 {
+		if vars[i+1] == "" {
+			names = append(names, prefix+vars[i])
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing %s", strings.Join(names, ", "))
+}
+
+// hosts lists the hosts, with any port, that the profile's issue and page
+// URLs are served from.
+func (p Profile) hosts() []string {
+	var hosts []string
+	if p.Domain != "" {
+		hosts = append(hosts, strings.ToLower(p.Domain))
+	}
+	for _, base := range []string{p.JiraURL, p.ConfluenceURL} {
+		if u, err := url.Parse(base); err == nil && u.Host != "" {
+			hosts = append(hosts, strings.ToLower(u.Host))
+		}
+	}
+	return hosts
+}
+
+// FindProfile looks up a profile by name.
+func FindProfile(name string) (Profile, bool) {
+	return findProfile(Profiles, name)
+}
+
+func findProfile(profiles []Profile, name string) (Profile, bool) {
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// ProfileForURL returns the profile of the site serving rawURL, if any.
+func ProfileForURL(rawURL string) (Profile, bool) {
+	return profileForURL(Profiles, rawURL)
+}
+
+func profileForURL(profiles []Profile, rawURL string) (Profile, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return Profile{}, false
+	}
+	host := strings.ToLower(u.Host)
+	for _, p := range profiles {
+		if slices.Contains(p.hosts(), host) {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// splitList parses a comma-separated list, dropping empty entries.
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// VerbAllowed reports whether verb passes the configured allowlist and
+// denylist. The denylist wins; an empty allowlist allows everything.
+func VerbAllowed(verb string) bool {
+	return verbAllowed(verb, AllowedVerbs, DeniedVerbs)
+}
+
+func verbAllowed(verb string, allowed, denied []string) bool {
+	if matchAny(denied, verb) {
+		return false
+	}
+	return len(allowed) == 0 || matchAny(allowed, verb)
+}
+
+func matchAny(patterns []string, verb string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, verb); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsConfirmation reports whether changing field through verb needs human
+// confirmation under ConfirmWrites. A rule without a field covers every
+// field of the verb.
+func NeedsConfirmation(verb, field string) bool {
+	return needsConfirmation(verb, field, ConfirmWrites)
+}
+
+func needsConfirmation(verb, field string, rules []string) bool {
+	for _, rule := range rules {
+		pattern, ruleField, hasField := strings.Cut(rule, ":")
+		if hasField && ruleField != field {
+			continue
+		}
+		if ok, _ := path.Match(pattern, verb); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // ExtractIssueKey extracts issue key from URL or returns input if already a key.
-// Supports: https://domain.atlassian.net/browse/PROJ-123 or just PROJ-123
+// Supports: https://domain.atlassian.net/browse/PROJ-123, self-hosted URLs such
+// as https://jira.example.com/browse/PROJ-123, or just PROJ-123
 func ExtractIssueKey(input string) (string, error) {
 	input = strings.TrimSpace(input)
 
@@ -130,6 +684,8 @@ func ExtractIssueKey(input string) (string, error) {
 }
 
 // ExtractPageID extracts page ID from URL or returns input if already an ID.
+// Supports Cloud and self-hosted /spaces/SPACE/pages/ID URLs and self-hosted
+// viewpage.action?pageId=ID URLs.
 func ExtractPageID(input string) (string, error) {
 	input = strings.TrimSpace(input)
 
@@ -141,10 +697,12 @@ func ExtractPageID(input string) (string, error) {
 		return input, nil
 	}
 
-	matches := pageURLPattern.FindStringSubmatch(input)
-	if len(matches) >= 4 {
-		return matches[3], nil
+	if matches := pageURLPattern.FindStringSubmatch(input); len(matches) == 3 {
+		return matches[2], nil
+	}
+	if matches := viewPageURLPattern.FindStringSubmatch(input); len(matches) == 2 {
+		return matches[1], nil
 	}
 
-	return "", fmt.Errorf("invalid input: must be page ID or full Confluence URL (e.g., https://domain.atlassian.net/wiki/spaces/SPACE/pages/123456/Title)")
+	return "", fmt.Errorf("invalid input: must be page ID or full Confluence URL (e.g., https://domain.atlassian.net/wiki/spaces/SPACE/pages/123456/Title or https://confluence.example.com/pages/viewpage.action?pageId=123456)")
 }
@@ -0,0 +1,227 @@
+package config
+
+import "testing"
+
+func TestVerbAllowed(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		verb    string
+		allowed []string
+		denied  []string
+		want    bool
+	}{
+		{"no lists", "confluence_update_page", nil, nil, true},
+		{"exact allow", "jira_get_issue", []string{"jira_get_issue"}, nil, true},
+		{"not in allowlist", "jira_search", []string{"jira_get_issue"}, nil, false},
+		{"glob allow", "jira_update_issue", []string{"jira_*"}, nil, true},
+		{"glob allow other service", "confluence_get_page", []string{"jira_*"}, nil, false},
+		{"exact deny", "confluence_create_page", nil, []string{"confluence_create_page"}, false},
+		{"glob deny", "confluence_update_page", nil, []string{"confluence_*"}, false},
+		{"deny wins over allow", "jira_create_issue", []string{"jira_*"}, []string{"jira_create_issue"}, false},
+		{"deny leaves others", "jira_add_comment", []string{"jira_*"}, []string{"jira_create_issue"}, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := verbAllowed(tt.verb, tt.allowed, tt.denied); got != tt.want {
+				t.Errorf("verbAllowed(%q) = %v, want %v", tt.verb, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsConfirmation(t *testing.T) {
+	t.Parallel()
+	defaults := []string{"confluence_update_page:body", "jira_update_issue:assignee"}
+	tests := []struct {
+		name  string
+		verb  string
+		field string
+		rules []string
+		want  bool
+	}{
+		{"page body", "confluence_update_page", "body", defaults, true},
+		{"page title", "confluence_update_page", "title", defaults, false},
+		{"assignee", "jira_update_issue", "assignee", defaults, true},
+		{"summary", "jira_update_issue", "summary", defaults, false},
+		{"other verb", "jira_add_comment", "comment", defaults, false},
+		{"whole verb", "jira_create_issue", "summary", []string{"jira_create_issue"}, true},
+		{"glob verb", "jira_delete_issue", "issue", []string{"*_delete_*"}, true},
+		{"glob with field", "confluence_update_page", "body", []string{"confluence_*:body"}, true},
+		{"no rules", "confluence_update_page", "body", nil, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := needsConfirmation(tt.verb, tt.field, tt.rules); got != tt.want {
+				t.Errorf("needsConfirmation(%q, %q) = %v, want %v", tt.verb, tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{" a , b,,c ", []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			got := splitList(tt.in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitList(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("splitList(%q) = %q, want %q", tt.in, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractIssueKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"key", "PROJ-123", "PROJ-123", false},
+		{"cloud", "https://example.atlassian.net/browse/PROJ-123", "PROJ-123", false},
+		{"self-hosted", "https://jira.example.com/browse/PROJ-123", "PROJ-123", false},
+		{"context path and port", "https://example.com:8443/jira/browse/PROJ-1?focused=1", "PROJ-1", false},
+		{"not an issue", "https://jira.example.com/projects/PROJ", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ExtractIssueKey(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractIssueKey(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractIssueKey(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPageID(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"id", "12345", "12345", false},
+		{"cloud", "https://example.atlassian.net/wiki/spaces/DEV/pages/12345/Title", "12345", false},
+		{"self-hosted", "https://wiki.example.com/spaces/DEV/pages/12345", "12345", false},
+		{"viewpage", "https://wiki.example.com/confluence/pages/viewpage.action?pageId=12345", "12345", false},
+		{"viewpage with params", "https://wiki.example.com/pages/viewpage.action?spaceKey=DEV&pageId=12345#top", "12345", false},
+		{"display URL", "https://wiki.example.com/display/DEV/Title", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ExtractPageID(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractPageID(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractPageID(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileForURL(t *testing.T) {
+	t.Parallel()
+	profiles := []Profile{
+		{Name: "company", Domain: "company.atlassian.net"},
+		{Name: "customer", Domain: "customer.atlassian.net"},
+		{Name: "onprem", JiraURL: "https://jira.example.com:8443/jira", ConfluenceURL: "https://wiki.example.com"},
+	}
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"cloud issue", "https://customer.atlassian.net/browse/PROJ-1", "customer"},
+		{"cloud page", "https://company.atlassian.net/wiki/spaces/DEV/pages/123", "company"},
+		{"host case", "https://Customer.Atlassian.net/browse/PROJ-1", "customer"},
+		{"jira with port", "https://jira.example.com:8443/jira/browse/PROJ-1", "onprem"},
+		{"confluence", "https://wiki.example.com/pages/viewpage.action?pageId=1", "onprem"},
+		{"port mismatch", "https://jira.example.com/browse/PROJ-1", ""},
+		{"unknown host", "https://other.atlassian.net/browse/PROJ-1", ""},
+		{"not a URL", "PROJ-1", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, _ := profileForURL(profiles, tt.url)
+			if p.Name != tt.want {
+				t.Errorf("profileForURL(%q) = %q, want %q", tt.url, p.Name, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadProfiles sets environment variables, so it cannot run in parallel.
+func TestLoadProfiles(t *testing.T) {
+	t.Setenv("ATLASSIAN_EMAIL", "me@example.com")
+	t.Setenv("ATLASSIAN_API_TOKEN", "shared")
+	t.Setenv("ATLASSIAN_SPACE", "DOCS")
+	t.Setenv("ATLASSIAN_PROFILES", "company, customer-a")
+	t.Setenv("ATLASSIAN_DEFAULT_PROFILE", "customer-a")
+	t.Setenv("ATLASSIAN_PROFILE_COMPANY_DOMAIN", "company.atlassian.net")
+	t.Setenv("ATLASSIAN_PROFILE_COMPANY_EMAIL", "me@company.com")
+	t.Setenv("ATLASSIAN_PROFILE_COMPANY_API_TOKEN", "company")
+	t.Setenv("ATLASSIAN_PROFILE_COMPANY_PROJECT", "OPS")
+	t.Setenv("ATLASSIAN_PROFILE_CUSTOMER_A_DOMAIN", "customer.atlassian.net")
+	t.Setenv("ATLASSIAN_PROFILE_CUSTOMER_A_EMAIL", "customer@example.com")
+	t.Setenv("ATLASSIAN_PROFILE_CUSTOMER_A_API_TOKEN", "customer")
+	prev := Profiles
+	t.Cleanup(func() { Profiles = prev })
+
+	if err := loadProfiles(); err != nil {
+		t.Fatalf("loadProfiles() error = %v", err)
+	}
+	if len(Profiles) != 2 {
+		t.Fatalf("got %d profiles, want 2", len(Profiles))
+	}
+	customer, company := Profiles[0], Profiles[1]
+	if customer.Name != "customer-a" || customer.Token != "customer" || customer.Email != "customer@example.com" {
+		t.Errorf("default profile = %+v, want customer-a with its own credentials", customer)
+	}
+	if company.Domain != "company.atlassian.net" || company.Token != "company" || company.Project != "OPS" || company.Space != "DOCS" {
+		t.Errorf("company profile = %+v, want its own site and token and the shared space", company)
+	}
+
+	// Credentials are never shared with a profile that lacks its own
+	t.Setenv("ATLASSIAN_PROFILE_COMPANY_API_TOKEN", "")
+	if err := loadProfiles(); err == nil || err.Error() != "missing ATLASSIAN_PROFILE_COMPANY_API_TOKEN" {
+		t.Errorf("loadProfiles() error = %v, want the missing company token", err)
+	}
+	t.Setenv("ATLASSIAN_PROFILE_COMPANY_API_TOKEN", "company")
+
+	t.Setenv("ATLASSIAN_PROFILE_CUSTOMER_A_DOMAIN", "customer.example.com")
+	if err := loadProfiles(); err == nil || err.Error() != "ATLASSIAN_PROFILE_CUSTOMER_A_DOMAIN must be an atlassian.net domain" {
+		t.Errorf("loadProfiles() error = %v, want the prefixed domain error", err)
+	}
+}
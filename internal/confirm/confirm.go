@@ -0,0 +1,138 @@
+// Package confirm asks a human to approve destructive writes before they are
+// sent, as configured by ATLASSIAN_CONFIRM_WRITES. Clients that support MCP
+// elicitation are asked directly; otherwise the agent must repeat the call
+// with "confirm": true after showing the change to the user.
+package confirm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/preview"
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+const (
+	// excerptLength caps the old and new values shown for each field.
+	excerptLength = 200
+
+	// elicitTimeout bounds how long a write waits for the user to answer.
+	elicitTimeout = 10 * time.Minute
+)
+
+type confirmKey struct{}
+
+// confirmation is the state of the write being handled.
+type confirmation struct {
+	verb      string
+	confirmed bool
+}
+
+// NewContext returns a context for a call to the write verb. confirmed
+// reports whether the caller passed "confirm": true.
+func NewContext(ctx context.Context, verb string, confirmed bool) context.Context {
+	return context.WithValue(ctx, confirmKey{}, confirmation{verb: verb, confirmed: confirmed})
+}
+
+// Changes returns nil if the changes may be written. Changes to fields that
+// need confirmation are shown to the user through elicitation when the client
+// supports it and can be reached, whether or not the agent passed
+// "confirm": true; otherwise an error asks the agent to confirm explicitly.
+// Writes outside a context from NewContext are not checked.
+func Changes(ctx context.Context, changes []preview.Change) error {
+	c, ok := ctx.Value(confirmKey{}).(confirmation)
+	if !ok {
+		return nil
+	}
+	guarded := c.guarded(changes)
+	if len(guarded) == 0 {
+		return nil
+	}
+
+	summary := summarize(guarded)
+	if session.FromContext(ctx).SupportsElicitation() {
+		err := elicit(ctx, c.verb, summary)
+		if !errors.Is(err, session.ErrUndelivered) {
+			return err
+		}
+		// The client could not be asked, such as over HTTP with no stream
+		// open, so it confirms explicitly like clients without elicitation
+	}
+	if c.confirmed {
+		return nil
+	}
+	return fmt.Errorf("%s needs human confirmation for this change. Show the user the change below and, once they approve it, repeat the call with \"confirm\": true.\n\n%s", c.verb, summary)
+}
+
+// Asks reports whether Changes will wait for the user to answer an
+// elicitation, after which the state the changes were checked against may
+// be outdated.
+func Asks(ctx context.Context, changes []preview.Change) bool {
+	c, ok := ctx.Value(confirmKey{}).(confirmation)
+	return ok && len(c.guarded(changes)) > 0 && session.FromContext(ctx).SupportsElicitation()
+}
+
+// guarded returns the changes that need confirmation.
+func (c confirmation) guarded(changes []preview.Change) []preview.Change {
+	var guarded []preview.Change
+	for _, change := range changes {
+		if config.NeedsConfirmation(c.verb, change.Field) {
+			guarded = append(guarded, change)
+		}
+	}
+	return guarded
+}
+
+// elicit asks the user to accept the change through the client.
+func elicit(ctx context.Context, verb, summary string) error {
+	ctx, cancel := context.WithTimeout(ctx, elicitTimeout)
+	defer cancel()
+
+	raw, err := session.Request(ctx, "elicitation/create", types.ElicitParams{
+		Message:         fmt.Sprintf("Allow %s to make this change?\n\n%s", verb, summary),
+		RequestedSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	})
+	if err != nil {
+		return fmt.Errorf("confirmation failed: %w", err)
+	}
+
+	var result types.ElicitResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return fmt.Errorf("failed to parse confirmation response")
+	}
+	switch result.Action {
+	case "accept":
+		return nil
+	case "decline":
+		return fmt.Errorf("the user declined the change; nothing was written")
+	default:
+		return fmt.Errorf("the user dismissed the confirmation; nothing was written")
+	}
+}
+
+// summarize lists each field with excerpts of its old and new values.
+func summarize(changes []preview.Change) string {
+	var sb strings.Builder
+	for _, c := range changes {
+		sb.WriteString(fmt.Sprintf("- %s\n  old: %s\n  new: %s\n", c.Field, excerpt(c.Before), excerpt(c.After)))
+	}
+	return sb.String()
+}
+
+// excerpt collapses whitespace and truncates s to excerptLength runes.
+func excerpt(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return "(empty)"
+	}
+	if runes := []rune(s); len(runes) > excerptLength {
+		return string(runes[:excerptLength]) + "…"
+	}
+	return s
+}
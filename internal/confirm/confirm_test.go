@@ -0,0 +1,166 @@
+package confirm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/preview"
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+// bodyChange replaces a page body, which needs confirmation by default.
+var bodyChange = []preview.Change{{Field: "body", Before: "Old text", After: "New text"}}
+
+// elicitingSession returns a session whose client supports elicitation and
+// answers every request with action. It records the messages it was sent.
+func elicitingSession(action string, messages *[]string) *session.Session {
+	var s *session.Session
+	s = session.New(func(msg any) {
+		req, ok := msg.(types.Request)
+		if !ok {
+			return
+		}
+		var p types.ElicitParams
+		_ = json.Unmarshal(req.Params, &p)
+		*messages = append(*messages, p.Message)
+		go s.HandleResponse(json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"action":%q}}`, req.ID, action)))
+	})
+	s.SetClientCapabilities(map[string]any{"elicitation": map[string]any{}})
+	return s
+}
+
+func TestChanges(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		verb      string
+		confirmed bool
+		changes   []preview.Change
+		wantErr   string
+	}{
+		{"unguarded field", "confluence_update_page", false, []preview.Change{{Field: "title", After: "New"}}, ""},
+		{"unguarded verb", "confluence_add_comment", false, []preview.Change{{Field: "comment", After: "Hi"}}, ""},
+		{"confirmed", "confluence_update_page", true, bodyChange, ""},
+		{"needs confirm", "confluence_update_page", false, bodyChange, `"confirm": true`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := session.NewContext(context.Background(), session.New(nil))
+			err := Changes(NewContext(ctx, tt.verb, tt.confirmed), tt.changes)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Changes() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Changes() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChanges_FallbackShowsChange(t *testing.T) {
+	t.Parallel()
+	ctx := NewContext(context.Background(), "confluence_update_page", false)
+	err := Changes(ctx, bodyChange)
+	if err == nil {
+		t.Fatal("Changes() error = nil, want a confirmation request")
+	}
+	for _, want := range []string{"- body", "old: Old text", "new: New text"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Changes() error missing %q:\n%s", want, err)
+		}
+	}
+}
+
+func TestChanges_Elicitation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		action  string
+		wantErr bool
+	}{
+		{"accept", false},
+		{"decline", true},
+		{"cancel", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.action, func(t *testing.T) {
+			t.Parallel()
+			var messages []string
+			ctx := session.NewContext(context.Background(), elicitingSession(tt.action, &messages))
+			err := Changes(NewContext(ctx, "confluence_update_page", false), bodyChange)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Changes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(messages) != 1 || !strings.Contains(messages[0], "old: Old text") {
+				t.Errorf("elicitation messages = %q", messages)
+			}
+		})
+	}
+}
+
+func TestChanges_ConfirmParamStillElicits(t *testing.T) {
+	t.Parallel()
+	var messages []string
+	ctx := session.NewContext(context.Background(), elicitingSession("decline", &messages))
+	if err := Changes(NewContext(ctx, "confluence_update_page", true), bodyChange); err == nil {
+		t.Error("Changes() error = nil, want the declined elicitation to win over confirm")
+	}
+	if len(messages) != 1 {
+		t.Errorf("elicitation messages = %q, want one", messages)
+	}
+}
+
+func TestChanges_Unreachable(t *testing.T) {
+	t.Parallel()
+	// The client supports elicitation but has no stream to receive it
+	var s *session.Session
+	s = session.New(func(msg any) {
+		if req, ok := msg.(types.Request); ok {
+			s.Undelivered(req.ID)
+		}
+	})
+	s.SetClientCapabilities(map[string]any{"elicitation": map[string]any{}})
+	ctx := session.NewContext(context.Background(), s)
+
+	if err := Changes(NewContext(ctx, "confluence_update_page", false), bodyChange); err == nil || !strings.Contains(err.Error(), `"confirm": true`) {
+		t.Errorf("Changes() error = %v, want a request to confirm explicitly", err)
+	}
+	if err := Changes(NewContext(ctx, "confluence_update_page", true), bodyChange); err != nil {
+		t.Errorf("Changes() confirmed error = %v", err)
+	}
+}
+
+func TestChanges_NoContext(t *testing.T) {
+	t.Parallel()
+	if err := Changes(context.Background(), bodyChange); err != nil {
+		t.Errorf("Changes() error = %v, want nil outside a verb call", err)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", "(empty)"},
+		{"whitespace collapsed", "a\n\n  b", "a b"},
+		{"truncated", strings.Repeat("é", excerptLength+5), strings.Repeat("é", excerptLength) + "…"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := excerpt(tt.in); got != tt.want {
+				t.Errorf("excerpt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
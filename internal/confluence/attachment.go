@@ -2,7 +2,7 @@ package confluence
 
 import (
 	"bytes"
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,7 +13,8 @@ import (
 	"strings"
 
 	"atlassian-mcp/internal/client"
-	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/preview"
+	"atlassian-mcp/internal/session"
 	"atlassian-mcp/internal/types"
 )
 
@@ -45,9 +46,8 @@ type pendingUpload struct {
 }
 
 // UploadAttachment uploads a file to a Confluence page and returns attachment info.
-func UploadAttachment(pageID string, fileData []byte, filename string) (*types.ConfluenceAttachmentInfo, error) {
-	endpoint := fmt.Sprintf("/wiki/rest/api/content/%s/child/attachment", pageID)
-	reqURL := fmt.Sprintf("https://%s%s", config.Domain, endpoint)
+func UploadAttachment(ctx context.Context, c *client.Client, pageID string, fileData []byte, filename string) (*types.ConfluenceAttachmentInfo, error) {
+	reqURL := c.URL(client.Confluence, fmt.Sprintf("/rest/api/content/%s/child/attachment", pageID))
 
 	// Create multipart form
 	var buf bytes.Buffer
@@ -66,18 +66,16 @@ func UploadAttachment(pageID string, fileData []byte, filename string) (*types.C
 		return nil, fmt.Errorf("failed to close multipart writer: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", reqURL, &buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, &buf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request")
 	}
 
-	auth := base64.StdEncoding.EncodeToString([]byte(config.Email + ":" + config.Token))
-	req.Header.Set("Authorization", "Basic "+auth)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("X-Atlassian-Token", "no-check") // Required for attachment uploads
 
-	resp, err := client.HTTPClient.Do(req)
+	resp, err := c.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Confluence: %v", err)
 	}
@@ -89,7 +87,7 @@ func UploadAttachment(pageID string, fileData []byte, filename string) (*types.C
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("attachment upload failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("attachment upload failed: %w", client.NewAPIError(client.Confluence, resp, respBody))
 	}
 
 	// V1 API response has "results" array with basic info
@@ -108,9 +106,17 @@ func UploadAttachment(pageID string, fileData []byte, filename string) (*types.C
 	}
 
 	attachmentID := v1Response.Results[0].ID
+	if c.DataCenter {
+		// Storage format references attachments by filename; there is no fileId
+		return &types.ConfluenceAttachmentInfo{
+			ID:     attachmentID,
+			Title:  v1Response.Results[0].Title,
+			FileID: attachmentID,
+		}, nil
+	}
 
 	// Fetch fileId using V2 API
-	fileID, err := getAttachmentFileID(attachmentID)
+	fileID, err := getAttachmentFileID(ctx, c, attachmentID)
 	if err != nil {
 		// Fall back to using attachment ID if we can't get fileId
 		fileID = attachmentID
@@ -124,8 +130,8 @@ func UploadAttachment(pageID string, fileData []byte, filename string) (*types.C
 }
 
 // getAttachmentFileID fetches the fileId for an attachment using V2 API.
-func getAttachmentFileID(attachmentID string) (string, error) {
-	body, err := client.Request(client.Confluence, fmt.Sprintf("/api/v2/attachments/%s", attachmentID))
+func getAttachmentFileID(ctx context.Context, c *client.Client, attachmentID string) (string, error) {
+	body, err := c.Request(ctx, client.Confluence, fmt.Sprintf("/api/v2/attachments/%s", attachmentID))
 	if err != nil {
 		return "", err
 	}
@@ -142,9 +148,9 @@ func getAttachmentFileID(attachmentID string) (string, error) {
 
 // UploadPendingMedia walks the ADF tree, validates all pending media, and uploads them.
 // All files are validated before any uploads occur to prevent partial uploads.
-func UploadPendingMedia(pageID string, adf map[string]any) error {
+func UploadPendingMedia(ctx context.Context, c *client.Client, pageID string, adf map[string]any) error {
 	// Phase 1: Collect all pending uploads into memory
-	pending, err := collectPendingUploads(pageID, adf)
+	pending, err := collectPendingUploads(ctx, pageID, adf)
 	if err != nil {
 		return fmt.Errorf("failed to collect uploads: %w", err)
 	}
@@ -152,31 +158,60 @@ func UploadPendingMedia(pageID string, adf map[string]any) error {
 		return nil
 	}
 
+	session.ExpectSteps(ctx, 1+len(pending))
+
 	// Phase 2: Validate all uploads
 	if err := validatePendingUploads(pending, maxConfluenceAttachmentSize); err != nil {
 		return err
 	}
+	session.Progress(ctx, fmt.Sprintf("Validated %d attachment(s)", len(pending)))
 
 	// Phase 3: Upload all files (only reached if validation passed)
-	for _, p := range pending {
-		attInfo, err := UploadAttachment(pageID, p.data, p.filename)
+	for i, p := range pending {
+		attInfo, err := UploadAttachment(ctx, c, pageID, p.data, p.filename)
 		if err != nil {
 			return fmt.Errorf("upload failed for %s: %w", p.source, err)
 		}
+		session.Progress(ctx, fmt.Sprintf("Uploaded %s (%d/%d)", p.filename, i+1, len(pending)))
 
 		// Update ADF node with real file ID
 		p.nodeAttrs["id"] = attInfo.FileID
 		p.nodeAttrs["collection"] = "contentId-" + pageID
 		p.nodeAttrs["type"] = "file"
+		if c.DataCenter {
+			p.nodeAttrs["filename"] = attInfo.Title
+		}
 		delete(p.nodeAttrs, "_source")
 	}
 
 	return nil
 }
 
+// PreviewPendingMedia fetches and validates the pending media in an ADF
+// document like UploadPendingMedia, but does not upload them.
+func PreviewPendingMedia(ctx context.Context, pageID string, adf map[string]any) ([]preview.Media, error) {
+	pending, err := collectPendingUploads(ctx, pageID, adf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect uploads: %w", err)
+	}
+	if err := validatePendingUploads(pending, maxConfluenceAttachmentSize); err != nil {
+		return nil, err
+	}
+
+	media := make([]preview.Media, 0, len(pending))
+	for _, p := range pending {
+		media = append(media, preview.Media{Source: p.source, Filename: p.filename, Size: len(p.data)})
+	}
+	return media, nil
+}
+
 // downloadFile fetches a file from a URL and returns its contents.
-func downloadFile(url string) ([]byte, string, error) {
-	resp, err := http.Get(url)
+func downloadFile(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create download request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to download file: %v", err)
 	}
@@ -261,7 +296,7 @@ func sanitizeFilename(name string) string {
 
 // collectPendingUploads walks the ADF tree and collects all pending media uploads.
 // It downloads URLs and reads local files into memory.
-func collectPendingUploads(pageID string, adf map[string]any) ([]pendingUpload, error) {
+func collectPendingUploads(ctx context.Context, pageID string, adf map[string]any) ([]pendingUpload, error) {
 	var uploads []pendingUpload
 
 	content, ok := adf["content"].([]any)
@@ -309,8 +344,10 @@ func collectPendingUploads(pageID string, adf map[string]any) ([]pendingUpload,
 			var filename string
 			var err error
 
+			session.ExpectSteps(ctx, 1)
+
 			if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-				fileData, filename, err = downloadFile(source)
+				fileData, filename, err = downloadFile(ctx, source)
 				if err != nil {
 					return nil, fmt.Errorf("failed to download %s: %w", source, err)
 				}
@@ -321,6 +358,7 @@ func collectPendingUploads(pageID string, adf map[string]any) ([]pendingUpload,
 				}
 				filename = filepath.Base(source)
 			}
+			session.Progress(ctx, "Fetched "+source)
 
 			// Use alt text as filename if available
 			if alt != "" && alt != "attachment" {
@@ -343,7 +381,7 @@ func collectPendingUploads(pageID string, adf map[string]any) ([]pendingUpload,
 		// Recursively process nested content
 		if innerContent, ok := nodeMap["content"].([]any); ok {
 			innerADF := map[string]any{"content": innerContent}
-			innerUploads, err := collectPendingUploads(pageID, innerADF)
+			innerUploads, err := collectPendingUploads(ctx, pageID, innerADF)
 			if err != nil {
 				return nil, err
 			}
@@ -1,11 +1,12 @@
 package confluence
 
 import (
+	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"strings"
 
+	"atlassian-mcp/internal/audit"
 	"atlassian-mcp/internal/client"
 )
 
@@ -21,13 +22,9 @@ func ComputePageChecksums(page map[string]any) map[string]string {
 		checksums["title"] = hashString(title)
 	}
 
-	// Body checksum (ADF JSON)
-	if body, ok := page["body"].(map[string]any); ok {
-		if adf, ok := body["atlas_doc_format"].(map[string]any); ok {
-			if value, ok := adf["value"].(string); ok {
-				checksums["body"] = hashString(value)
-			}
-		}
+	// Body checksum (ADF JSON, or storage format on Data Center)
+	if value, ok := rawBody(page["body"]); ok {
+		checksums["body"] = hashString(value)
 	}
 
 	// Version checksum
@@ -41,20 +38,20 @@ func ComputePageChecksums(page map[string]any) map[string]string {
 }
 
 // ValidatePageChecksums validates provided checksums against current page state.
-// Returns: current checksums, list of conflicting fields, error
-func ValidatePageChecksums(pageID string, provided map[string]string) (map[string]string, []string, error) {
+// The current values are recorded for the audit log.
+// Returns: current field values (see pageValues), list of conflicting fields, error
+func ValidatePageChecksums(ctx context.Context, c *client.Client, pageID string, provided map[string]string) (map[string]string, []string, error) {
 	// Fetch current page to get current checksums
-	body, err := client.Request(client.Confluence, fmt.Sprintf("/api/v2/pages/%s?body-format=atlas_doc_format", pageID))
+	page, err := fetchPage(client.NoCache(ctx), c, pageID, true)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var page map[string]any
-	if err := json.Unmarshal(body, &page); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse page response")
-	}
-
 	current := ComputePageChecksums(page)
+	values := pageValues(page)
+	for field, value := range values {
+		audit.CaptureBefore(ctx, field, value)
+	}
 	var conflicts []string
 
 	// Compare provided checksums with current
@@ -66,7 +63,25 @@ func ValidatePageChecksums(pageID string, provided map[string]string) (map[strin
 		}
 	}
 
-	return current, conflicts, nil
+	return values, conflicts, nil
+}
+
+// pageValues returns the page's title, body (see readableBody) and version in
+// readable form.
+func pageValues(page map[string]any) map[string]string {
+	values := make(map[string]string)
+	if title, ok := page["title"].(string); ok {
+		values["title"] = title
+	}
+	if body, ok := readableBody(page["body"]); ok {
+		values["body"] = body
+	}
+	if version, ok := page["version"].(map[string]any); ok {
+		if number, ok := version["number"].(float64); ok {
+			values["version"] = fmt.Sprintf("%d", int(number))
+		}
+	}
+	return values
 }
 
 // FormatChecksums formats checksums for output.
@@ -91,18 +106,14 @@ func hashString(s string) string {
 	return fmt.Sprintf("%x", h[:8]) // First 8 bytes = 16 hex chars
 }
 
-// GetCurrentVersion fetches the current version number for a page.
-func GetCurrentVersion(pageID string) (int, error) {
-	body, err := client.Request(client.Confluence, fmt.Sprintf("/api/v2/pages/%s", pageID))
+// GetCurrentVersion fetches the current version number for a page, bypassing
+// the cache since writes must name the next version.
+func GetCurrentVersion(ctx context.Context, c *client.Client, pageID string) (int, error) {
+	page, err := fetchPage(client.NoCache(ctx), c, pageID, false)
 	if err != nil {
 		return 0, err
 	}
 
-	var page map[string]any
-	if err := json.Unmarshal(body, &page); err != nil {
-		return 0, fmt.Errorf("failed to parse page response")
-	}
-
 	if version, ok := page["version"].(map[string]any); ok {
 		if number, ok := version["number"].(float64); ok {
 			return int(number), nil
@@ -102,7 +102,7 @@ func TestComputePageChecksums(t *testing.T) {
 			wantFields: []string{"title", "body", "version"},
 		},
 		{
-			name: "Body_Without_ADF",
+			name: "Storage_Body",
 			page: map[string]any{
 				"title": "Test Page",
 				"body": map[string]any{
@@ -111,6 +111,14 @@ func TestComputePageChecksums(t *testing.T) {
 					},
 				},
 			},
+			wantFields: []string{"title", "body"},
+		},
+		{
+			name: "Body_Without_Value",
+			page: map[string]any{
+				"title": "Test Page",
+				"body":  map[string]any{"view": map[string]any{}},
+			},
 			wantFields: []string{"title"},
 		},
 	}
@@ -0,0 +1,237 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"atlassian-mcp/internal/adf"
+	"atlassian-mcp/internal/client"
+)
+
+// Confluence Data Center and Server have no v2 API: pages are content
+// objects of /rest/api/content with storage format (XHTML) bodies, users are
+// identified by username, and spaces by key. Pages are normalized to the
+// shape of v2 pages so formatting and checksums serve both deployments.
+
+// pageEndpoint returns the endpoint of a page, with its body when withBody
+// is set.
+func pageEndpoint(c *client.Client, pageID string, withBody bool) string {
+	if c.DataCenter {
+		expand := "version,space,history,ancestors"
+		if withBody {
+			expand = "body.storage," + expand
+		}
+		return fmt.Sprintf("/rest/api/content/%s?expand=%s", pageID, expand)
+	}
+	if withBody {
+		return fmt.Sprintf("/api/v2/pages/%s?body-format=atlas_doc_format", pageID)
+	}
+	return fmt.Sprintf("/api/v2/pages/%s", pageID)
+}
+
+// fetchPage fetches a page in the shape of a v2 page.
+func fetchPage(ctx context.Context, c *client.Client, pageID string, withBody bool) (map[string]any, error) {
+	body, err := c.Request(ctx, client.Confluence, pageEndpoint(c, pageID, withBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var page map[string]any
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse page response")
+	}
+	if c.DataCenter {
+		page = normalizePage(page)
+	}
+	return page, nil
+}
+
+// normalizePage maps a Data Center content object to the fields of a v2
+// page. Space IDs are space keys and author IDs usernames.
+func normalizePage(content map[string]any) map[string]any {
+	page := map[string]any{"body": content["body"]}
+	for _, name := range []string{"id", "title", "status"} {
+		if v, ok := content[name].(string); ok {
+			page[name] = v
+		}
+	}
+	if space, ok := content["space"].(map[string]any); ok {
+		page["spaceId"], _ = space["key"].(string)
+	}
+	if v, ok := content["version"].(map[string]any); ok {
+		version := map[string]any{"number": v["number"]}
+		version["createdAt"], _ = v["when"].(string)
+		if by, ok := v["by"].(map[string]any); ok {
+			version["authorId"], _ = by["username"].(string)
+		}
+		page["version"] = version
+	}
+	if history, ok := content["history"].(map[string]any); ok {
+		page["createdAt"], _ = history["createdDate"].(string)
+		if by, ok := history["createdBy"].(map[string]any); ok {
+			page["authorId"], _ = by["username"].(string)
+		}
+	}
+	if ancestors, ok := content["ancestors"].([]any); ok && len(ancestors) > 0 {
+		if parent, ok := ancestors[len(ancestors)-1].(map[string]any); ok {
+			page["parentId"], _ = parent["id"].(string)
+		}
+	}
+	return page
+}
+
+// rawBody returns the body of a page or comment as stored: the ADF JSON on
+// Cloud, the storage format XHTML on Data Center.
+func rawBody(body any) (string, bool) {
+	b, ok := body.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	for _, format := range []string{"atlas_doc_format", "storage"} {
+		if rep, ok := b[format].(map[string]any); ok {
+			if value, ok := rep["value"].(string); ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// readableBody returns the body of a page or comment for output: ADF
+// converted to extended markdown, storage format unchanged.
+func readableBody(body any) (string, bool) {
+	b, ok := body.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	value, ok := rawBody(b)
+	if !ok {
+		return "", false
+	}
+	if _, ok := b["storage"]; ok {
+		return value, true
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		return "", false
+	}
+	return adf.ToMarkdown(doc), true
+}
+
+// bodyRepresentation returns the body of a write in the format of the
+// deployment, keyed by representation as the v1 API expects.
+func bodyRepresentation(c *client.Client, doc map[string]any) (map[string]any, error) {
+	if c.DataCenter {
+		return map[string]any{
+			"storage": map[string]any{
+				"value":          adf.ToStorage(doc),
+				"representation": "storage",
+			},
+		}, nil
+	}
+	adfJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert markdown to ADF")
+	}
+	return map[string]any{
+		"atlas_doc_format": map[string]any{
+			"value":          string(adfJSON),
+			"representation": "atlas_doc_format",
+		},
+	}, nil
+}
+
+// pageWrite returns the endpoint and payload of a page update or, when
+// pageID is empty, creation. doc is nil to leave the body unchanged.
+func pageWrite(c *client.Client, pageID, spaceID, parentID, title string, version int, doc map[string]any) (string, map[string]any, error) {
+	if c.DataCenter {
+		payload := map[string]any{"type": "page", "title": title}
+		if pageID != "" {
+			payload["id"] = pageID
+			payload["version"] = map[string]any{"number": version}
+		} else {
+			payload["space"] = map[string]any{"key": spaceID}
+		}
+		if parentID != "" {
+			payload["ancestors"] = []any{map[string]any{"id": parentID}}
+		}
+		if doc != nil {
+			body, err := bodyRepresentation(c, doc)
+			if err != nil {
+				return "", nil, err
+			}
+			payload["body"] = body
+		}
+		if pageID == "" {
+			return "/rest/api/content", payload, nil
+		}
+		return "/rest/api/content/" + pageID, payload, nil
+	}
+
+	payload := map[string]any{"status": "current", "title": title}
+	if pageID != "" {
+		payload["id"] = pageID
+		payload["version"] = map[string]any{"number": version}
+	} else {
+		payload["spaceId"] = spaceID
+	}
+	if parentID != "" {
+		payload["parentId"] = parentID
+	}
+	if doc != nil {
+		adfJSON, err := json.Marshal(doc)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to convert markdown to ADF")
+		}
+		payload["body"] = map[string]any{
+			"representation": "atlas_doc_format",
+			"value":          string(adfJSON),
+		}
+	}
+	if pageID == "" {
+		return "/api/v2/pages", payload, nil
+	}
+	return "/api/v2/pages/" + pageID, payload, nil
+}
+
+// searchEndpoint returns the CQL search endpoint. Data Center searches
+// content directly; its results are wrapped like Cloud search hits by
+// searchHits.
+func searchEndpoint(c *client.Client, cql string) string {
+	if c.DataCenter {
+		return "/rest/api/content/search?expand=space&cql=" + url.QueryEscape(cql)
+	}
+	return "/rest/api/search?cql=" + url.QueryEscape(cql)
+}
+
+// searchHits wraps Data Center content results in search hits.
+func searchHits(c *client.Client, results []any) []any {
+	if !c.DataCenter {
+		return results
+	}
+	hits := make([]any, len(results))
+	for i, r := range results {
+		hits[i] = map[string]any{"content": r}
+	}
+	return hits
+}
+
+// userID returns the accountId of a Cloud user or the username of a Data
+// Center user.
+func userID(user map[string]any) string {
+	if id, ok := user["accountId"].(string); ok {
+		return id
+	}
+	name, _ := user["username"].(string)
+	return name
+}
+
+// userQuery returns the query that looks up a user by ID.
+func userQuery(c *client.Client, id string) string {
+	if c.DataCenter {
+		return "username=" + url.QueryEscape(id)
+	}
+	return "accountId=" + url.QueryEscape(id)
+}
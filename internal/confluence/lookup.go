@@ -0,0 +1,40 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+
+	"atlassian-mcp/internal/client"
+)
+
+// Space identifies a Confluence space.
+type Space struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// ListSpaces returns the spaces visible to the authenticated user, up to
+// client.MaxPageLimit. On Data Center, where pages are created by space key,
+// the ID of a space is its key.
+func ListSpaces(ctx context.Context, c *client.Client) ([]Space, error) {
+	endpoint := "/api/v2/spaces"
+	if c.DataCenter {
+		endpoint = "/rest/api/space"
+	}
+	results, err := c.Links(client.Confluence, endpoint, "results").Collect(ctx, client.PageRequest{Limit: client.MaxPageLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spaces: %w", err)
+	}
+
+	var spaces []Space
+	if err := results.Decode(&spaces); err != nil {
+		return nil, fmt.Errorf("failed to parse space list")
+	}
+	if c.DataCenter {
+		for i := range spaces {
+			spaces[i].ID = spaces[i].Key
+		}
+	}
+	return spaces, nil
+}
@@ -1,15 +1,19 @@
 package confluence
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"atlassian-mcp/internal/adf"
 	"atlassian-mcp/internal/client"
 	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/confirm"
+	"atlassian-mcp/internal/preview"
+	"atlassian-mcp/internal/session"
 	"atlassian-mcp/internal/types"
 )
 
@@ -17,6 +21,7 @@ import (
 const userCacheMaxSize = 100
 
 type lruCache struct {
+	mu       sync.Mutex
 	capacity int
 	items    map[string]*lruItem
 	head     *lruItem // most recent
@@ -36,6 +41,8 @@ var userCache = &lruCache{
 }
 
 func (c *lruCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if item, ok := c.items[key]; ok {
 		c.moveToFront(item)
 		return item.value, true
@@ -44,6 +51,8 @@ func (c *lruCache) get(key string) (string, bool) {
 }
 
 func (c *lruCache) set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if item, ok := c.items[key]; ok {
 		item.value = value
 		c.moveToFront(item)
@@ -100,25 +109,28 @@ func (c *lruCache) removeTail() {
 	c.remove(c.tail)
 }
 
-// fetchUserDisplayName fetches user display name with caching.
-func fetchUserDisplayName(accountID string) string {
+// fetchUserDisplayName fetches user display name with caching. Names are
+// cached per site, since account IDs on Data Center are only unique within
+// one instance.
+func fetchUserDisplayName(ctx context.Context, c *client.Client, accountID string) string {
 	if accountID == "" {
 		return "Unknown"
 	}
 
-	if name, ok := userCache.get(accountID); ok {
+	key := c.Site + "\x00" + accountID
+	if name, ok := userCache.get(key); ok {
 		return name
 	}
 
-	body, err := client.Request(client.Confluence, fmt.Sprintf("/rest/api/user?accountId=%s", accountID))
+	body, err := c.Request(ctx, client.Confluence, "/rest/api/user?"+userQuery(c, accountID))
 	if err != nil {
-		userCache.set(accountID, accountID)
+		userCache.set(key, accountID)
 		return accountID
 	}
 
 	var user map[string]any
 	if err := json.Unmarshal(body, &user); err != nil {
-		userCache.set(accountID, accountID)
+		userCache.set(key, accountID)
 		return accountID
 	}
 
@@ -130,33 +142,51 @@ func fetchUserDisplayName(accountID string) string {
 		}
 	}
 
-	userCache.set(accountID, displayName)
+	userCache.set(key, displayName)
 	return displayName
 }
 
 // GetPage fetches a page with metadata, body as extended markdown, and checksums.
-func GetPage(pageIDOrURL string) (string, error) {
+func GetPage(ctx context.Context, c *client.Client, pageIDOrURL string) (types.Result, error) {
 	pageID, err := config.ExtractPageID(pageIDOrURL)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
-	// Fetch page with ADF body format
-	body, err := client.Request(client.Confluence, fmt.Sprintf("/api/v2/pages/%s?body-format=atlas_doc_format", pageID))
+	// Fetch page with ADF body format (storage format on Data Center)
+	page, err := fetchPage(ctx, c, pageID, true)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
-	var page map[string]any
-	if err := json.Unmarshal(body, &page); err != nil {
-		return "", fmt.Errorf("failed to parse page response")
-	}
+	return types.Result{
+		Text: formatPageOutput(ctx, c, page),
+		Data: map[string]any{
+			"site":      c.Site,
+			"page":      pageData(page),
+			"checksums": ComputePageChecksums(page),
+		},
+	}, nil
+}
 
-	return formatPageOutput(page), nil
+// pageData extracts the structured metadata of a page.
+func pageData(page map[string]any) map[string]any {
+	data := map[string]any{}
+	for _, name := range []string{"id", "title", "status", "spaceId", "parentId", "authorId", "createdAt"} {
+		if v, ok := page[name].(string); ok && v != "" {
+			data[name] = v
+		}
+	}
+	if version, ok := page["version"].(map[string]any); ok {
+		if number, ok := version["number"].(float64); ok {
+			data["version"] = int(number)
+		}
+	}
+	return data
 }
 
 // formatPageOutput formats page data for output.
-func formatPageOutput(page map[string]any) string {
+func formatPageOutput(ctx context.Context, c *client.Client, page map[string]any) string {
 	var sb strings.Builder
 
 	id, _ := page["id"].(string)
@@ -164,6 +194,9 @@ func formatPageOutput(page map[string]any) string {
 	status, _ := page["status"].(string)
 
 	sb.WriteString(fmt.Sprintf("# %s\n\n", title))
+	if c.Site != "" {
+		sb.WriteString(fmt.Sprintf("**Site:** %s\n", c.Site))
+	}
 	sb.WriteString(fmt.Sprintf("**Page ID:** %s\n", id))
 	sb.WriteString(fmt.Sprintf("**Status:** %s\n", status))
 
@@ -181,7 +214,7 @@ func formatPageOutput(page map[string]any) string {
 			sb.WriteString(fmt.Sprintf("**Last Updated:** %s\n", createdAt))
 		}
 		if authorID, ok := version["authorId"].(string); ok {
-			sb.WriteString(fmt.Sprintf("**Last Author:** %s {user:%s}\n", fetchUserDisplayName(authorID), authorID))
+			sb.WriteString(fmt.Sprintf("**Last Author:** %s {user:%s}\n", fetchUserDisplayName(ctx, c, authorID), authorID))
 		}
 	}
 
@@ -192,7 +225,7 @@ func formatPageOutput(page map[string]any) string {
 
 	// Author
 	if authorID, ok := page["authorId"].(string); ok {
-		sb.WriteString(fmt.Sprintf("**Author:** %s {user:%s}\n", fetchUserDisplayName(authorID), authorID))
+		sb.WriteString(fmt.Sprintf("**Author:** %s {user:%s}\n", fetchUserDisplayName(ctx, c, authorID), authorID))
 	}
 
 	// Parent page
@@ -202,18 +235,11 @@ func formatPageOutput(page map[string]any) string {
 
 	sb.WriteString("\n")
 
-	// Body content - convert ADF to extended markdown
-	if body, ok := page["body"].(map[string]any); ok {
-		if adfData, ok := body["atlas_doc_format"].(map[string]any); ok {
-			if value, ok := adfData["value"].(string); ok {
-				var adfDoc map[string]any
-				if err := json.Unmarshal([]byte(value), &adfDoc); err == nil {
-					sb.WriteString("__DESCRIPTION__\n")
-					sb.WriteString(adf.ToMarkdown(adfDoc))
-					sb.WriteString("\n__END_DESCRIPTION__\n")
-				}
-			}
-		}
+	// Body content - ADF converted to extended markdown, storage format as is
+	if body, ok := readableBody(page["body"]); ok {
+		sb.WriteString("__DESCRIPTION__\n")
+		sb.WriteString(body)
+		sb.WriteString("\n__END_DESCRIPTION__\n")
 	}
 
 	// Checksums
@@ -225,24 +251,73 @@ func formatPageOutput(page map[string]any) string {
 }
 
 // GetComments fetches comments for a page.
-func GetComments(pageIDOrURL string) (string, error) {
+func GetComments(ctx context.Context, c *client.Client, pageIDOrURL string, page client.PageRequest) (types.Result, error) {
 	pageID, err := config.ExtractPageID(pageIDOrURL)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
-	// Fetch footer comments using v1 API with ADF format
-	body, err := client.Request(client.Confluence, fmt.Sprintf("/rest/api/content/%s/child/comment?expand=body.atlas_doc_format,version", pageID))
+	// Fetch footer comments using v1 API with ADF format (storage format on
+	// Data Center)
+	format := "atlas_doc_format"
+	if c.DataCenter {
+		format = "storage"
+	}
+	endpoint := fmt.Sprintf("/rest/api/content/%s/child/comment?expand=body.%s,version", pageID, format)
+	results, err := c.Links(client.Confluence, endpoint, "results").Collect(ctx, page)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
-	var response map[string]any
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to parse response")
+	response, err := resultsResponse(results)
+	if err != nil {
+		return types.Result{}, err
 	}
 
-	return formatCommentsOutput(pageID, response), nil
+	return types.Result{
+		Text: formatCommentsOutput(pageID, response) + results.Summary(),
+		Data: results.Annotate(map[string]any{"pageId": pageID, "comments": commentsData(response)}),
+	}, nil
+}
+
+// resultsResponse rebuilds the shape of a v1 list response from collected
+// results, for the formatters.
+func resultsResponse(results client.Page) (map[string]any, error) {
+	var list []any
+	if err := results.Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to parse response")
+	}
+	return map[string]any{"results": list}, nil
+}
+
+// commentsData extracts structured comment metadata with markdown bodies.
+func commentsData(response map[string]any) []map[string]any {
+	results, _ := response["results"].([]any)
+	data := make([]map[string]any, 0, len(results))
+	for _, c := range results {
+		comment, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		entry := map[string]any{}
+		entry["id"], _ = comment["id"].(string)
+		if version, ok := comment["version"].(map[string]any); ok {
+			entry["created"], _ = version["when"].(string)
+			if by, ok := version["by"].(map[string]any); ok {
+				author := map[string]any{}
+				author["displayName"], _ = by["displayName"].(string)
+				if id := userID(by); id != "" {
+					author["accountId"] = id
+				}
+				entry["author"] = author
+			}
+		}
+		if body, ok := readableBody(comment["body"]); ok {
+			entry["body"] = body
+		}
+		data = append(data, entry)
+	}
+	return data
 }
 
 // formatCommentsOutput formats comments for output.
@@ -270,9 +345,7 @@ func formatCommentsOutput(pageID string, response map[string]any) string {
 				if displayName, ok := by["displayName"].(string); ok {
 					author = displayName
 				}
-				if accountID, ok := by["accountId"].(string); ok {
-					authorID = accountID
-				}
+				authorID = userID(by)
 			}
 			authorInfo := author
 			if authorID != "" {
@@ -287,17 +360,10 @@ func formatCommentsOutput(pageID string, response map[string]any) string {
 			sb.WriteString(fmt.Sprintf("### Author: %s\n\n", author))
 		}
 
-		if body, ok := comment["body"].(map[string]any); ok {
-			if adfData, ok := body["atlas_doc_format"].(map[string]any); ok {
-				if value, ok := adfData["value"].(string); ok {
-					var adfDoc map[string]any
-					if err := json.Unmarshal([]byte(value), &adfDoc); err == nil {
-						sb.WriteString("__COMMENT__\n")
-						sb.WriteString(adf.ToMarkdown(adfDoc))
-						sb.WriteString("\n__END_COMMENT__\n")
-					}
-				}
-			}
+		if body, ok := readableBody(comment["body"]); ok {
+			sb.WriteString("__COMMENT__\n")
+			sb.WriteString(body)
+			sb.WriteString("\n__END_COMMENT__\n")
 		}
 		sb.WriteString("---\n\n")
 	}
@@ -306,20 +372,50 @@ func formatCommentsOutput(pageID string, response map[string]any) string {
 }
 
 // SearchPages searches for pages using CQL.
-func SearchPages(cql string) (string, error) {
-	// URL encode the CQL query
-	encoded := url.QueryEscape(cql)
-	body, err := client.Request(client.Confluence, fmt.Sprintf("/rest/api/search?cql=%s&limit=50", encoded))
+func SearchPages(ctx context.Context, c *client.Client, cql string, page client.PageRequest) (types.Result, error) {
+	results, err := c.Links(client.Confluence, searchEndpoint(c, cql), "results").Collect(ctx, page)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
-	var response map[string]any
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to parse response")
+	response, err := resultsResponse(results)
+	if err != nil {
+		return types.Result{}, err
+	}
+	response["results"] = searchHits(c, response["results"].([]any))
+
+	return types.Result{
+		Text: formatSearchResults(response) + results.Summary(),
+		Data: results.Annotate(searchData(response)),
+	}, nil
+}
+
+// searchData extracts structured search hits.
+func searchData(response map[string]any) map[string]any {
+	results, _ := response["results"].([]any)
+	hits := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		result, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		content, _ := result["content"].(map[string]any)
+		if content == nil {
+			continue
+		}
+		hit := map[string]any{}
+		hit["id"], _ = content["id"].(string)
+		hit["title"], _ = content["title"].(string)
+		hit["type"], _ = content["type"].(string)
+		if space, ok := content["space"].(map[string]any); ok {
+			if key, ok := space["key"].(string); ok {
+				hit["space"] = key
+			}
+		}
+		hits = append(hits, hit)
 	}
 
-	return formatSearchResults(response), nil
+	return map[string]any{"results": hits}
 }
 
 // formatSearchResults formats search results for output.
@@ -362,26 +458,21 @@ func formatSearchResults(response map[string]any) string {
 		sb.WriteString(")\n")
 	}
 
-	// Show total size if available
-	if totalSize, ok := response["totalSize"].(float64); ok {
-		sb.WriteString(fmt.Sprintf("\n**Total results:** %d (showing first 50)\n", int(totalSize)))
-	}
-
 	return sb.String()
 }
 
-// AddComment adds a comment to a page.
-func AddComment(params types.ConfluenceAddCommentParams) (string, error) {
+// AddComment adds a comment to a page. A dry run returns the request without
+// sending it.
+func AddComment(ctx context.Context, c *client.Client, params types.ConfluenceAddCommentParams) (types.Result, error) {
 	pageID, err := config.ExtractPageID(params.PageID)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
-	// Convert markdown to ADF
-	adfDoc := adf.FromMarkdown(params.Body)
-	adfJSON, err := json.Marshal(adfDoc)
+	// Convert markdown to ADF (storage format on Data Center)
+	body, err := bodyRepresentation(c, adf.FromMarkdown(params.Body))
 	if err != nil {
-		return "", fmt.Errorf("failed to convert markdown to ADF")
+		return types.Result{}, err
 	}
 
 	// Create comment using v1 API (v2 doesn't support comments well yet)
@@ -391,136 +482,191 @@ func AddComment(params types.ConfluenceAddCommentParams) (string, error) {
 			"id":   pageID,
 			"type": "page",
 		},
-		"body": map[string]any{
-			"atlas_doc_format": map[string]any{
-				"value":          string(adfJSON),
-				"representation": "atlas_doc_format",
-			},
-		},
+		"body": body,
+	}
+
+	changes := []preview.Change{{Field: "comment", After: params.Body}}
+	if params.DryRun {
+		return preview.Write{
+			Title:    "Add comment to page " + pageID,
+			Method:   "POST",
+			Endpoint: "/rest/api/content",
+			Payload:  payload,
+			Changes:  changes,
+		}.Result(map[string]any{"pageId": pageID}), nil
+	}
+	if err := confirm.Changes(ctx, changes); err != nil {
+		return types.Result{}, err
 	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload")
+		return types.Result{}, fmt.Errorf("failed to marshal payload")
 	}
 
-	_, err = client.Post(client.Confluence, "/rest/api/content", payloadBytes)
+	resp, err := c.Post(ctx, client.Confluence, "/rest/api/content", payloadBytes)
 	if err != nil {
-		return "", fmt.Errorf("failed to add comment: %w", err)
+		return types.Result{}, fmt.Errorf("failed to add comment: %w", err)
+	}
+
+	var created map[string]any
+	commentID := ""
+	if json.Unmarshal(resp, &created) == nil {
+		commentID, _ = created["id"].(string)
 	}
 
-	return fmt.Sprintf("Comment added to page %s successfully.", pageID), nil
+	return types.Result{
+		Text: fmt.Sprintf("Comment added to page %s successfully.", pageID),
+		Data: map[string]any{"pageId": pageID, "commentId": commentID},
+	}, nil
 }
 
-// UpdatePage updates a page with checksum validation.
-func UpdatePage(params types.ConfluenceUpdatePageParams) (string, error) {
+// UpdatePage updates a page with checksum validation. A dry run verifies the
+// checksums and fetches pending media, then returns the request and a diff of
+// the title and body without sending it.
+func UpdatePage(ctx context.Context, c *client.Client, params types.ConfluenceUpdatePageParams) (types.Result, error) {
 	pageID, err := config.ExtractPageID(params.PageID)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
 	// Validate checksums
 	if len(params.Checksums) == 0 {
-		return "", fmt.Errorf("checksums required for update_page. Use get_page first to obtain checksums")
+		return types.Result{}, fmt.Errorf("checksums required for update_page. Use get_page first to obtain checksums")
 	}
 
-	_, conflicts, err := ValidatePageChecksums(pageID, params.Checksums)
+	// Verify, update, wait for the new version, and re-fetch; media uploads
+	// add their own steps
+	session.ExpectSteps(ctx, 4)
+
+	current, conflicts, err := ValidatePageChecksums(ctx, c, pageID, params.Checksums)
 	if err != nil {
-		return "", fmt.Errorf("failed to validate checksums: %w", err)
+		return types.Result{}, fmt.Errorf("failed to validate checksums: %w", err)
 	}
 
 	if len(conflicts) > 0 {
-		return "", fmt.Errorf("conflict: fields modified since read: %s", strings.Join(conflicts, ", "))
+		return types.Result{}, fmt.Errorf("conflict: fields modified since read: %s", strings.Join(conflicts, ", "))
 	}
+	session.Progress(ctx, "Verified checksums")
 
 	// Get current version
-	currentVersion, err := GetCurrentVersion(pageID)
+	currentVersion, err := GetCurrentVersion(ctx, c, pageID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get current version: %w", err)
-	}
-
-	// Build update payload
-	payload := map[string]any{
-		"id":      pageID,
-		"status":  "current",
-		"version": map[string]any{"number": currentVersion + 1},
+		return types.Result{}, fmt.Errorf("failed to get current version: %w", err)
 	}
 
 	// Add title if provided
-	if params.Title != "" {
-		payload["title"] = params.Title
+	var changes []preview.Change
+	title := params.Title
+	if title != "" {
+		changes = append(changes, preview.Change{Field: "title", Before: current["title"], After: params.Title})
 	} else {
 		// Fetch current title
-		body, err := client.Request(client.Confluence, fmt.Sprintf("/api/v2/pages/%s", pageID))
+		page, err := fetchPage(client.NoCache(ctx), c, pageID, false)
 		if err != nil {
-			return "", fmt.Errorf("failed to fetch current page: %w", err)
-		}
-		var page map[string]any
-		if err := json.Unmarshal(body, &page); err != nil {
-			return "", fmt.Errorf("failed to parse page: %w", err)
+			return types.Result{}, fmt.Errorf("failed to fetch current page: %w", err)
 		}
-		if title, ok := page["title"].(string); ok {
-			payload["title"] = title
+		title, _ = page["title"].(string)
+	}
+
+	if params.Body != "" {
+		changes = append(changes, preview.Change{Field: "body", Before: current["body"], After: params.Body})
+	}
+
+	// Confirm before uploading media, which is already a write
+	if !params.DryRun {
+		if err := confirm.Changes(ctx, changes); err != nil {
+			return types.Result{}, err
 		}
 	}
 
 	// Add body if provided
+	var media []preview.Media
+	var adfDoc map[string]any
 	if params.Body != "" {
-		adfDoc := adf.FromMarkdown(params.Body)
+		adfDoc = adf.FromMarkdown(params.Body)
 
 		// Upload any pending media (images from URLs or local paths)
-		if err := UploadPendingMedia(pageID, adfDoc); err != nil {
-			return "", fmt.Errorf("failed to upload media: %w", err)
+		if params.DryRun {
+			media, err = PreviewPendingMedia(ctx, pageID, adfDoc)
+		} else {
+			err = UploadPendingMedia(ctx, c, pageID, adfDoc)
 		}
-
-		adfJSON, err := json.Marshal(adfDoc)
 		if err != nil {
-			return "", fmt.Errorf("failed to convert markdown to ADF")
+			return types.Result{}, fmt.Errorf("failed to upload media: %w", err)
 		}
+	}
 
-		payload["body"] = map[string]any{
-			"representation": "atlas_doc_format",
-			"value":          string(adfJSON),
-		}
+	// Build update payload
+	endpoint, payload, err := pageWrite(c, pageID, "", "", title, currentVersion+1, adfDoc)
+	if err != nil {
+		return types.Result{}, err
+	}
+
+	if params.DryRun {
+		return preview.Write{
+			Title:    "Update page " + pageID,
+			Method:   "PUT",
+			Endpoint: endpoint,
+			Payload:  payload,
+			Changes:  changes,
+			Media:    media,
+		}.Result(map[string]any{"pageId": pageID}), nil
 	}
 
 	// Update page
 	expectedVersion := currentVersion + 1
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload")
+		return types.Result{}, fmt.Errorf("failed to marshal payload")
 	}
 
-	_, err = client.Put(client.Confluence, fmt.Sprintf("/api/v2/pages/%s", pageID), payloadBytes)
+	_, err = c.Put(ctx, client.Confluence, endpoint, payloadBytes)
 	if err != nil {
-		return "", fmt.Errorf("failed to update page: %w", err)
+		return types.Result{}, fmt.Errorf("failed to update page: %w", err)
 	}
+	session.Progress(ctx, fmt.Sprintf("Updated page %s", pageID))
 
 	// Wait for version to propagate before fetching
 	delays := []time.Duration{200 * time.Millisecond, 500 * time.Millisecond, 1 * time.Second}
+poll:
 	for _, delay := range delays {
-		if v, _ := GetCurrentVersion(pageID); v == expectedVersion {
+		if v, _ := GetCurrentVersion(ctx, c, pageID); v == expectedVersion {
 			break
 		}
-		time.Sleep(delay)
+		select {
+		case <-ctx.Done():
+			break poll
+		case <-time.After(delay):
+		}
 	}
 
+	session.Progress(ctx, fmt.Sprintf("Version %d is live", expectedVersion))
+
 	// Fetch updated page to get new checksums
-	result, err := GetPage(pageID)
+	result, err := GetPage(ctx, c, pageID)
 	if err != nil {
-		return fmt.Sprintf("Page %s updated successfully, but failed to fetch updated checksums.", pageID), nil
+		return types.Result{
+			Text: fmt.Sprintf("Page %s updated successfully, but failed to fetch updated checksums.", pageID),
+			Data: map[string]any{"pageId": pageID},
+		}, nil
 	}
+	session.Progress(ctx, "Fetched fresh checksums")
 
-	return fmt.Sprintf("Page %s updated successfully.\n\n%s", pageID, result), nil
+	return types.Result{
+		Text: fmt.Sprintf("Page %s updated successfully.\n\n%s", pageID, result.Text),
+		Data: map[string]any{"pageId": pageID, "checksums": result.Data["checksums"]},
+	}, nil
 }
 
-// CreatePage creates a new page in a space.
-func CreatePage(params types.ConfluenceCreatePageParams) (string, error) {
+// CreatePage creates a new page in a space. A dry run returns the request
+// without sending it.
+func CreatePage(ctx context.Context, c *client.Client, params types.ConfluenceCreatePageParams) (types.Result, error) {
 	if params.SpaceID == "" {
-		return "", fmt.Errorf("spaceId is required")
+		return types.Result{}, fmt.Errorf("spaceId is required")
 	}
 	if params.Title == "" {
-		return "", fmt.Errorf("title is required")
+		return types.Result{}, fmt.Errorf("title is required")
 	}
 
 	// Convert markdown body to ADF (or empty doc if no body)
@@ -538,43 +684,62 @@ func CreatePage(params types.ConfluenceCreatePageParams) (string, error) {
 	// Check if there are pending media uploads
 	hasPendingMedia := checkPendingMedia(adfDoc)
 
-	adfJSON, err := json.Marshal(adfDoc)
-	if err != nil {
-		return "", fmt.Errorf("failed to convert markdown to ADF")
+	// Create, then upload media and re-update the page if needed
+	session.ExpectSteps(ctx, 1)
+	if hasPendingMedia {
+		session.ExpectSteps(ctx, 1)
 	}
 
-	payload := map[string]any{
-		"spaceId": params.SpaceID,
-		"status":  "current",
-		"title":   params.Title,
-		"body": map[string]any{
-			"representation": "atlas_doc_format",
-			"value":          string(adfJSON),
-		},
+	// Data Center takes the space key as spaceId
+	endpoint, payload, err := pageWrite(c, "", params.SpaceID, params.ParentID, params.Title, 0, adfDoc)
+	if err != nil {
+		return types.Result{}, err
 	}
 
-	// Add parent if specified
-	if params.ParentID != "" {
-		payload["parentId"] = params.ParentID
+	changes := []preview.Change{
+		{Field: "title", After: params.Title},
+		{Field: "body", After: params.Body},
+	}
+	if params.DryRun {
+		var media []preview.Media
+		if hasPendingMedia {
+			// Media is uploaded to the page once it exists
+			media, err = PreviewPendingMedia(ctx, "", adf.FromMarkdown(params.Body))
+			if err != nil {
+				return types.Result{}, fmt.Errorf("failed to upload media: %w", err)
+			}
+		}
+		return preview.Write{
+			Title:    fmt.Sprintf("Create page %q in space %s", params.Title, params.SpaceID),
+			Method:   "POST",
+			Endpoint: endpoint,
+			Payload:  payload,
+			Changes:  changes,
+			Media:    media,
+		}.Result(nil), nil
+	}
+	if err := confirm.Changes(ctx, changes); err != nil {
+		return types.Result{}, err
 	}
 
 	// Create page
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload")
+		return types.Result{}, fmt.Errorf("failed to marshal payload")
 	}
 
-	body, err := client.Post(client.Confluence, "/api/v2/pages", payloadBytes)
+	body, err := c.Post(ctx, client.Confluence, endpoint, payloadBytes)
 	if err != nil {
-		return "", fmt.Errorf("failed to create page: %w", err)
+		return types.Result{}, fmt.Errorf("failed to create page: %w", err)
 	}
 
 	var response map[string]any
 	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to parse response")
+		return types.Result{}, fmt.Errorf("failed to parse response")
 	}
 
 	pageID, _ := response["id"].(string)
+	session.Progress(ctx, fmt.Sprintf("Created page %s", pageID))
 
 	// If there were pending media, upload them and update the page
 	if hasPendingMedia && pageID != "" {
@@ -582,38 +747,39 @@ func CreatePage(params types.ConfluenceCreatePageParams) (string, error) {
 		adfDoc = adf.FromMarkdown(params.Body)
 
 		// Upload pending media to the newly created page
-		if err := UploadPendingMedia(pageID, adfDoc); err != nil {
-			return fmt.Sprintf("Page created but media upload failed: %v\n**Page ID:** %s\n**Title:** %s", err, pageID, params.Title), nil
+		if err := UploadPendingMedia(ctx, c, pageID, adfDoc); err != nil {
+			return createdResult(fmt.Sprintf("Page created but media upload failed: %v\n**Page ID:** %s\n**Title:** %s", err, pageID, params.Title), pageID, params.Title), nil
 		}
 
-		// Update page with the media IDs
-		adfJSON, _ = json.Marshal(adfDoc)
-
 		// Get current version for update
-		currentVersion, err := GetCurrentVersion(pageID)
+		currentVersion, err := GetCurrentVersion(ctx, c, pageID)
 		if err != nil {
-			return fmt.Sprintf("Page created but failed to get version for media update: %v\n**Page ID:** %s\n**Title:** %s", err, pageID, params.Title), nil
+			return createdResult(fmt.Sprintf("Page created but failed to get version for media update: %v\n**Page ID:** %s\n**Title:** %s", err, pageID, params.Title), pageID, params.Title), nil
 		}
 
-		updatePayload := map[string]any{
-			"id":      pageID,
-			"status":  "current",
-			"title":   params.Title,
-			"version": map[string]any{"number": currentVersion + 1},
-			"body": map[string]any{
-				"representation": "atlas_doc_format",
-				"value":          string(adfJSON),
-			},
+		// Update page with the media IDs
+		updateEndpoint, updatePayload, err := pageWrite(c, pageID, "", "", params.Title, currentVersion+1, adfDoc)
+		if err != nil {
+			return createdResult(fmt.Sprintf("Page created but media update failed: %v\n**Page ID:** %s\n**Title:** %s", err, pageID, params.Title), pageID, params.Title), nil
 		}
 
 		updateBytes, _ := json.Marshal(updatePayload)
-		_, err = client.Put(client.Confluence, fmt.Sprintf("/api/v2/pages/%s", pageID), updateBytes)
+		_, err = c.Put(ctx, client.Confluence, updateEndpoint, updateBytes)
 		if err != nil {
-			return fmt.Sprintf("Page created but media update failed: %v\n**Page ID:** %s\n**Title:** %s", err, pageID, params.Title), nil
+			return createdResult(fmt.Sprintf("Page created but media update failed: %v\n**Page ID:** %s\n**Title:** %s", err, pageID, params.Title), pageID, params.Title), nil
 		}
+		session.Progress(ctx, "Updated page with uploaded media")
 	}
 
-	return fmt.Sprintf("Page created successfully.\n**Page ID:** %s\n**Title:** %s", pageID, params.Title), nil
+	return createdResult(fmt.Sprintf("Page created successfully.\n**Page ID:** %s\n**Title:** %s", pageID, params.Title), pageID, params.Title), nil
+}
+
+// createdResult builds the result of a page creation.
+func createdResult(text, pageID, title string) types.Result {
+	return types.Result{
+		Text: text,
+		Data: map[string]any{"pageId": pageID, "title": title},
+	}
 }
 
 // checkPendingMedia checks if an ADF document has any pending media uploads.
@@ -0,0 +1,35 @@
+package confluence
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"atlassian-mcp/internal/client"
+)
+
+func TestFetchUserDisplayName_PerSite(t *testing.T) {
+	t.Parallel()
+	site := func(name, displayName string) *client.Client {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"displayName":"` + displayName + `"}`))
+		}))
+		t.Cleanup(ts.Close)
+		c := client.NewDataCenter("", ts.URL, client.BearerAuth{})
+		c.Site = name
+		c.HTTP = ts.Client()
+		return c
+	}
+	// The same username belongs to different people on each instance
+	company := site("company-cache-test", "Jane Smith")
+	customer := site("customer-cache-test", "John Smith")
+
+	ctx := context.Background()
+	if got := fetchUserDisplayName(ctx, company, "jsmith"); got != "Jane Smith" {
+		t.Errorf("company name = %q, want Jane Smith", got)
+	}
+	if got := fetchUserDisplayName(ctx, customer, "jsmith"); got != "John Smith" {
+		t.Errorf("customer name = %q, want John Smith", got)
+	}
+}
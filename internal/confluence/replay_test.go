@@ -0,0 +1,128 @@
+package confluence
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/types"
+)
+
+// replayClient returns a client for example.atlassian.net that answers from
+// the cassette recorded in testdata/cassettes/name.
+func replayClient(t *testing.T, name string) *client.Client {
+	t.Helper()
+	cassette, err := client.ReplayCassette(filepath.Join("testdata", "cassettes", name))
+	if err != nil {
+		t.Fatalf("ReplayCassette() error = %v", err)
+	}
+	c := client.New("example.atlassian.net", client.BasicAuth{})
+	c.HTTP = cassette.HTTPClient()
+	return c
+}
+
+func TestGetPage_Replay(t *testing.T) {
+	t.Parallel()
+	c := replayClient(t, "get_page")
+	c.Site = "example"
+
+	got, err := GetPage(context.Background(), c, "https://example.atlassian.net/wiki/spaces/ENG/pages/123456/Checkout+rollout+plan")
+	if err != nil {
+		t.Fatalf("GetPage() error = %v", err)
+	}
+	want := `# Checkout rollout plan
+
+**Site:** example
+**Page ID:** 123456
+**Status:** current
+**Space ID:** 65538
+**Version:** 5
+**Last Updated:** 2024-05-03T14:20:11.538Z
+**Last Author:** Ada Lovelace {user:5b10ac8d82e05b22cc7d4ef5}
+**Created:** 2024-04-29T10:02:45.112Z
+**Author:** Grace Hopper {user:5b10a2844c20165700ede21g}
+**Parent Page ID:** 98765
+
+__DESCRIPTION__
+## Rollout
+
+Enable the new checkout for internal users. See [the runbook](https://example.atlassian.net/wiki/spaces/ENG/pages/98765).
+
+- [x] Feature flag created
+__END_DESCRIPTION__
+
+__CHECKSUMS__
+title=e5ef677763256ca0
+body=927070e55b18f3fb
+version=ef2d127de37b942b
+__END_CHECKSUMS__`
+	if got.Text != want {
+		t.Errorf("GetPage() text =\n%s\nwant\n%s", got.Text, want)
+	}
+}
+
+func TestSearchPages_Replay(t *testing.T) {
+	t.Parallel()
+	c := replayClient(t, "search_pages")
+
+	got, err := SearchPages(context.Background(), c, `space = ENG AND title ~ "checkout"`, client.PageRequest{Limit: 3})
+	if err != nil {
+		t.Fatalf("SearchPages() error = %v", err)
+	}
+	want := `# Search Results
+
+- **Checkout rollout plan** (ID: 123456, Type: page, Space: ENG)
+- **Checkout incident review** (ID: 123470, Type: page, Space: ENG)
+- **checkout-flow.png** (ID: 123488, Type: attachment, Space: ENG)
+`
+	if got.Text != want {
+		t.Errorf("SearchPages() text =\n%s\nwant\n%s", got.Text, want)
+	}
+}
+
+func TestUpdatePage_Replay(t *testing.T) {
+	t.Parallel()
+	c := replayClient(t, "update_page")
+
+	got, err := UpdatePage(context.Background(), c, types.ConfluenceUpdatePageParams{
+		PageID:    "123456",
+		Title:     "Checkout rollout plan",
+		Body:      "## Rollout\n\nEnable the **new checkout** for 10% of traffic.\n\n- Monitor error rates\n- Roll back on alerts",
+		Checksums: map[string]string{"body": "927070e55b18f3fb", "version": "ef2d127de37b942b"},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePage() error = %v", err)
+	}
+	want := `Page 123456 updated successfully.
+
+# Checkout rollout plan
+
+**Page ID:** 123456
+**Status:** current
+**Space ID:** 65538
+**Version:** 6
+**Last Updated:** 2024-05-07T09:12:40.907Z
+**Last Author:** Ada Lovelace {user:5b10ac8d82e05b22cc7d4ef5}
+**Created:** 2024-04-29T10:02:45.112Z
+**Author:** Grace Hopper {user:5b10a2844c20165700ede21g}
+**Parent Page ID:** 98765
+
+__DESCRIPTION__
+## Rollout
+
+Enable the **new checkout** for 10% of traffic.
+
+- Monitor error rates
+- Roll back on alerts
+__END_DESCRIPTION__
+
+__CHECKSUMS__
+title=e5ef677763256ca0
+body=8f0fd15c5c7aa1ac
+version=e7f6c011776e8db7
+__END_CHECKSUMS__`
+	if got.Text != want {
+		t.Errorf("UpdatePage() text =\n%s\nwant\n%s", got.Text, want)
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"atlassian-mcp/internal/audit"
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/logging"
+	"atlassian-mcp/internal/types"
+)
+
+const (
+	defaultAuditEntries = 20
+	maxAuditEntries     = 100
+)
+
+// auditLogVerb lists recent writes from the audit file.
+var auditLogVerb = verbDef{
+	name:        "audit_log",
+	kind:        kindRead,
+	description: "List recent writes made through this server",
+	notes: `Returns the latest write calls, newest first, with time, verb, target, result, and the fields changed.
+Structured results include the full records: params, checksums presented, and field values before the write.
+
+Requires ATLASSIAN_AUDIT_LOG to be set.`,
+	schema:   map[string]any{"type": "string", "description": fmt.Sprintf("number of writes to list, 1-%d (default %d)", maxAuditEntries, defaultAuditEntries)},
+	examples: []string{"20"},
+	run:      listAuditLog,
+}
+
+// auditedCall runs a write verb and records it in the audit log, whether it
+// succeeds or not.
+func auditedCall(ctx context.Context, tool toolDef, v verbDef, param string) any {
+	ctx = audit.NewContext(ctx)
+	result := v.call(withTool(ctx, tool), param)
+
+	entry := auditEntry(tool.name, v.name, param, result)
+	if c := client.FromContext(ctx); c != nil {
+		entry.Site = c.Site
+	}
+	entry.Before = audit.Before(ctx)
+	if err := audit.Write(entry); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to write audit entry", "error", err)
+	}
+	return result
+}
+
+// auditEntry builds the audit record of a write call from its param and tool
+// result.
+func auditEntry(tool, verb, param string, result any) audit.Entry {
+	e := audit.Entry{
+		Time:   time.Now().UTC(),
+		Tool:   tool,
+		Verb:   verb,
+		Result: audit.ResultSuccess,
+		Params: param,
+	}
+
+	var params map[string]any
+	if json.Unmarshal([]byte(param), &params) == nil {
+		if checksums, ok := params["checksums"].(map[string]any); ok {
+			e.Checksums = make(map[string]string, len(checksums))
+			for k, v := range checksums {
+				e.Checksums[k], _ = v.(string)
+			}
+			delete(params, "checksums")
+		}
+		e.Params = params
+	}
+
+	res, _ := result.(map[string]any)
+	output, _ := res["structuredContent"].(map[string]any)
+	if res["isError"] == true {
+		e.Result = audit.ResultError
+		if content, ok := res["content"].([]types.TextContent); ok && len(content) > 0 {
+			e.Error = content[0].Text
+		}
+	} else {
+		e.Output = output
+	}
+
+	// Created issues and pages are only known from the output
+	for _, key := range []string{"issue", "pageId"} {
+		if target, ok := params[key].(string); ok && target != "" {
+			e.Target = target
+			break
+		}
+		if target, ok := output[key].(string); ok && target != "" {
+			e.Target = target
+			break
+		}
+	}
+	return e
+}
+
+// listAuditLog renders the latest audit entries.
+func listAuditLog(_ context.Context, _ *client.Client, param string) (types.Result, error) {
+	n := defaultAuditEntries
+	if param = strings.TrimSpace(param); param != "" {
+		v, err := strconv.Atoi(param)
+		if err != nil || v < 1 || v > maxAuditEntries {
+			return types.Result{}, fmt.Errorf("param must be a number from 1 to %d", maxAuditEntries)
+		}
+		n = v
+	}
+
+	entries, err := audit.Recent(n)
+	if err != nil {
+		return types.Result{}, err
+	}
+	if len(entries) == 0 {
+		return types.Result{Text: "No writes recorded yet.", Data: map[string]any{"entries": []any{}}}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Recent Writes (%d)\n\n", len(entries)))
+	sb.WriteString("| Time | Verb | Target | Result | Fields |\n")
+	sb.WriteString("|------|------|--------|--------|--------|\n")
+	for _, e := range entries {
+		result := e.Result
+		if params, ok := e.Params.(map[string]any); ok && params["dryRun"] == true {
+			result += " (dry run)"
+		}
+		if e.Error != "" {
+			result += ": " + firstLine(e.Error)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			e.Time.Format(time.RFC3339), tableCell(e.Verb), tableCell(e.Target), tableCell(result), tableCell(strings.Join(changedFields(e), ", "))))
+	}
+
+	return types.Result{Text: sb.String(), Data: map[string]any{"entries": entries}}, nil
+}
+
+// changedFields lists the fields a write set, from its params.
+func changedFields(e audit.Entry) []string {
+	params, _ := e.Params.(map[string]any)
+	if fields, ok := params["fields"].(map[string]any); ok {
+		params = fields
+	}
+
+	var names []string
+	for name := range params {
+		if !slices.Contains([]string{"issue", "pageId", "dryRun", "confirm"}, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// tableCell escapes s for a markdown table cell, which cannot hold pipes or
+// line breaks.
+func tableCell(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "|", `\|`)), " ")
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
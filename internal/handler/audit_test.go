@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+
+	"atlassian-mcp/internal/audit"
+	"atlassian-mcp/internal/types"
+)
+
+func TestAuditEntry(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		verb          string
+		param         string
+		result        map[string]any
+		wantTarget    string
+		wantResult    string
+		wantChecksums map[string]string
+		wantFields    []string
+	}{
+		{
+			name:          "update",
+			verb:          "jira_update_issue",
+			param:         `{"issue": "PROJ-1", "fields": {"summary": "New"}, "checksums": {"summary": "abc"}}`,
+			result:        dataResult(types.Result{Text: "Issue PROJ-1 updated", Data: map[string]any{"issue": "PROJ-1"}}),
+			wantTarget:    "PROJ-1",
+			wantResult:    audit.ResultSuccess,
+			wantChecksums: map[string]string{"summary": "abc"},
+			wantFields:    []string{"summary"},
+		},
+		{
+			name:       "create takes target from output",
+			verb:       "confluence_create_page",
+			param:      `{"spaceId": "1", "title": "T", "body": "B"}`,
+			result:     dataResult(types.Result{Text: "Page created", Data: map[string]any{"pageId": "42", "title": "T"}}),
+			wantTarget: "42",
+			wantResult: audit.ResultSuccess,
+			wantFields: []string{"body", "spaceId", "title"},
+		},
+		{
+			name:          "failure",
+			verb:          "confluence_update_page",
+			param:         `{"pageId": "42", "body": "B", "checksums": {"body": "x"}}`,
+			result:        errorResult("conflict: fields modified since read: body"),
+			wantTarget:    "42",
+			wantResult:    audit.ResultError,
+			wantChecksums: map[string]string{"body": "x"},
+			wantFields:    []string{"body"},
+		},
+		{
+			name:       "invalid JSON kept verbatim",
+			verb:       "jira_add_comment",
+			param:      `not json`,
+			result:     errorResult("invalid"),
+			wantResult: audit.ResultError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := auditEntry("atlassian_write", tt.verb, tt.param, tt.result)
+			if e.Target != tt.wantTarget || e.Result != tt.wantResult {
+				t.Errorf("auditEntry() target = %q, result = %q; want %q, %q", e.Target, e.Result, tt.wantTarget, tt.wantResult)
+			}
+			if len(e.Checksums) != len(tt.wantChecksums) {
+				t.Errorf("auditEntry() checksums = %v, want %v", e.Checksums, tt.wantChecksums)
+			}
+			for k, v := range tt.wantChecksums {
+				if e.Checksums[k] != v {
+					t.Errorf("auditEntry() checksums = %v, want %v", e.Checksums, tt.wantChecksums)
+				}
+			}
+			if got := changedFields(e); !slices.Equal(got, tt.wantFields) {
+				t.Errorf("changedFields() = %v, want %v", got, tt.wantFields)
+			}
+			if tt.wantResult == audit.ResultError && e.Error == "" {
+				t.Error("auditEntry() error is empty for a failed call")
+			}
+		})
+	}
+}
+
+func TestTableCell(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"PROJ-1", "PROJ-1"},
+		{"error: a | b", `error: a \| b`},
+		{"first\r\nsecond", "first second"},
+	}
+	for _, tt := range tests {
+		if got := tableCell(tt.in); got != tt.want {
+			t.Errorf("tableCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
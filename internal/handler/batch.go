@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+const (
+	batchVerbName = "batch"
+
+	// maxBatchEntries caps the number of entries in one batch call.
+	maxBatchEntries = 20
+
+	// maxBatchConcurrency caps how many batch entries run at once, so a
+	// single call cannot flood the Atlassian API.
+	maxBatchConcurrency = 4
+)
+
+// batchVerb runs several read verbs in one call. It is registered in init
+// because it dispatches through the registry it belongs to.
+var batchVerb = verbDef{
+	name:        batchVerbName,
+	kind:        kindRead,
+	description: "Run several read verbs concurrently in one call",
+	notes: fmt.Sprintf(`Runs up to %d entries, %d at a time. Results are returned in request order, one section per entry.
+A failing entry reports its error in its own section and does not fail the batch.
+Only read verbs available in this tool can be batched; batch cannot be nested.`, maxBatchEntries, maxBatchConcurrency),
+	schema: map[string]any{
+		"type": "array",
+		"items": objectParam(map[string]any{
+			"verb":  field("string", "Read verb to run"),
+			"param": field("string", "Param for that verb"),
+			"site":  field("string", "Site to run it against, as for a direct call"),
+		}, "verb", "param"),
+	},
+	examples: []string{`[{"verb": "jira_get_issue", "param": "PROJ-1"}, {"verb": "jira_get_comments", "param": "PROJ-1"}]`},
+	run:      runBatch,
+}
+
+func init() {
+	registry = append(registry, batchVerb)
+}
+
+// batchEntry is one element of the batch param.
+type batchEntry struct {
+	Verb  string `json:"verb"`
+	Param string `json:"param"`
+	Site  string `json:"site,omitempty"`
+}
+
+// batchOutcome is the result of one entry.
+type batchOutcome struct {
+	result types.Result
+	err    error
+}
+
+func runBatch(ctx context.Context, _ *client.Client, param string) (types.Result, error) {
+	var entries []batchEntry
+	if err := json.Unmarshal([]byte(param), &entries); err != nil {
+		return types.Result{}, fmt.Errorf("Invalid JSON params: %w", err)
+	}
+	if len(entries) == 0 {
+		return types.Result{}, fmt.Errorf("batch requires at least one entry")
+	}
+	if len(entries) > maxBatchEntries {
+		return types.Result{}, fmt.Errorf("batch accepts at most %d entries, got %d", maxBatchEntries, len(entries))
+	}
+
+	tool, _ := toolFromContext(ctx)
+	session.ExpectSteps(ctx, len(entries))
+
+	outcomes := make([]batchOutcome, len(entries))
+	slots := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			outcomes[i].err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			outcomes[i] = runBatchEntry(ctx, tool, entry)
+			session.Progress(ctx, fmt.Sprintf("Finished entry %d (%s)", i+1, entry.Verb))
+		}()
+	}
+	wg.Wait()
+
+	return batchResult(entries, outcomes), nil
+}
+
+// runBatchEntry runs one entry with the same checks and site selection as a
+// direct call. Entries default to the site of the batch call.
+func runBatchEntry(ctx context.Context, tool toolDef, entry batchEntry) (outcome batchOutcome) {
+	defer func() {
+		if r := recover(); r != nil {
+			outcome = batchOutcome{err: fmt.Errorf("internal error: %v", r)}
+		}
+	}()
+
+	if entry.Verb == batchVerbName {
+		return batchOutcome{err: fmt.Errorf("batch cannot be nested")}
+	}
+	if err := tool.checkVerb(entry.Verb); err != nil {
+		return batchOutcome{err: err}
+	}
+	v, ok := findVerb(entry.Verb)
+	if !ok {
+		return batchOutcome{err: fmt.Errorf("Unknown verb: %s", entry.Verb)}
+	}
+	if entry.Param == "help" {
+		return batchOutcome{result: types.Result{Text: v.help()}}
+	}
+	if err := v.validate(entry.Param); err != nil {
+		return batchOutcome{err: err}
+	}
+	ctx, err := withSite(ctx, v, entry.Site, entry.Param)
+	if err != nil {
+		return batchOutcome{err: err}
+	}
+	result, err := v.run(ctx, client.FromContext(ctx), entry.Param)
+	return batchOutcome{result: result, err: err}
+}
+
+// batchResult renders the outcomes in request order.
+func batchResult(entries []batchEntry, outcomes []batchOutcome) types.Result {
+	var sb strings.Builder
+	data := make([]map[string]any, len(entries))
+	failed := 0
+	for i, entry := range entries {
+		out := outcomes[i]
+		sb.WriteString(fmt.Sprintf("## [%d] %s %s\n\n", i+1, entry.Verb, entry.Param))
+
+		item := map[string]any{"verb": entry.Verb, "param": entry.Param}
+		if out.err != nil {
+			failed++
+			sb.WriteString("Error: " + out.err.Error() + "\n\n")
+			item["error"] = out.err.Error()
+		} else {
+			sb.WriteString(strings.TrimRight(out.result.Text, "\n") + "\n\n")
+			if out.result.Data != nil {
+				item["data"] = out.result.Data
+			}
+		}
+		data[i] = item
+	}
+
+	summary := fmt.Sprintf("Batch: %d entries, %d succeeded, %d failed\n\n", len(entries), len(entries)-failed, failed)
+	return types.Result{
+		Text: summary + strings.TrimRight(sb.String(), "\n") + "\n",
+		Data: map[string]any{"entries": data},
+	}
+}
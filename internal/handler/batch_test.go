@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/config"
+)
+
+func TestRunBatch(t *testing.T) {
+	setToolConfig(t, config.LayoutPerService, false, nil, nil)
+	tool, ok := findTool("confluence_read")
+	if !ok {
+		t.Fatal("confluence_read not found")
+	}
+	ctx := withTool(context.Background(), tool)
+
+	res, err := runBatch(ctx, nil, `[
+		{"verb": "get_format", "param": ""},
+		{"verb": "jira_get_issue", "param": "PROJ-1"},
+		{"verb": "batch", "param": "[]"},
+		{"verb": "confluence_get_page", "param": ""},
+		{"verb": "get_format", "param": "help"}
+	]`)
+	if err != nil {
+		t.Fatalf("runBatch() error = %v", err)
+	}
+
+	entries, _ := res.Data["entries"].([]map[string]any)
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+	wantErr := []string{"", "not available in confluence_read", "cannot be nested", "must not be empty", ""}
+	for i, want := range wantErr {
+		got, _ := entries[i]["error"].(string)
+		if want == "" && got != "" || !strings.Contains(got, want) {
+			t.Errorf("entry %d error = %q, want %q", i, got, want)
+		}
+	}
+	if !strings.HasPrefix(res.Text, "Batch: 5 entries, 2 succeeded, 3 failed") {
+		t.Errorf("unexpected summary: %q", strings.SplitN(res.Text, "\n", 2)[0])
+	}
+}
+
+func TestRunBatch_Limits(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		param   string
+		wantErr string
+	}{
+		{"empty", `[]`, "at least one entry"},
+		{"too many", "[" + strings.Repeat(`{"verb":"get_format","param":""},`, maxBatchEntries) + `{"verb":"get_format","param":""}]`, "at most"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := runBatch(context.Background(), nil, tt.param)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runBatch() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
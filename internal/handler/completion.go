@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/confluence"
+	"atlassian-mcp/internal/jira"
+	"atlassian-mcp/internal/types"
+	"atlassian-mcp/internal/users"
+)
+
+const (
+	// maxCompletionValues is the most values returned per completion, as
+	// capped by the MCP spec.
+	maxCompletionValues = 100
+
+	// lookupTTL bounds how long project, issue type, space and user lookups
+	// are reused. Completions fire on every keystroke, so they must not each
+	// hit the API.
+	lookupTTL = 5 * time.Minute
+
+	// minUserQuery is the shortest value sent to the user search.
+	minUserQuery = 2
+)
+
+// completer returns candidate values for an argument given its partial value
+// and the other arguments the client has already filled in.
+type completer func(ctx context.Context, value string, args map[string]string) ([]string, error)
+
+// argumentCompleter is a completer, run only while the verb that fetches the
+// same data is enabled.
+type argumentCompleter struct {
+	complete completer
+	verb     string
+}
+
+// completers maps prompt and resource template argument names to their
+// completers. Arguments without an entry complete to nothing.
+var completers = map[string]argumentCompleter{
+	"issue":     {completeIssueKey, "jira_search"},
+	"epic":      {completeIssueKey, "jira_search"},
+	"target":    {completeIssueKey, "jira_search"},
+	"key":       {completeIssueKey, "jira_search"},
+	"project":   {completeProjectKey, "jira_search"},
+	"issuetype": {completeIssueType, "jira_create_issue"},
+	"spaceId":   {completeSpaceID, "confluence_search"},
+	"mention":   {completeUser, "search_users"},
+}
+
+// handleComplete answers completion/complete for prompt and resource template
+// arguments.
+func handleComplete(ctx context.Context, req types.Request) types.Response {
+	var params types.CompleteParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Argument.Name == "" {
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &types.Error{Code: -32602, Message: "Invalid params: ref and argument are required"},
+		}
+	}
+
+	args, err := completionArguments(params.Ref)
+	if err != nil {
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &types.Error{Code: -32602, Message: err.Error()},
+		}
+	}
+
+	var values []string
+	c, ok := completers[params.Argument.Name]
+	if ok && args[params.Argument.Name] && verbsEnabled(c.verb) {
+		var filled map[string]string
+		if params.Context != nil {
+			filled = params.Context.Arguments
+		}
+		values, err = c.complete(ctx, strings.TrimSpace(params.Argument.Value), filled)
+		if err != nil {
+			return types.Response{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &types.Error{Code: -32603, Message: err.Error()},
+			}
+		}
+	}
+
+	return types.Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]any{"completion": newCompletion(values)},
+	}
+}
+
+// completionArguments returns the argument names accepted by the referenced
+// prompt or resource template. Prompts and templates hidden by the verb
+// settings are unknown.
+func completionArguments(ref types.CompletionRef) (map[string]bool, error) {
+	args := map[string]bool{}
+	switch ref.Type {
+	case "ref/prompt":
+		def, ok := findPrompt(ref.Name)
+		if !ok || !def.enabled() {
+			return nil, fmt.Errorf("Unknown prompt: %s", ref.Name)
+		}
+		for _, arg := range def.Arguments {
+			args[arg.Name] = true
+		}
+	case "ref/resource":
+		var found bool
+		for _, t := range resourceTemplates {
+			if t.URITemplate == ref.URI && verbsEnabled(t.verb) {
+				found = true
+				for _, name := range templateVariables(t.URITemplate) {
+					args[name] = true
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown resource template: %s", ref.URI)
+		}
+	default:
+		return nil, fmt.Errorf("Invalid params: unknown ref type %q", ref.Type)
+	}
+	return args, nil
+}
+
+// templateVariables returns the variable names in a URI template.
+func templateVariables(template string) []string {
+	var names []string
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return names
+		}
+		names = append(names, template[start+1:start+end])
+		template = template[start+end+1:]
+	}
+}
+
+// newCompletion caps values at the spec limit and reports the total.
+func newCompletion(values []string) types.Completion {
+	c := types.Completion{Values: values, Total: len(values)}
+	if c.Values == nil {
+		c.Values = []string{}
+	}
+	if len(c.Values) > maxCompletionValues {
+		c.Values = c.Values[:maxCompletionValues]
+		c.HasMore = true
+	}
+	return c
+}
+
+// hasPrefixFold reports whether s starts with prefix, ignoring case.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// completeIssueKey completes the project part of an issue key. Issue numbers
+// are left to the user since they cannot be listed cheaply.
+func completeIssueKey(ctx context.Context, value string, _ map[string]string) ([]string, error) {
+	if strings.Contains(value, "-") {
+		return nil, nil
+	}
+	keys, err := completeProjectKey(ctx, value, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = key + "-"
+	}
+	return keys, nil
+}
+
+// completeProjectKey completes project keys, matching on key or name.
+func completeProjectKey(ctx context.Context, value string, _ map[string]string) ([]string, error) {
+	projects, err := cachedLookup(ctx, "projects", jira.ListProjects)
+	if err != nil {
+		return nil, err
+	}
+	return matchProjects(projects, value), nil
+}
+
+func matchProjects(projects []jira.Project, value string) []string {
+	var keys []string
+	for _, p := range projects {
+		if hasPrefixFold(p.Key, value) || hasPrefixFold(p.Name, value) {
+			keys = append(keys, p.Key)
+		}
+	}
+	return keys
+}
+
+// completeIssueType completes issue types of the project filled in so far.
+func completeIssueType(ctx context.Context, value string, args map[string]string) ([]string, error) {
+	project := strings.ToUpper(strings.TrimSpace(args["project"]))
+	if project == "" {
+		return nil, nil
+	}
+	issueTypes, err := cachedLookup(ctx, "issuetypes:"+project, func(ctx context.Context, c *client.Client) ([]string, error) {
+		return jira.ListIssueTypes(ctx, c, project)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range issueTypes {
+		if hasPrefixFold(name, value) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// completeSpaceID completes space IDs, matching on ID, key or name since
+// users rarely know the numeric ID.
+func completeSpaceID(ctx context.Context, value string, _ map[string]string) ([]string, error) {
+	spaces, err := cachedLookup(ctx, "spaces", confluence.ListSpaces)
+	if err != nil {
+		return nil, err
+	}
+	return matchSpaces(spaces, value), nil
+}
+
+func matchSpaces(spaces []confluence.Space, value string) []string {
+	var ids []string
+	for _, s := range spaces {
+		if strings.HasPrefix(s.ID, value) || hasPrefixFold(s.Key, value) || hasPrefixFold(s.Name, value) {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+// completeUser completes user display names.
+func completeUser(ctx context.Context, value string, _ map[string]string) ([]string, error) {
+	if len(value) < minUserQuery {
+		return nil, nil
+	}
+	query := strings.ToLower(value)
+	found, err := cachedLookup(ctx, "users:"+query, func(ctx context.Context, c *client.Client) ([]users.User, error) {
+		return users.FindUsers(ctx, c, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(found))
+	for _, u := range found {
+		names = append(names, u.DisplayName)
+	}
+	return names, nil
+}
+
+// lookupCache holds lookup results for lookupTTL. Failed lookups are not
+// cached.
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[string]lookupEntry
+	now     func() time.Time
+}
+
+type lookupEntry struct {
+	value   any
+	expires time.Time
+}
+
+var lookups = newLookupCache()
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{entries: map[string]lookupEntry{}, now: time.Now}
+}
+
+func (c *lookupCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *lookupCache) set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// User lookups are keyed by query, so drop stale entries as we go
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = lookupEntry{value: value, expires: now.Add(lookupTTL)}
+}
+
+// cachedLookup returns the cached result for key, calling fetch with the
+// client carried by ctx on a miss. Entries are kept per site.
+func cachedLookup[T any](ctx context.Context, key string, fetch func(context.Context, *client.Client) (T, error)) (T, error) {
+	c := client.FromContext(ctx)
+	if c != nil {
+		key = c.Site + "\x00" + key
+	}
+	if v, ok := lookups.get(key); ok {
+		return v.(T), nil
+	}
+	value, err := fetch(ctx, c)
+	if err != nil {
+		return value, err
+	}
+	lookups.set(key, value)
+	return value, nil
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/confluence"
+	"atlassian-mcp/internal/jira"
+	"atlassian-mcp/internal/types"
+)
+
+func TestMatchProjects(t *testing.T) {
+	t.Parallel()
+	projects := []jira.Project{
+		{Key: "PROJ", Name: "Platform"},
+		{Key: "OPS", Name: "Operations"},
+		{Key: "PAY", Name: "Payments"},
+	}
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"PROJ", "OPS", "PAY"}},
+		{"p", []string{"PROJ", "PAY"}},
+		{"PR", []string{"PROJ"}},
+		{"oper", []string{"OPS"}},
+		{"X", nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			if got := matchProjects(projects, tt.value); !slices.Equal(got, tt.want) {
+				t.Errorf("matchProjects(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchSpaces(t *testing.T) {
+	t.Parallel()
+	spaces := []confluence.Space{
+		{ID: "123", Key: "DEV", Name: "Engineering"},
+		{ID: "456", Key: "HR", Name: "People"},
+	}
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"123", "456"}},
+		{"12", []string{"123"}},
+		{"dev", []string{"123"}},
+		{"peo", []string{"456"}},
+		{"X", nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			if got := matchSpaces(spaces, tt.value); !slices.Equal(got, tt.want) {
+				t.Errorf("matchSpaces(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCompletion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		count       int
+		wantValues  int
+		wantHasMore bool
+	}{
+		{0, 0, false},
+		{5, 5, false},
+		{maxCompletionValues, maxCompletionValues, false},
+		{maxCompletionValues + 1, maxCompletionValues, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strconv.Itoa(tt.count), func(t *testing.T) {
+			t.Parallel()
+			var values []string
+			for i := range tt.count {
+				values = append(values, strconv.Itoa(i))
+			}
+			got := newCompletion(values)
+			if len(got.Values) != tt.wantValues || got.HasMore != tt.wantHasMore || got.Total != tt.count {
+				t.Errorf("newCompletion(%d values) = %d values, total %d, hasMore %v", tt.count, len(got.Values), got.Total, got.HasMore)
+			}
+			if got.Values == nil {
+				t.Error("Values is nil, want empty slice")
+			}
+		})
+	}
+}
+
+func TestCompletionArguments(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		ref     types.CompletionRef
+		want    []string
+		wantErr bool
+	}{
+		{"prompt", types.CompletionRef{Type: "ref/prompt", Name: "triage_issue"}, []string{"issue"}, false},
+		{"resource template", types.CompletionRef{Type: "ref/resource", URI: "jira://issue/{key}/comments"}, []string{"key"}, false},
+		{"unknown prompt", types.CompletionRef{Type: "ref/prompt", Name: "nope"}, nil, true},
+		{"unknown template", types.CompletionRef{Type: "ref/resource", URI: "jira://board/{id}"}, nil, true},
+		{"unknown ref type", types.CompletionRef{Type: "ref/tool", Name: "atlassian_read"}, nil, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := completionArguments(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("completionArguments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("completionArguments() = %v, missing %q", got, name)
+				}
+			}
+		})
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newLookupCache()
+	c.now = func() time.Time { return now }
+
+	c.set("projects", []string{"PROJ"})
+	if _, ok := c.get("projects"); !ok {
+		t.Fatal("get() missed a fresh entry")
+	}
+
+	now = now.Add(lookupTTL)
+	if _, ok := c.get("projects"); ok {
+		t.Error("get() returned an expired entry")
+	}
+	c.set("spaces", nil)
+	if _, ok := c.entries["projects"]; ok {
+		t.Error("set() kept an expired entry")
+	}
+}
+
+func TestHandleComplete(t *testing.T) {
+	// Seeds the shared lookup cache so no API call is made
+	lookups.set("projects", []jira.Project{{Key: "PROJ", Name: "Platform"}, {Key: "OPS", Name: "Operations"}})
+
+	tests := []struct {
+		name     string
+		params   string
+		want     []string
+		wantCode int
+	}{
+		{"issue key", `{"ref": {"type": "ref/prompt", "name": "triage_issue"}, "argument": {"name": "issue", "value": "pr"}}`, []string{"PROJ-"}, 0},
+		{"resource key", `{"ref": {"type": "ref/resource", "uri": "jira://issue/{key}"}, "argument": {"name": "key", "value": ""}}`, []string{"PROJ-", "OPS-"}, 0},
+		{"issue number not completed", `{"ref": {"type": "ref/prompt", "name": "triage_issue"}, "argument": {"name": "issue", "value": "PROJ-1"}}`, []string{}, 0},
+		{"project", `{"ref": {"type": "ref/prompt", "name": "draft_issue"}, "argument": {"name": "project", "value": "op"}}`, []string{"OPS"}, 0},
+		{"issuetype without project", `{"ref": {"type": "ref/prompt", "name": "draft_issue"}, "argument": {"name": "issuetype", "value": "B"}}`, []string{}, 0},
+		{"argument not in prompt", `{"ref": {"type": "ref/prompt", "name": "triage_issue"}, "argument": {"name": "project", "value": "P"}}`, []string{}, 0},
+		{"argument without completer", `{"ref": {"type": "ref/prompt", "name": "draft_issue"}, "argument": {"name": "summary", "value": "P"}}`, []string{}, 0},
+		{"unknown prompt", `{"ref": {"type": "ref/prompt", "name": "nope"}, "argument": {"name": "issue", "value": ""}}`, nil, -32602},
+		{"missing argument", `{"ref": {"type": "ref/prompt", "name": "triage_issue"}}`, nil, -32602},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := handleComplete(context.Background(), types.Request{ID: 1, Params: json.RawMessage(tt.params)})
+			if tt.wantCode != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.wantCode {
+					t.Fatalf("handleComplete() error = %+v, want code %d", resp.Error, tt.wantCode)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("handleComplete() error = %+v", resp.Error)
+			}
+			got := resp.Result.(map[string]any)["completion"].(types.Completion)
+			if !slices.Equal(got.Values, tt.want) {
+				t.Errorf("handleComplete() values = %v, want %v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleComplete_DisabledVerbs(t *testing.T) {
+	lookups.set("projects", []jira.Project{{Key: "PROJ", Name: "Platform"}})
+
+	tests := []struct {
+		name     string
+		denied   []string
+		params   string
+		want     []string
+		wantCode int
+	}{
+		{"prompt disabled", []string{"jira_get_comments"}, `{"ref": {"type": "ref/prompt", "name": "triage_issue"}, "argument": {"name": "issue", "value": ""}}`, nil, -32602},
+		{"template disabled", []string{"jira_get_issue"}, `{"ref": {"type": "ref/resource", "uri": "jira://issue/{key}"}, "argument": {"name": "key", "value": ""}}`, nil, -32602},
+		{"completer disabled", []string{"jira_search"}, `{"ref": {"type": "ref/resource", "uri": "jira://issue/{key}"}, "argument": {"name": "key", "value": ""}}`, []string{}, 0},
+		{"other verb disabled", []string{"confluence_search"}, `{"ref": {"type": "ref/resource", "uri": "jira://issue/{key}"}, "argument": {"name": "key", "value": ""}}`, []string{"PROJ-"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setToolConfig(t, config.LayoutCombined, false, nil, tt.denied)
+			resp := handleComplete(context.Background(), types.Request{ID: 1, Params: json.RawMessage(tt.params)})
+			if tt.wantCode != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.wantCode {
+					t.Fatalf("handleComplete() error = %+v, want code %d", resp.Error, tt.wantCode)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("handleComplete() error = %+v", resp.Error)
+			}
+			got := resp.Result.(map[string]any)["completion"].(types.Completion)
+			if !slices.Equal(got.Values, tt.want) {
+				t.Errorf("handleComplete() values = %v, want %v", got.Values, tt.want)
+			}
+		})
+	}
+}
@@ -1,78 +1,131 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/config"
 	"atlassian-mcp/internal/confluence"
 	"atlassian-mcp/internal/types"
 )
 
-// handleConfluenceRead handles Confluence read operations.
-func handleConfluenceRead(operation, param string) any {
-	switch operation {
-	case "get_page":
-		result, err := confluence.GetPage(param)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+// confluenceVerbs declares the Confluence verbs.
+var confluenceVerbs = []verbDef{
+	{
+		name:        "confluence_get_page",
+		service:     "confluence",
+		kind:        kindRead,
+		description: "Get page content",
+		notes: `Returns: title, status, space, author, version, body (as markdown), checksums.
 
-	case "get_comments":
-		result, err := confluence.GetComments(param)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Roundtrip formats in output (copy into confluence_update_page):
+- Mentions: @[Name](accountId:xxx)
+- Dates: {date:2024-01-15}
+- Status: {status:TODO|color=blue}
 
-	case "search":
-		result, err := confluence.SearchPages(param)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Returns __CHECKSUMS__ section with SHA256 hashes for: title, body, version.
+Required for confluence_update_page.`,
+		schema:   stringParam("page ID or URL"),
+		examples: []string{"123456"},
+		run:      confluence.GetPage,
+	},
+	{
+		name:        "confluence_get_comments",
+		service:     "confluence",
+		kind:        kindRead,
+		description: "Get page comments",
+		notes:       "Returns up to 50 comments with author, timestamp, and body in markdown. Pass limit for more, and cursor to continue after the last result.",
+		schema:      stringParam("page ID or URL"),
+		examples:    []string{"123456", `{"pageId": "123456", "limit": 100}`},
+		listField:   "pageId",
+		run:         withPage(confluence.GetComments),
+	},
+	{
+		name:        "confluence_search",
+		service:     "confluence",
+		kind:        kindRead,
+		description: "Search pages with CQL",
+		notes: `Returns up to 50 matching pages with: ID, title, space, status.
+Pass limit for more, and cursor to continue after the last result.
 
-	default:
-		return errorResult("Unknown Confluence read operation: " + operation + ". Valid: get_page, get_comments, search")
-	}
-}
-
-// handleConfluenceWrite handles Confluence write operations.
-func handleConfluenceWrite(operation, param string) any {
-	switch operation {
-	case "add_comment":
-		var p types.ConfluenceAddCommentParams
-		if err := json.Unmarshal([]byte(param), &p); err != nil {
-			return errorResult("Invalid JSON params: " + err.Error() + "\n\n" + types.ConfluenceWriteVerbHelp["add_comment"])
-		}
-		result, err := confluence.AddComment(p)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+CQL Reference: https://developer.atlassian.com/cloud/confluence/cql-functions/`,
+		schema:    stringParam("CQL query string"),
+		examples:  []string{"space = DEV AND title ~ 'API'", `{"cql": "space = DEV", "limit": 100}`},
+		listField: "cql",
+		run:       withPage(confluence.SearchPages),
+	},
+	{
+		name:        "confluence_add_comment",
+		service:     "confluence",
+		kind:        kindWrite,
+		description: "Add comment to page",
+		notes: `Body supports markdown:
+- Blocks: headings, code blocks, blockquotes, lists, tables
+- Inline: **bold**, *italic*, ~~strike~~, ` + "`code`" + `, [link](url)
+- Mentions: @[Name](accountId:xxx)`,
+		schema: objectParam(map[string]any{
+			"pageId":  field("string", "Page ID or URL"),
+			"body":    field("string", "Comment text in extended markdown"),
+			"dryRun":  dryRunField(),
+			"confirm": confirmField(),
+		}, "pageId", "body"),
+		examples: []string{`{"pageId": "123456", "body": "Comment text"}`},
+		run:      withParams(confluence.AddComment),
+	},
+	{
+		name:        "confluence_update_page",
+		service:     "confluence",
+		kind:        kindWrite,
+		description: "Update page content (requires checksums)",
+		notes: `Workflow:
+1. Call get_format to learn extended markdown syntax
+2. Call confluence_get_page to get current values and checksums
+3. Include checksums for fields you're updating
+4. If page changed since read, returns conflict error
+5. Optionally pass "dryRun": true first to preview the request and a diff
+6. Some changes need human confirmation (ATLASSIAN_CONFIRM_WRITES); if asked,
+   show the user the change and repeat with "confirm": true once they approve
 
-	case "update_page":
-		var p types.ConfluenceUpdatePageParams
-		if err := json.Unmarshal([]byte(param), &p); err != nil {
-			return errorResult("Invalid JSON params: " + err.Error() + "\n\n" + types.ConfluenceWriteVerbHelp["update_page"])
-		}
-		result, err := confluence.UpdatePage(p)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Checksum fields: title, body, version (all required)
 
-	case "create_page":
-		var p types.ConfluenceCreatePageParams
-		if err := json.Unmarshal([]byte(param), &p); err != nil {
-			return errorResult("Invalid JSON params: " + err.Error() + "\n\n" + types.ConfluenceWriteVerbHelp["create_page"])
-		}
-		result, err := confluence.CreatePage(p)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Returns fresh checksums on success.`,
+		schema: objectParam(map[string]any{
+			"pageId":    field("string", "Page ID or URL"),
+			"title":     field("string", "New title; omit to keep the current one"),
+			"body":      field("string", "New content in extended markdown; omit to keep the current one"),
+			"checksums": checksumsField("Checksums from confluence_get_page"),
+			"dryRun":    dryRunField(),
+			"confirm":   confirmField(),
+		}, "pageId", "checksums"),
+		examples: []string{`{"pageId": "123456", "title": "New Title", "body": "Content", "checksums": {"title": "...", "body": "...", "version": "..."}}`},
+		run:      withParams(confluence.UpdatePage),
+	},
+	{
+		name:        "confluence_create_page",
+		service:     "confluence",
+		kind:        kindWrite,
+		description: "Create new page",
+		notes: `Workflow:
+1. Call get_format to learn extended markdown syntax
+2. Create page with fields
 
-	default:
-		return errorResult("Unknown Confluence write operation: " + operation + ". Valid: add_comment, update_page, create_page")
-	}
+Returns created page ID.`,
+		schema: objectParam(map[string]any{
+			"spaceId":  field("string", "Space ID; omit to use the default space of the site"),
+			"title":    field("string", "Page title"),
+			"body":     field("string", "Content in extended markdown"),
+			"parentId": field("string", "Parent page ID, for child pages"),
+			"dryRun":   dryRunField(),
+			"confirm":  confirmField(),
+		}, "title"),
+		examples: []string{`{"spaceId": "123", "title": "Title", "body": "Content", "parentId": "456"}`},
+		run: withParams(func(ctx context.Context, c *client.Client, p types.ConfluenceCreatePageParams) (types.Result, error) {
+			spaceID, err := siteDefault(c, "spaceId", p.SpaceID, func(p config.Profile) string { return p.Space })
+			if err != nil {
+				return types.Result{}, err
+			}
+			p.SpaceID = spaceID
+			return confluence.CreatePage(ctx, c, p)
+		}),
+	},
 }
@@ -1,24 +1,50 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/confirm"
+	"atlassian-mcp/internal/logging"
+	"atlassian-mcp/internal/session"
 	"atlassian-mcp/internal/types"
-	"atlassian-mcp/internal/users"
 )
 
-// HandleRequest routes MCP requests to appropriate handlers.
-func HandleRequest(req types.Request) types.Response {
+// New returns a handler for MCP requests that calls Atlassian through the
+// clients of the configured sites. The first is the default site.
+func New(sites ...*client.Client) func(context.Context, types.Request) types.Response {
+	return func(ctx context.Context, req types.Request) types.Response {
+		return handleRequest(withSites(client.NewContext(ctx, sites[0]), sites), req)
+	}
+}
+
+// handleRequest routes MCP requests to appropriate handlers.
+func handleRequest(ctx context.Context, req types.Request) types.Response {
+	ctx = logging.NewContext(ctx, "request_id", logging.NewRequestID(), "method", req.Method)
+
 	switch req.Method {
 	case "initialize":
+		var params types.InitializeParams
+		_ = json.Unmarshal(req.Params, &params)
+		version := session.NegotiateProtocolVersion(params.ProtocolVersion)
+		session.FromContext(ctx).SetProtocolVersion(version)
+		session.FromContext(ctx).SetClientCapabilities(params.Capabilities)
 		return types.Response{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Result: map[string]any{
-				"protocolVersion": "2024-11-05",
+				"protocolVersion": version,
 				"capabilities": map[string]any{
-					"tools": map[string]any{},
+					"tools":       map[string]any{},
+					"resources":   map[string]any{},
+					"prompts":     map[string]any{},
+					"logging":     map[string]any{},
+					"completions": map[string]any{},
 				},
 				"serverInfo": map[string]any{
 					"name":    "atlassian-mcp",
@@ -34,7 +60,7 @@ func HandleRequest(req types.Request) types.Response {
 		return types.Response{
 			JSONRPC: "2.0",
 			ID:      req.ID,
-			Result:  handleToolsList(),
+			Result:  handleToolsList(ctx),
 		}
 
 	case "tools/call":
@@ -46,12 +72,65 @@ func HandleRequest(req types.Request) types.Response {
 				Error:   &types.Error{Code: -32602, Message: "Invalid params"},
 			}
 		}
+		if params.Meta != nil {
+			ctx = session.WithProgressToken(ctx, params.Meta.ProgressToken)
+		}
 		return types.Response{
 			JSONRPC: "2.0",
 			ID:      req.ID,
-			Result:  handleToolCall(params),
+			Result:  handleToolCall(ctx, params),
 		}
 
+	case "logging/setLevel":
+		var params types.SetLevelParams
+		level, ok := slog.Level(0), false
+		if err := json.Unmarshal(req.Params, &params); err == nil {
+			level, ok = logging.ParseMCPLevel(params.Level)
+		}
+		if !ok {
+			return types.Response{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &types.Error{Code: -32602, Message: "Invalid params: unknown log level " + strconv.Quote(params.Level)},
+			}
+		}
+		session.FromContext(ctx).SetLogLevel(level)
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  map[string]any{},
+		}
+
+	case "resources/list":
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  handleResourcesList(),
+		}
+
+	case "resources/templates/list":
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  handleResourceTemplatesList(),
+		}
+
+	case "resources/read":
+		return handleResourcesRead(ctx, req)
+
+	case "prompts/list":
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  handlePromptsList(),
+		}
+
+	case "prompts/get":
+		return handlePromptsGet(ctx, req)
+
+	case "completion/complete":
+		return handleComplete(ctx, req)
+
 	default:
 		return types.Response{
 			JSONRPC: "2.0",
@@ -61,206 +140,202 @@ func HandleRequest(req types.Request) types.Response {
 	}
 }
 
-// handleToolsList returns the list of available tools.
-func handleToolsList() any {
-	return map[string]any{
-		"tools": []types.Tool{
-			{
-				Name:        "atlassian_read",
-				Description: "Read from Jira/Confluence. Verbs: jira_get_issue, jira_get_comments, jira_search, confluence_get_page, confluence_get_comments, confluence_search, get_format, search_users. IMPORTANT: Call with param=\"help\" first to learn verb usage.",
-				InputSchema: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"verb": map[string]any{
-							"type":        "string",
-							"description": "Operation: jira_get_issue, jira_get_comments, jira_search, confluence_get_page, confluence_get_comments, confluence_search, get_format, search_users",
-						},
-						"param": map[string]any{
-							"type":        "string",
-							"description": "Issue key/URL, page ID/URL, query, or \"help\" for usage",
-						},
-					},
-					"required": []string{"verb", "param"},
-				},
-			},
-			{
-				Name:        "atlassian_write",
-				Description: "Write to Jira/Confluence. Verbs: jira_add_comment, jira_update_issue, jira_create_issue, confluence_add_comment, confluence_update_page, confluence_create_page. IMPORTANT: Call with param=\"help\" first to learn verb usage.",
-				InputSchema: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"verb": map[string]any{
-							"type":        "string",
-							"description": "Operation: jira_add_comment, jira_update_issue, jira_create_issue, confluence_add_comment, confluence_update_page, confluence_create_page",
-						},
-						"param": map[string]any{
-							"type":        "string",
-							"description": "JSON params or \"help\" for usage",
-						},
-					},
-					"required": []string{"verb", "param"},
-				},
-			},
-		},
+// handleToolsList returns the tools exposed under the current configuration.
+// Output schemas are only advertised to clients that negotiated structured
+// content.
+func handleToolsList(ctx context.Context) any {
+	structured := session.FromContext(ctx).SupportsStructuredContent()
+
+	tools := []types.Tool{}
+	for _, d := range activeTools() {
+		tool := d.definition()
+		if !structured {
+			tool.OutputSchema = nil
+		}
+		tools = append(tools, tool)
 	}
+	return map[string]any{"tools": tools}
+}
+
+// checksumsSchema describes the checksum map returned by get and update verbs.
+var checksumsSchema = map[string]any{
+	"type":                 "object",
+	"description":          "Field checksums to pass back when updating",
+	"additionalProperties": map[string]any{"type": "string"},
+}
+
+// readOutputSchema describes the structuredContent of atlassian_read results.
+// Only verb is always present; the rest depends on the verb.
+var readOutputSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"verb":       map[string]any{"type": "string"},
+		"site":       map[string]any{"type": "string", "description": "Site the issue or page was read from"},
+		"issue":      map[string]any{"type": []string{"object", "string"}, "description": "Parsed issue fields (jira_get_issue) or issue key"},
+		"page":       map[string]any{"type": "object", "description": "Page metadata (confluence_get_page)"},
+		"pageId":     map[string]any{"type": "string"},
+		"checksums":  checksumsSchema,
+		"comments":   map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+		"results":    map[string]any{"type": "array", "items": map[string]any{"type": "object"}, "description": "Search hits"},
+		"total":      map[string]any{"type": "integer"},
+		"nextCursor": map[string]any{"type": "string", "description": "Pass as cursor to fetch the next results of a list verb"},
+		"users":      map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+		"entries":    map[string]any{"type": "array", "items": map[string]any{"type": "object"}, "description": "Per-entry results of batch in request order, or audit records of audit_log"},
+	},
+	"required": []string{"verb"},
+}
+
+// writeOutputSchema describes the structuredContent of atlassian_write results.
+var writeOutputSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"verb":      map[string]any{"type": "string"},
+		"issue":     map[string]any{"type": "string", "description": "Key of the issue written to"},
+		"pageId":    map[string]any{"type": "string"},
+		"title":     map[string]any{"type": "string"},
+		"commentId": map[string]any{"type": "string"},
+		"checksums": checksumsSchema,
+		"dryRun":    map[string]any{"type": "boolean", "description": "Set when nothing was written"},
+		"method":    map[string]any{"type": "string", "description": "HTTP method a dry run would use"},
+		"endpoint":  map[string]any{"type": "string", "description": "REST endpoint a dry run would call"},
+		"payload":   map[string]any{"type": "object", "description": "Request body a dry run would send"},
+		"changes":   map[string]any{"type": "array", "items": map[string]any{"type": "object"}, "description": "Per-field before, after and diff of a dry run"},
+		"media":     map[string]any{"type": "array", "items": map[string]any{"type": "object"}, "description": "Attachments a dry run would upload"},
+	},
+	"required": []string{"verb"},
 }
 
 // handleToolCall dispatches tool calls to appropriate handlers.
-func handleToolCall(params types.ToolCallParams) any {
+func handleToolCall(ctx context.Context, params types.ToolCallParams) any {
 	var args types.VerbArgs
 	if err := json.Unmarshal(params.Arguments, &args); err != nil {
 		return errorResult("Invalid arguments: must provide verb and param")
 	}
 
-	switch params.Name {
-	case "atlassian_read":
-		return handleAtlassianRead(args)
-	case "atlassian_write":
-		return handleAtlassianWrite(args)
-	default:
+	tool, ok := findTool(params.Name)
+	if !ok {
 		return errorResult("Unknown tool: " + params.Name)
 	}
-}
 
-// handleAtlassianRead routes read operations to the appropriate service.
-func handleAtlassianRead(args types.VerbArgs) any {
-	// Help handling - show all available verbs
-	if args.Param == "help" {
-		return handleReadHelp(args.Verb)
-	}
+	ctx = logging.NewContext(ctx, "tool", tool.name, "verb", args.Verb)
+	start := time.Now()
+	result := handleVerbCall(ctx, tool, args)
+	logToolCall(ctx, result, time.Since(start))
 
-	// Handle unified verbs (no service prefix needed)
-	if args.Verb == "get_format" {
-		return successResult(types.FormatDocumentation)
-	}
-	if args.Verb == "search_users" {
-		result, err := users.SearchUsers(args.Param)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
-	}
+	return withStructuredContent(ctx, result, args.Verb)
+}
 
-	// Parse service prefix from verb (e.g., "jira_get_issue" -> "jira", "get_issue")
-	service, operation := parseVerb(args.Verb)
+// logToolCall records the outcome of a tool call.
+func logToolCall(ctx context.Context, result any, elapsed time.Duration) {
+	logger := logging.FromContext(ctx)
+	attrs := []slog.Attr{slog.Int64("latency_ms", elapsed.Milliseconds())}
 
-	switch service {
-	case "jira":
-		return handleJiraRead(operation, args.Param)
-	case "confluence":
-		return handleConfluenceRead(operation, args.Param)
-	default:
-		return errorResult("Unknown service prefix in verb: " + args.Verb + ". Use jira_ or confluence_ prefix, or use get_format.")
+	res, _ := result.(map[string]any)
+	if res["isError"] != true {
+		logger.LogAttrs(ctx, slog.LevelInfo, "tool call succeeded", attrs...)
+		return
 	}
+	if content, ok := res["content"].([]types.TextContent); ok && len(content) > 0 {
+		attrs = append(attrs, slog.String("error", content[0].Text))
+	}
+	logger.LogAttrs(ctx, slog.LevelWarn, "tool call failed", attrs...)
 }
 
-// handleAtlassianWrite routes write operations to the appropriate service.
-func handleAtlassianWrite(args types.VerbArgs) any {
-	// Help handling - show all available verbs
-	if args.Param == "help" {
-		return handleWriteHelp(args.Verb)
+// withStructuredContent finalizes the structuredContent of a tool result.
+// Successful results always carry the verb so they satisfy the output schema
+// even when the verb has no structured data (help, get_format). Clients on
+// older protocol revisions get the text content only.
+func withStructuredContent(ctx context.Context, res any, verb string) any {
+	result, ok := res.(map[string]any)
+	if !ok {
+		return res
 	}
-
-	// Parse service prefix from verb
-	service, operation := parseVerb(args.Verb)
-
-	switch service {
-	case "jira":
-		return handleJiraWrite(operation, args.Param)
-	case "confluence":
-		return handleConfluenceWrite(operation, args.Param)
-	default:
-		return errorResult("Unknown service prefix in verb: " + args.Verb + ". Use jira_ or confluence_ prefix.")
+	if !session.FromContext(ctx).SupportsStructuredContent() {
+		delete(result, "structuredContent")
+		return result
+	}
+	if result["isError"] == true {
+		return result
 	}
-}
 
-// parseVerb splits "jira_get_issue" into ("jira", "get_issue")
-func parseVerb(verb string) (service, operation string) {
-	parts := strings.SplitN(verb, "_", 2)
-	if len(parts) != 2 {
-		return "", verb
+	data := map[string]any{"verb": verb}
+	if extra, ok := result["structuredContent"].(map[string]any); ok {
+		for k, v := range extra {
+			data[k] = v
+		}
 	}
-	return parts[0], parts[1]
+	result["structuredContent"] = data
+	return result
 }
 
-// handleReadHelp returns help for read verbs.
-func handleReadHelp(verb string) any {
-	// If a specific verb is given, show its help
-	if verb != "" {
-		// Handle unified verbs
-		if verb == "get_format" {
-			return successResult("Get extended markdown format documentation. Param: ignored\n\nReturns full syntax reference for the extended markdown format used by this MCP.")
-		}
-		if verb == "search_users" {
-			return successResult(types.SearchUsersHelp)
+// handleVerbCall validates the verb against the tool and dispatches it through
+// the registry.
+func handleVerbCall(ctx context.Context, tool toolDef, args types.VerbArgs) any {
+	if args.Verb != "" {
+		if err := tool.checkVerb(args.Verb); err != nil {
+			return errorResult(err.Error())
 		}
+	}
 
-		service, operation := parseVerb(verb)
-		switch service {
-		case "jira":
-			if help, ok := types.JiraReadVerbHelp[operation]; ok {
-				return successResult(help)
-			}
-		case "confluence":
-			if help, ok := types.ConfluenceReadVerbHelp[operation]; ok {
-				return successResult(help)
-			}
+	// Help handling - show all available verbs
+	if args.Param == "help" {
+		if v, ok := findVerb(args.Verb); ok {
+			return successResult(v.help())
 		}
+		return successResult(verbListing("Available "+tool.kind+" verbs", tool.verbs()))
 	}
 
-	// Show all available read verbs
-	var sb strings.Builder
-	sb.WriteString("Available read verbs:\n\n")
-
-	sb.WriteString("**Jira:**\n")
-	for v := range types.JiraReadVerbHelp {
-		sb.WriteString("- jira_" + v + "\n")
+	v, ok := findVerb(args.Verb)
+	if !ok {
+		return errorResult("Unknown verb: " + args.Verb + ". Valid: " + strings.Join(tool.verbs(), ", "))
 	}
-
-	sb.WriteString("\n**Confluence:**\n")
-	for v := range types.ConfluenceReadVerbHelp {
-		sb.WriteString("- confluence_" + v + "\n")
+	ctx, err := withSite(ctx, v, args.Site, args.Param)
+	if err != nil {
+		return errorResult(err.Error())
 	}
+	if v.kind == kindWrite {
+		ctx = confirm.NewContext(ctx, v.name, confirmed(args.Param))
+		return auditedCall(ctx, tool, v, args.Param)
+	}
+	return v.call(withTool(ctx, tool), args.Param)
+}
 
-	sb.WriteString("\n**Shared:**\n")
-	sb.WriteString("- get_format\n")
-	sb.WriteString("- search_users\n")
-
-	return successResult(sb.String())
+// confirmed reports whether a write param carries "confirm": true.
+func confirmed(param string) bool {
+	var p struct {
+		Confirm bool `json:"confirm"`
+	}
+	return json.Unmarshal([]byte(param), &p) == nil && p.Confirm
 }
 
-// handleWriteHelp returns help for write verbs.
-func handleWriteHelp(verb string) any {
-	// If a specific verb is given, show its help
-	if verb != "" {
-		service, operation := parseVerb(verb)
-		switch service {
-		case "jira":
-			if help, ok := types.JiraWriteVerbHelp[operation]; ok {
-				return successResult(help)
-			}
-		case "confluence":
-			if help, ok := types.ConfluenceWriteVerbHelp[operation]; ok {
-				return successResult(help)
+// verbListing renders verbs grouped by service, with their descriptions.
+func verbListing(title string, names []string) string {
+	groups := []struct {
+		service string
+		name    string
+		verbs   []verbDef
+	}{{"jira", "Jira", nil}, {"confluence", "Confluence", nil}, {"", "Shared", nil}}
+	for _, name := range names {
+		v, _ := findVerb(name)
+		for i := range groups {
+			if groups[i].service == v.service {
+				groups[i].verbs = append(groups[i].verbs, v)
 			}
 		}
 	}
 
-	// Show all available write verbs
 	var sb strings.Builder
-	sb.WriteString("Available write verbs:\n\n")
-
-	sb.WriteString("**Jira:**\n")
-	for v := range types.JiraWriteVerbHelp {
-		sb.WriteString("- jira_" + v + "\n")
-	}
-
-	sb.WriteString("\n**Confluence:**\n")
-	for v := range types.ConfluenceWriteVerbHelp {
-		sb.WriteString("- confluence_" + v + "\n")
+	sb.WriteString(title + ":\n")
+	for _, g := range groups {
+		if len(g.verbs) == 0 {
+			continue
+		}
+		sb.WriteString("\n**" + g.name + ":**\n")
+		for _, v := range g.verbs {
+			sb.WriteString("- " + v.name + ": " + v.description + "\n")
+		}
 	}
-
-	return successResult(sb.String())
+	return sb.String()
 }
 
 // successResult creates a successful tool result.
@@ -270,6 +345,16 @@ func successResult(text string) map[string]any {
 	}
 }
 
+// dataResult creates a successful tool result from an operation result,
+// keeping its structured data alongside the text.
+func dataResult(res types.Result) map[string]any {
+	result := successResult(res.Text)
+	if res.Data != nil {
+		result["structuredContent"] = res.Data
+	}
+	return result
+}
+
 // errorResult creates an error tool result.
 func errorResult(text string) map[string]any {
 	return map[string]any{
@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+// fakeJira serves the Jira endpoints used by the read verbs and rejects
+// requests without the test credentials.
+func fakeJira(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/3/issue/PROJ-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"key":"PROJ-1","fields":{"summary":"Fix login","status":{"name":"Open"}}}`))
+	})
+	mux.HandleFunc("GET /rest/api/3/issue/PROJ-404", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Arequestid", "req-1")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`))
+	})
+	mux.HandleFunc("GET /rest/api/3/issue/PROJ-403", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errorMessages":["You do not have permission to see this issue."]}`))
+	})
+	mux.HandleFunc("GET /rest/api/3/issue/PROJ-500", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("POST /rest/api/3/search/jql", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		_ = json.Unmarshal(body, &req)
+		if req["jql"] != "project = PROJ" || req["maxResults"] != float64(1) {
+			t.Errorf("search request = %s", body)
+		}
+		w.Write([]byte(`{"issues":[{"key":"PROJ-1","fields":{"summary":"Fix login"}}],"nextPageToken":"t2"}`))
+	})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "me@example.com" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestNew_ToolCall(t *testing.T) {
+	ts := fakeJira(t)
+	handle := New(&client.Client{
+		JiraURL:       ts.URL,
+		ConfluenceURL: ts.URL + "/wiki",
+		Auth:          client.BasicAuth{Email: "me@example.com", Token: "secret"},
+		HTTP:          ts.Client(),
+	})
+
+	tests := []struct {
+		name      string
+		verb      string
+		param     string
+		want      []string
+		wantError bool
+	}{
+		{"get issue", "jira_get_issue", "PROJ-1", []string{"# PROJ-1", "**Summary:** Fix login", "**Status:** Open"}, false},
+		{"search with limit", "jira_search", `{"jql":"project = PROJ","limit":1}`, []string{"PROJ-1", `"cursor": "t2"`}, false},
+		{"api error", "jira_get_issue", "PROJ-404", []string{"not found or no permission (HTTP 404): Issue does not exist", "[request ID req-1]"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(types.VerbArgs{Verb: tt.verb, Param: tt.param})
+			params, _ := json.Marshal(types.ToolCallParams{Name: "atlassian_read", Arguments: args})
+			ctx := session.NewContext(context.Background(), session.New(nil))
+
+			resp := handle(ctx, types.Request{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+			result, _ := resp.Result.(map[string]any)
+			content, _ := result["content"].([]types.TextContent)
+			if len(content) == 0 {
+				t.Fatalf("tools/call returned no content: %+v", resp)
+			}
+			if isError := result["isError"] == true; isError != tt.wantError {
+				t.Errorf("isError = %v, want %v: %s", isError, tt.wantError, content[0].Text)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content[0].Text, want) {
+					t.Errorf("result does not contain %q:\n%s", want, content[0].Text)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_DataCenter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/2/issue/PROJ-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"key":"PROJ-1","fields":{"summary":"Fix login","description":"h1. Steps\n*bold*","assignee":{"name":"jdoe","displayName":"Jane Doe"}}}`))
+	})
+	mux.HandleFunc("GET /rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		if jql := r.URL.Query().Get("jql"); jql != "project = PROJ" {
+			t.Errorf("search jql = %q", jql)
+		}
+		w.Write([]byte(`{"startAt":0,"maxResults":1,"total":1,"issues":[{"key":"PROJ-1","fields":{"summary":"Fix login"}}]}`))
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	c := client.NewDataCenter(ts.URL, "", client.BearerAuth{Token: "pat"})
+	c.HTTP = ts.Client()
+	handle := New(c)
+
+	tests := []struct {
+		name      string
+		verb      string
+		param     string
+		want      []string
+		wantError bool
+	}{
+		{"get issue", "jira_get_issue", "PROJ-1", []string{"# PROJ-1", "h1. Steps\n*bold*", "Jane Doe"}, false},
+		{"search", "jira_search", `{"jql":"project = PROJ","limit":1}`, []string{"PROJ-1", "Fix login"}, false},
+		{"confluence not configured", "confluence_get_page", "12345", []string{"Confluence is not configured"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(types.VerbArgs{Verb: tt.verb, Param: tt.param})
+			params, _ := json.Marshal(types.ToolCallParams{Name: "atlassian_read", Arguments: args})
+			ctx := session.NewContext(context.Background(), session.New(nil))
+
+			resp := handle(ctx, types.Request{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+			result, _ := resp.Result.(map[string]any)
+			content, _ := result["content"].([]types.TextContent)
+			if len(content) == 0 {
+				t.Fatalf("tools/call returned no content: %+v", resp)
+			}
+			if isError := result["isError"] == true; isError != tt.wantError {
+				t.Errorf("isError = %v, want %v: %s", isError, tt.wantError, content[0].Text)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content[0].Text, want) {
+					t.Errorf("result does not contain %q:\n%s", want, content[0].Text)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_Sites(t *testing.T) {
+	company := fakeJira(t)
+	customer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/3/issue/PROJ-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"key":"PROJ-1","fields":{"summary":"Customer issue"}}`))
+	}))
+	t.Cleanup(customer.Close)
+
+	prevProfiles := config.Profiles
+	t.Cleanup(func() { config.Profiles = prevProfiles })
+	config.Profiles = []config.Profile{
+		{Name: "company", JiraURL: company.URL},
+		{Name: "customer", JiraURL: customer.URL},
+	}
+
+	handle := New(
+		&client.Client{Site: "company", JiraURL: company.URL, Auth: client.BasicAuth{Email: "me@example.com", Token: "secret"}, HTTP: company.Client()},
+		&client.Client{Site: "customer", JiraURL: customer.URL, Auth: client.BasicAuth{}, HTTP: customer.Client()},
+	)
+
+	tests := []struct {
+		name      string
+		site      string
+		param     string
+		want      []string
+		wantError bool
+	}{
+		{"default site", "", "PROJ-1", []string{"**Site:** company", "Fix login"}, false},
+		{"named site", "customer", "PROJ-1", []string{"**Site:** customer", "Customer issue"}, false},
+		{"site from URL", "", customer.URL + "/browse/PROJ-1", []string{"**Site:** customer", "Customer issue"}, false},
+		{"unknown site", "other", "PROJ-1", []string{`Unknown site "other". Configured: company, customer`}, true},
+		{"URL of unknown site", "", "https://other.atlassian.net/browse/PROJ-1", []string{"No configured site serves https://other.atlassian.net/browse/PROJ-1", "company (127.0.0.1:", "customer (127.0.0.1:"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(types.VerbArgs{Verb: "jira_get_issue", Param: tt.param, Site: tt.site})
+			params, _ := json.Marshal(types.ToolCallParams{Name: "atlassian_read", Arguments: args})
+			ctx := session.NewContext(context.Background(), session.New(nil))
+
+			resp := handle(ctx, types.Request{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+			result, _ := resp.Result.(map[string]any)
+			content, _ := result["content"].([]types.TextContent)
+			if len(content) == 0 {
+				t.Fatalf("tools/call returned no content: %+v", resp)
+			}
+			if isError := result["isError"] == true; isError != tt.wantError {
+				t.Errorf("isError = %v, want %v: %s", isError, tt.wantError, content[0].Text)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content[0].Text, want) {
+					t.Errorf("result does not contain %q:\n%s", want, content[0].Text)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_SitesResourcesAndPrompts(t *testing.T) {
+	company := fakeJira(t)
+	customer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/3/issue/PROJ-1":
+			w.Write([]byte(`{"key":"PROJ-1","fields":{"summary":"Customer issue"}}`))
+		case "/rest/api/3/issue/PROJ-1/comment":
+			w.Write([]byte(`{"comments":[],"total":0}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(customer.Close)
+
+	prevProfiles := config.Profiles
+	t.Cleanup(func() { config.Profiles = prevProfiles })
+	config.Profiles = []config.Profile{
+		{Name: "company", JiraURL: company.URL},
+		{Name: "customer", JiraURL: customer.URL},
+	}
+
+	handle := New(
+		&client.Client{Site: "company", JiraURL: company.URL, Auth: client.BasicAuth{Email: "me@example.com", Token: "secret"}, HTTP: company.Client()},
+		&client.Client{Site: "customer", JiraURL: customer.URL, Auth: client.BasicAuth{}, HTTP: customer.Client()},
+	)
+	issueURL := customer.URL + "/browse/PROJ-1"
+
+	tests := []struct {
+		name   string
+		method string
+		params any
+		want   string
+	}{
+		{"resource on default site", "resources/read", types.ReadResourceParams{URI: "jira://issue/PROJ-1"}, "Fix login"},
+		{"resource from URL", "resources/read", types.ReadResourceParams{URI: "jira://issue/" + url.PathEscape(issueURL)}, "Customer issue"},
+		{"prompt from URL", "prompts/get", types.GetPromptParams{Name: "triage_issue", Arguments: map[string]string{"issue": issueURL}}, "Customer issue"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(tt.params)
+			ctx := session.NewContext(context.Background(), session.New(nil))
+
+			resp := handle(ctx, types.Request{JSONRPC: "2.0", ID: 1, Method: tt.method, Params: params})
+			if resp.Error != nil {
+				t.Fatalf("%s error = %s", tt.method, resp.Error.Message)
+			}
+			if got, _ := json.Marshal(resp.Result); !strings.Contains(string(got), tt.want) {
+				t.Errorf("%s result does not contain %q:\n%s", tt.method, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNew_ProtocolVersion(t *testing.T) {
+	ts := fakeJira(t)
+	handle := New(&client.Client{
+		JiraURL: ts.URL,
+		Auth:    client.BasicAuth{Email: "me@example.com", Token: "secret"},
+		HTTP:    ts.Client(),
+	})
+
+	tests := []struct {
+		requested  string
+		want       string
+		structured bool
+	}{
+		{"2025-06-18", "2025-06-18", true},
+		{"2025-03-26", "2025-03-26", false},
+		{"2024-11-05", "2024-11-05", false},
+		{"2099-01-01", "2025-06-18", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			ctx := session.NewContext(context.Background(), session.New(nil))
+			call := func(method string, params any) map[string]any {
+				t.Helper()
+				raw, _ := json.Marshal(params)
+				resp := handle(ctx, types.Request{JSONRPC: "2.0", ID: 1, Method: method, Params: raw})
+				if resp.Error != nil {
+					t.Fatalf("%s error = %s", method, resp.Error.Message)
+				}
+				result, _ := resp.Result.(map[string]any)
+				return result
+			}
+
+			result := call("initialize", types.InitializeParams{ProtocolVersion: tt.requested})
+			if got := result["protocolVersion"]; got != tt.want {
+				t.Errorf("negotiated protocolVersion = %v, want %s", got, tt.want)
+			}
+
+			for _, tool := range call("tools/list", nil)["tools"].([]types.Tool) {
+				if (tool.OutputSchema != nil) != tt.structured {
+					t.Errorf("tool %s outputSchema = %v, want present %v", tool.Name, tool.OutputSchema, tt.structured)
+				}
+			}
+
+			args, _ := json.Marshal(types.VerbArgs{Verb: "jira_get_issue", Param: "PROJ-1"})
+			result = call("tools/call", types.ToolCallParams{Name: "atlassian_read", Arguments: args})
+			data, ok := result["structuredContent"].(map[string]any)
+			if ok != tt.structured {
+				t.Fatalf("structuredContent = %v, want present %v", result["structuredContent"], tt.structured)
+			}
+			if tt.structured && (data["verb"] != "jira_get_issue" || data["checksums"] == nil) {
+				t.Errorf("structuredContent = %v, want the verb and checksums", data)
+			}
+		})
+	}
+}
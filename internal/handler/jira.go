@@ -1,95 +1,162 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 
+	"atlassian-mcp/internal/client"
 	"atlassian-mcp/internal/config"
 	"atlassian-mcp/internal/jira"
 	"atlassian-mcp/internal/types"
 )
 
-// handleJiraRead handles Jira read operations.
-func handleJiraRead(operation, param string) any {
-	switch operation {
-	case "get_issue":
-		issueKey, err := config.ExtractIssueKey(param)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		result, err := jira.FetchIssue(issueKey)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+// jiraVerbs declares the Jira verbs.
+var jiraVerbs = []verbDef{
+	{
+		name:        "jira_get_issue",
+		service:     "jira",
+		kind:        kindRead,
+		description: "Get issue details",
+		notes: `Returns: summary, status, type, priority, assignee, reporter, labels, components, parent, dates, description, subtasks, linked issues.
 
-	case "get_comments":
-		issueKey, err := config.ExtractIssueKey(param)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		result, err := jira.FetchComments(issueKey)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Roundtrip formats in output (copy into jira_add_comment/jira_update_issue):
+- Mentions: @[Name](accountId:xxx)
+- Media: ![alt](jira-media:id:collection:type)
 
-	case "search":
-		result, err := jira.SearchIssues(param)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Returns __CHECKSUMS__ section with SHA256 hashes for: summary, description, status, assignee, priority, labels, components. Required for jira_update_issue.`,
+		schema:   stringParam("issue key or URL"),
+		examples: []string{"PROJ-123"},
+		run: func(ctx context.Context, c *client.Client, param string) (types.Result, error) {
+			issueKey, err := config.ExtractIssueKey(param)
+			if err != nil {
+				return types.Result{}, err
+			}
+			return jira.FetchIssue(ctx, c, issueKey)
+		},
+	},
+	{
+		name:        "jira_get_comments",
+		service:     "jira",
+		kind:        kindRead,
+		description: "Get issue comments",
+		notes:       "Returns up to 50 comments (oldest first) with author, timestamp, and body in markdown. Pass limit for more, and cursor to continue after the last result.",
+		schema:      stringParam("issue key or URL"),
+		examples:    []string{"PROJ-123", `{"issue": "PROJ-123", "limit": 100}`},
+		listField:   "issue",
+		run: withPage(func(ctx context.Context, c *client.Client, param string, page client.PageRequest) (types.Result, error) {
+			issueKey, err := config.ExtractIssueKey(param)
+			if err != nil {
+				return types.Result{}, err
+			}
+			return jira.FetchComments(ctx, c, issueKey, page)
+		}),
+	},
+	{
+		name:        "jira_search",
+		service:     "jira",
+		kind:        kindRead,
+		description: "Search issues with JQL",
+		notes: `Returns up to 50 issues with: key, type, summary, status, assignee.
+Pass limit for more, and cursor to continue after the last result.
 
-	default:
-		return errorResult("Unknown Jira read operation: " + operation + ". Valid: get_issue, get_comments, search")
-	}
-}
+JQL Reference: https://support.atlassian.com/jira-software-cloud/docs/use-advanced-search-with-jira-query-language-jql/`,
+		schema:    stringParam("JQL query string"),
+		examples:  []string{"assignee=currentUser() AND status=Open", `{"jql": "project = PROJ", "cursor": "..."}`},
+		listField: "jql",
+		run:       withPage(jira.SearchIssues),
+	},
+	{
+		name:        "jira_add_comment",
+		service:     "jira",
+		kind:        kindWrite,
+		description: "Add comment to issue",
+		notes: `Body supports markdown:
+- Blocks: headings, code blocks (with lang), blockquotes, lists, tables, horizontal rules
+- Inline: **bold**, *italic*, ~~strike~~, ` + "`code`" + `, [link](url)
+- Mentions: @[Name](accountId:xxx) - use format from jira_get_issue output
+- Existing media: ![alt](jira-media:id:collection:type)
+
+Note: Image uploads not supported in comments. To add images, update the issue description.`,
+		schema: objectParam(map[string]any{
+			"issue":   field("string", "Issue key or URL"),
+			"body":    field("string", "Comment text in extended markdown"),
+			"dryRun":  dryRunField(),
+			"confirm": confirmField(),
+		}, "issue", "body"),
+		examples: []string{`{"issue": "PROJ-123", "body": "Comment text"}`},
+		run: withParams(func(ctx context.Context, c *client.Client, p types.JiraAddCommentParams) (types.Result, error) {
+			issueKey, err := config.ExtractIssueKey(p.Issue)
+			if err != nil {
+				return types.Result{}, err
+			}
+			return jira.AddComment(ctx, c, issueKey, p.Body, p.DryRun)
+		}),
+	},
+	{
+		name:        "jira_update_issue",
+		service:     "jira",
+		kind:        kindWrite,
+		description: "Update issue fields (requires checksums)",
+		notes: `Workflow:
+1. Call get_format to learn extended markdown syntax
+2. Call jira_get_issue to get current values and checksums
+3. Include checksum for each field you update
+4. If field changed since read, returns conflict error
+5. Optionally pass "dryRun": true first to preview the request and a diff
+6. Some changes need human confirmation (ATLASSIAN_CONFIRM_WRITES); if asked,
+   show the user the change and repeat with "confirm": true once they approve
 
-// handleJiraWrite handles Jira write operations.
-func handleJiraWrite(operation, param string) any {
-	switch operation {
-	case "add_comment":
-		var p types.JiraAddCommentParams
-		if err := json.Unmarshal([]byte(param), &p); err != nil {
-			return errorResult("Invalid JSON params: " + err.Error() + "\n\n" + types.JiraWriteVerbHelp["add_comment"])
-		}
-		issueKey, err := config.ExtractIssueKey(p.Issue)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		result, err := jira.AddComment(issueKey, p.Body)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Checksum fields: summary, description, status, assignee, priority, labels, components
 
-	case "update_issue":
-		var p types.JiraUpdateIssueParams
-		if err := json.Unmarshal([]byte(param), &p); err != nil {
-			return errorResult("Invalid JSON params: " + err.Error() + "\n\n" + types.JiraWriteVerbHelp["update_issue"])
-		}
-		issueKey, err := config.ExtractIssueKey(p.Issue)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		result, err := jira.UpdateIssue(issueKey, p.Fields, p.Checksums)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Image uploads supported:
+- New: ![alt](url) or ![alt](/path) - auto-uploaded as attachment (10MB limit)
+- Existing: ![alt](jira-media:id:collection:type) from jira_get_issue
 
-	case "create_issue":
-		var p types.JiraCreateIssueParams
-		if err := json.Unmarshal([]byte(param), &p); err != nil {
-			return errorResult("Invalid JSON params: " + err.Error() + "\n\n" + types.JiraWriteVerbHelp["create_issue"])
-		}
-		result, err := jira.CreateIssue(p.Project, p.IssueType, p.Summary, p.Description)
-		if err != nil {
-			return errorResult(err.Error())
-		}
-		return successResult(result)
+Returns fresh checksums on success.`,
+		schema: objectParam(map[string]any{
+			"issue":     field("string", "Issue key or URL"),
+			"fields":    field("object", "Fields to update, keyed by field name"),
+			"checksums": checksumsField("Checksums from jira_get_issue for each updated field"),
+			"dryRun":    dryRunField(),
+			"confirm":   confirmField(),
+		}, "issue", "fields", "checksums"),
+		examples: []string{
+			`{"issue": "PROJ-123", "fields": {"summary": "New title"}, "checksums": {"summary": "..."}}`,
+			`{"issue": "PROJ-123", "fields": {"description": "New details"}, "checksums": {"description": "..."}, "dryRun": true}`,
+		},
+		run: withParams(func(ctx context.Context, c *client.Client, p types.JiraUpdateIssueParams) (types.Result, error) {
+			issueKey, err := config.ExtractIssueKey(p.Issue)
+			if err != nil {
+				return types.Result{}, err
+			}
+			return jira.UpdateIssue(ctx, c, issueKey, p.Fields, p.Checksums, p.DryRun)
+		}),
+	},
+	{
+		name:        "jira_create_issue",
+		service:     "jira",
+		kind:        kindWrite,
+		description: "Create new issue",
+		notes: `Workflow:
+1. Call get_format to learn extended markdown syntax
+2. Create issue with fields
 
-	default:
-		return errorResult("Unknown Jira write operation: " + operation + ". Valid: add_comment, update_issue, create_issue")
-	}
+To add images: create issue first, then use jira_update_issue with description containing ![alt](url).
+Returns created issue key.`,
+		schema: objectParam(map[string]any{
+			"project":     field("string", "Project key; omit to use the default project of the site"),
+			"issuetype":   field("string", "Issue type name, e.g. Task"),
+			"summary":     field("string", "Issue title"),
+			"description": field("string", "Details in extended markdown"),
+			"dryRun":      dryRunField(),
+			"confirm":     confirmField(),
+		}, "issuetype", "summary"),
+		examples: []string{`{"project": "PROJ", "issuetype": "Task", "summary": "Title", "description": "Details"}`},
+		run: withParams(func(ctx context.Context, c *client.Client, p types.JiraCreateIssueParams) (types.Result, error) {
+			project, err := siteDefault(c, "project", p.Project, func(p config.Profile) string { return p.Project })
+			if err != nil {
+				return types.Result{}, err
+			}
+			return jira.CreateIssue(ctx, c, project, p.IssueType, p.Summary, p.Description, p.DryRun)
+		}),
+	},
 }
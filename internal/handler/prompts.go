@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/confluence"
+	"atlassian-mcp/internal/jira"
+	"atlassian-mcp/internal/types"
+	"atlassian-mcp/internal/users"
+)
+
+// promptDef pairs a prompt definition with the function that pre-fetches its
+// content and renders the messages. A prompt is offered only while the verbs
+// it fetches with, or tells the agent to call, are enabled.
+type promptDef struct {
+	types.Prompt
+	enabled func() bool
+	build   func(ctx context.Context, args map[string]string) ([]types.PromptMessage, error)
+}
+
+// prompts lists the built-in prompt templates in display order.
+var prompts = []promptDef{
+	{
+		Prompt: types.Prompt{
+			Name:        "triage_issue",
+			Description: "Triage a Jira issue: classify it, spot missing information, and propose next steps",
+			Arguments: []types.PromptArgument{
+				{Name: "issue", Description: "Issue key or URL (e.g., PROJ-123)", Required: true},
+			},
+		},
+		// Triage is useful without write access; the instructions then
+		// leave updates to the user
+		enabled: func() bool {
+			return verbsEnabled("jira_get_issue", "jira_get_comments")
+		},
+		build: buildTriageIssue,
+	},
+	{
+		Prompt: types.Prompt{
+			Name:        "design_doc_from_epic",
+			Description: "Draft a Confluence design doc from a Jira epic and its child issues",
+			Arguments: []types.PromptArgument{
+				{Name: "epic", Description: "Epic key or URL", Required: true},
+				{Name: "spaceId", Description: "Confluence space ID to create the page in"},
+				{Name: "parentId", Description: "Parent page ID for the new page"},
+			},
+		},
+		enabled: func() bool {
+			return verbsEnabled("jira_get_issue", "jira_search", "get_format", "confluence_create_page")
+		},
+		build: buildDesignDocFromEpic,
+	},
+	{
+		Prompt: types.Prompt{
+			Name:        "summarize_new_comments",
+			Description: "Summarize comments on an issue or page posted since your last reply",
+			Arguments: []types.PromptArgument{
+				{Name: "target", Description: "Issue key/URL or page ID/URL", Required: true},
+			},
+		},
+		// Either product's comments verb will do; build checks the one the
+		// target needs.
+		enabled: func() bool {
+			return verbsEnabled("jira_get_comments") || verbsEnabled("confluence_get_comments")
+		},
+		build: buildSummarizeNewComments,
+	},
+	{
+		Prompt: types.Prompt{
+			Name:        "draft_issue",
+			Description: "Draft a Jira issue from a short idea and create it once approved",
+			Arguments: []types.PromptArgument{
+				{Name: "project", Description: "Project key (e.g., PROJ)", Required: true},
+				{Name: "summary", Description: "What the issue is about", Required: true},
+				{Name: "issuetype", Description: "Issue type name (default: Task)"},
+				{Name: "mention", Description: "Display name of someone to mention in the description"},
+			},
+		},
+		enabled: func() bool {
+			return verbsEnabled("get_format", "jira_create_issue")
+		},
+		build: buildDraftIssue,
+	},
+}
+
+// handlePromptsList returns the prompt templates whose verbs are enabled.
+func handlePromptsList() any {
+	list := make([]types.Prompt, 0, len(prompts))
+	for _, p := range prompts {
+		if p.enabled() {
+			list = append(list, p.Prompt)
+		}
+	}
+	return map[string]any{"prompts": list}
+}
+
+// handlePromptsGet renders a prompt with its arguments, pre-fetching content.
+func handlePromptsGet(ctx context.Context, req types.Request) types.Response {
+	var params types.GetPromptParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &types.Error{Code: -32602, Message: "Invalid params"},
+		}
+	}
+
+	def, ok := findPrompt(params.Name)
+	if !ok || !def.enabled() {
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &types.Error{Code: -32602, Message: "Unknown prompt: " + params.Name},
+		}
+	}
+
+	for _, arg := range def.Arguments {
+		if arg.Required && strings.TrimSpace(params.Arguments[arg.Name]) == "" {
+			return types.Response{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &types.Error{Code: -32602, Message: "Missing required argument: " + arg.Name},
+			}
+		}
+	}
+
+	messages, err := def.build(ctx, params.Arguments)
+	if err != nil {
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &types.Error{Code: -32603, Message: err.Error()},
+		}
+	}
+
+	return types.Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]any{
+			"description": def.Description,
+			"messages":    messages,
+		},
+	}
+}
+
+func findPrompt(name string) (promptDef, bool) {
+	for _, p := range prompts {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return promptDef{}, false
+}
+
+// resourceMessage wraps fetched content as an embedded resource message.
+func resourceMessage(uri, text string) types.PromptMessage {
+	return types.PromptMessage{
+		Role: "user",
+		Content: types.EmbeddedResource{
+			Type:     "resource",
+			Resource: types.ResourceContents{URI: uri, MimeType: markdownMimeType, Text: text},
+		},
+	}
+}
+
+// textMessage wraps instructions as a text message.
+func textMessage(text string) types.PromptMessage {
+	return types.PromptMessage{
+		Role:    "user",
+		Content: types.TextContent{Type: "text", Text: text},
+	}
+}
+
+func buildTriageIssue(ctx context.Context, args map[string]string) ([]types.PromptMessage, error) {
+	issueKey, err := config.ExtractIssueKey(args["issue"])
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = selectSite(ctx, "", siteURL(args["issue"]))
+	if err != nil {
+		return nil, err
+	}
+	c := client.FromContext(ctx)
+	issue, err := jira.FetchIssue(ctx, c, issueKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", issueKey, err)
+	}
+	comments, err := jira.FetchComments(ctx, c, issueKey, client.PageRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments for %s: %w", issueKey, err)
+	}
+
+	instructions := fmt.Sprintf(`Triage Jira issue %s using the issue details and comments above.
+
+1. Classify it: bug, feature request, task, or question. Say if the issue type is wrong.
+2. Assess severity and recommend a priority, with a one-line justification.
+3. List information that is missing for someone to start work (repro steps, expected vs actual, acceptance criteria, environment).
+4. Flag duplicates or related issues worth linking, if any are evident.
+5. Propose concrete next steps and who should own them.
+
+`, issueKey)
+	if verbsEnabled("jira_update_issue") {
+		instructions += fmt.Sprintf("Do not change the issue yet. Present the triage first; if I approve, apply updates with %s jira_update_issue using the checksums from the issue above.", toolFor("jira_update_issue"))
+	} else {
+		instructions += "Updating issues is not available on this server, so present the triage and list the changes for me to apply."
+	}
+
+	return []types.PromptMessage{
+		resourceMessage("jira://issue/"+issueKey, issue.Text),
+		resourceMessage("jira://issue/"+issueKey+"/comments", comments.Text),
+		textMessage(instructions),
+	}, nil
+}
+
+func buildDesignDocFromEpic(ctx context.Context, args map[string]string) ([]types.PromptMessage, error) {
+	epicKey, err := config.ExtractIssueKey(args["epic"])
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = selectSite(ctx, "", siteURL(args["epic"]))
+	if err != nil {
+		return nil, err
+	}
+	c := client.FromContext(ctx)
+	epic, err := jira.FetchIssue(ctx, c, epicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", epicKey, err)
+	}
+	children, err := jira.SearchIssues(ctx, c, fmt.Sprintf("parent = %s ORDER BY created ASC", epicKey), client.PageRequest{Limit: client.MaxPageLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch child issues of %s: %w", epicKey, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`Write a Confluence design document for epic %s using the epic and its child issues above.
+
+Structure:
+1. Overview - the problem and why it matters now
+2. Goals and non-goals
+3. Background and current state
+4. Proposed design - components, data flow, APIs, and key decisions with alternatives considered
+5. Work breakdown - map each child issue to the part of the design it delivers
+6. Risks and mitigations
+7. Open questions
+
+Call %s get_format first and use the extended markdown format (panels for callouts, tables for the work breakdown).
+`, epicKey, toolFor("get_format")))
+
+	spaceID := strings.TrimSpace(args["spaceId"])
+	parentID := strings.TrimSpace(args["parentId"])
+	switch {
+	case spaceID != "" && parentID != "":
+		sb.WriteString(fmt.Sprintf("\nShow me the draft, then create it with %s confluence_create_page using spaceId %q and parentId %q.", toolFor("confluence_create_page"), spaceID, parentID))
+	case spaceID != "":
+		sb.WriteString(fmt.Sprintf("\nShow me the draft, then create it with %s confluence_create_page using spaceId %q.", toolFor("confluence_create_page"), spaceID))
+	default:
+		sb.WriteString("\nShow me the draft and ask which Confluence space to create it in before calling confluence_create_page.")
+	}
+
+	return []types.PromptMessage{
+		resourceMessage("jira://issue/"+epicKey, epic.Text),
+		textMessage("Child issues of " + epicKey + ":\n\n" + children.Text),
+		textMessage(sb.String()),
+	}, nil
+}
+
+func buildSummarizeNewComments(ctx context.Context, args map[string]string) ([]types.PromptMessage, error) {
+	target := args["target"]
+
+	ctx, err := selectSite(ctx, "", siteURL(target))
+	if err != nil {
+		return nil, err
+	}
+	c := client.FromContext(ctx)
+	name, accountID, err := users.CurrentUser(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify current user: %w", err)
+	}
+
+	var uri string
+	var comments types.Result
+	if issueKey, keyErr := config.ExtractIssueKey(target); keyErr == nil {
+		if err := requireVerbs("jira_get_comments"); err != nil {
+			return nil, err
+		}
+		uri = "jira://issue/" + issueKey + "/comments"
+		comments, err = jira.FetchComments(ctx, c, issueKey, client.PageRequest{})
+	} else if pageID, idErr := config.ExtractPageID(target); idErr == nil {
+		if err := requireVerbs("confluence_get_comments"); err != nil {
+			return nil, err
+		}
+		uri = "confluence://page/" + pageID + "/comments"
+		comments, err = confluence.GetComments(ctx, c, pageID, client.PageRequest{})
+	} else {
+		return nil, fmt.Errorf("invalid target: must be an issue key/URL or page ID/URL")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+
+	instructions := fmt.Sprintf(`I am %s {user:%s}.
+
+In the comments above, find my most recent comment (its header contains {user:%s}) and summarize only the comments posted after it. If I have not commented, summarize all of them.
+
+- Group the summary by author, oldest first.
+- Call out questions addressed to me and anything that needs my decision.
+- List action items with their owners.
+- Keep it short; quote only when exact wording matters.`, name, accountID, accountID)
+
+	return []types.PromptMessage{
+		resourceMessage(uri, comments.Text),
+		textMessage(instructions),
+	}, nil
+}
+
+func buildDraftIssue(ctx context.Context, args map[string]string) ([]types.PromptMessage, error) {
+	project := strings.ToUpper(strings.TrimSpace(args["project"]))
+	issueType := strings.TrimSpace(args["issuetype"])
+	if issueType == "" {
+		issueType = "Task"
+	}
+
+	issueTypes, err := cachedLookup(ctx, "issuetypes:"+project, func(ctx context.Context, c *client.Client) ([]string, error) {
+		return jira.ListIssueTypes(ctx, c, project)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(issueTypes, func(name string) bool { return strings.EqualFold(name, issueType) }) {
+		return nil, fmt.Errorf("issue type %q is not available in %s (valid: %s)", issueType, project, strings.Join(issueTypes, ", "))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`Draft a Jira %s for project %s about:
+
+%s
+
+Write a concise summary line and a description with context, the problem or goal, and acceptance criteria as a task list. Call %s get_format first and use the extended markdown format.
+`, issueType, project, strings.TrimSpace(args["summary"]), toolFor("get_format")))
+
+	if mention := strings.TrimSpace(args["mention"]); mention != "" && verbsEnabled("search_users") {
+		sb.WriteString(fmt.Sprintf("\nMention %s in the description. Look up their mention format with %s search_users.\n", mention, toolFor("search_users")))
+	}
+
+	sb.WriteString(fmt.Sprintf("\nShow me the draft, then create it with %s jira_create_issue using project %q and issuetype %q.", toolFor("jira_create_issue"), project, issueType))
+
+	return []types.PromptMessage{textMessage(sb.String())}, nil
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/types"
+)
+
+// promptsClient returns a client for a fake Jira serving what the prompts
+// pre-fetch.
+func promptsClient(t *testing.T) *client.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/3/issue/PRMT-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"key":"PRMT-1","fields":{"summary":"Checkout epic"}}`))
+	})
+	mux.HandleFunc("GET /rest/api/3/issue/PRMT-1/comment", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"startAt":0,"maxResults":50,"total":0,"comments":[]}`))
+	})
+	mux.HandleFunc("POST /rest/api/3/search/jql", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"issues":[{"key":"PRMT-2","fields":{"summary":"Child task"}}]}`))
+	})
+	mux.HandleFunc("GET /rest/api/3/myself", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"accountId":"5b10ac8d82e05b22cc7d4ef5","displayName":"Ada Lovelace"}`))
+	})
+	mux.HandleFunc("GET /rest/api/3/project/PRMT", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"issueTypes":[{"name":"Task"},{"name":"Bug"},{"name":"Sub-task","subtask":true}]}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return &client.Client{JiraURL: ts.URL, Auth: client.BasicAuth{}, HTTP: ts.Client()}
+}
+
+func TestHandlePromptsList(t *testing.T) {
+	list := handlePromptsList().(map[string]any)["prompts"].([]types.Prompt)
+	if len(list) != len(prompts) {
+		t.Fatalf("prompts = %d, want %d", len(list), len(prompts))
+	}
+	for _, p := range list {
+		if p.Description == "" || len(p.Arguments) == 0 {
+			t.Errorf("prompt %s has no description or arguments", p.Name)
+		}
+	}
+}
+
+func TestHandlePromptsGet_TriageReadOnly(t *testing.T) {
+	setToolConfig(t, config.LayoutCombined, true, nil, nil)
+	ctx := client.NewContext(context.Background(), promptsClient(t))
+
+	params, _ := json.Marshal(types.GetPromptParams{Name: "triage_issue", Arguments: map[string]string{"issue": "PRMT-1"}})
+	resp := handlePromptsGet(ctx, types.Request{JSONRPC: "2.0", ID: 1, Params: params})
+	if resp.Error != nil {
+		t.Fatalf("prompts/get error = %s", resp.Error.Message)
+	}
+	got, _ := json.Marshal(resp.Result)
+	if !strings.Contains(string(got), "Updating issues is not available") || strings.Contains(string(got), "jira_update_issue") {
+		t.Errorf("prompts/get result should leave updates to the user:\n%s", got)
+	}
+}
+
+func TestHandlePromptsGet(t *testing.T) {
+	ctx := client.NewContext(context.Background(), promptsClient(t))
+
+	tests := []struct {
+		name    string
+		prompt  string
+		args    map[string]string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "triage",
+			prompt: "triage_issue",
+			args:   map[string]string{"issue": "PRMT-1"},
+			want:   []string{"jira://issue/PRMT-1", "Checkout epic", "Triage Jira issue PRMT-1", "jira_update_issue"},
+		},
+		{
+			name:   "design doc with space",
+			prompt: "design_doc_from_epic",
+			args:   map[string]string{"epic": "PRMT-1", "spaceId": "65538"},
+			want:   []string{"Child task", `spaceId \"65538\"`},
+		},
+		{
+			name:   "summarize issue comments",
+			prompt: "summarize_new_comments",
+			args:   map[string]string{"target": "PRMT-1"},
+			want:   []string{"jira://issue/PRMT-1/comments", "I am Ada Lovelace {user:5b10ac8d82e05b22cc7d4ef5}"},
+		},
+		{
+			name:   "draft issue",
+			prompt: "draft_issue",
+			args:   map[string]string{"project": "prmt", "summary": "Retry payments", "issuetype": "bug", "mention": "Grace"},
+			want:   []string{"Draft a Jira bug for project PRMT", "Retry payments", "Mention Grace", `issuetype \"bug\"`},
+		},
+		{
+			name:    "unknown prompt",
+			prompt:  "write_novel",
+			wantErr: "Unknown prompt: write_novel",
+		},
+		{
+			name:    "missing argument",
+			prompt:  "triage_issue",
+			args:    map[string]string{"issue": " "},
+			wantErr: "Missing required argument: issue",
+		},
+		{
+			name:    "invalid target",
+			prompt:  "summarize_new_comments",
+			args:    map[string]string{"target": "not a target"},
+			wantErr: "invalid target",
+		},
+		{
+			name:    "unavailable issue type",
+			prompt:  "draft_issue",
+			args:    map[string]string{"project": "PRMT", "summary": "x", "issuetype": "Sub-task"},
+			wantErr: `issue type "Sub-task" is not available in PRMT (valid: Task, Bug)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(types.GetPromptParams{Name: tt.prompt, Arguments: tt.args})
+			resp := handlePromptsGet(ctx, types.Request{JSONRPC: "2.0", ID: 1, Params: params})
+			if tt.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Message, tt.wantErr) {
+					t.Errorf("prompts/get error = %+v, want %q", resp.Error, tt.wantErr)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("prompts/get error = %s", resp.Error.Message)
+			}
+			got, _ := json.Marshal(resp.Result)
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("prompts/get result does not contain %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
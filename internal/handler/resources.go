@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/confluence"
+	"atlassian-mcp/internal/jira"
+	"atlassian-mcp/internal/types"
+)
+
+// JSON-RPC error code for unknown resources, as defined by the MCP spec.
+const errCodeResourceNotFound = -32002
+
+const (
+	formatResourceURI = "atlassian://format"
+	markdownMimeType  = "text/markdown"
+)
+
+// resourceTemplate is a parameterized resource, available while the verb
+// that fetches the same content is enabled.
+type resourceTemplate struct {
+	types.ResourceTemplate
+	verb string
+}
+
+// resourceTemplates lists the parameterized resources clients can read.
+var resourceTemplates = []resourceTemplate{
+	{types.ResourceTemplate{
+		URITemplate: "jira://issue/{key}",
+		Name:        "Jira issue",
+		Description: "Issue details as extended markdown with __CHECKSUMS__ block (same as jira_get_issue)",
+		MimeType:    markdownMimeType,
+	}, "jira_get_issue"},
+	{types.ResourceTemplate{
+		URITemplate: "jira://issue/{key}/comments",
+		Name:        "Jira issue comments",
+		Description: "Issue comments as extended markdown (same as jira_get_comments)",
+		MimeType:    markdownMimeType,
+	}, "jira_get_comments"},
+	{types.ResourceTemplate{
+		URITemplate: "confluence://page/{id}",
+		Name:        "Confluence page",
+		Description: "Page content as extended markdown with __CHECKSUMS__ block (same as confluence_get_page)",
+		MimeType:    markdownMimeType,
+	}, "confluence_get_page"},
+	{types.ResourceTemplate{
+		URITemplate: "confluence://page/{id}/comments",
+		Name:        "Confluence page comments",
+		Description: "Page comments as extended markdown (same as confluence_get_comments)",
+		MimeType:    markdownMimeType,
+	}, "confluence_get_comments"},
+}
+
+// handleResourcesList returns the static resources. Issues and pages are
+// exposed through templates since they cannot be enumerated.
+func handleResourcesList() any {
+	resources := []types.Resource{}
+	if verbsEnabled("get_format") {
+		resources = append(resources, types.Resource{
+			URI:         formatResourceURI,
+			Name:        "Extended markdown format reference",
+			Description: "Syntax accepted and produced by all Jira and Confluence verbs",
+			MimeType:    markdownMimeType,
+		})
+	}
+	return map[string]any{"resources": resources}
+}
+
+// handleResourceTemplatesList returns the templates of enabled resources.
+func handleResourceTemplatesList() any {
+	templates := []types.ResourceTemplate{}
+	for _, t := range resourceTemplates {
+		if verbsEnabled(t.verb) {
+			templates = append(templates, t.ResourceTemplate)
+		}
+	}
+	return map[string]any{"resourceTemplates": templates}
+}
+
+// handleResourcesRead resolves a resource URI and fetches its contents.
+func handleResourcesRead(ctx context.Context, req types.Request) types.Response {
+	var params types.ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &types.Error{Code: -32602, Message: "Invalid params: uri is required"},
+		}
+	}
+
+	text, err := readResource(ctx, params.URI)
+	if err != nil {
+		return types.Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &types.Error{Code: resourceErrorCode(err), Message: err.Error()},
+		}
+	}
+
+	return types.Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]any{
+			"contents": []types.ResourceContents{
+				{URI: params.URI, MimeType: markdownMimeType, Text: text},
+			},
+		},
+	}
+}
+
+// resourceErrorCode returns the JSON-RPC error code for a failed read. Issues
+// and pages the API doesn't find are not found like unknown URIs; denied
+// access is the client's problem rather than a server fault.
+func resourceErrorCode(err error) int {
+	if _, ok := err.(resourceNotFoundError); ok {
+		return errCodeResourceNotFound
+	}
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusNotFound:
+			return errCodeResourceNotFound
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return -32602
+		}
+	}
+	return -32603
+}
+
+// resourceNotFoundError signals a URI that matches no resource or template.
+type resourceNotFoundError string
+
+func (e resourceNotFoundError) Error() string {
+	return "Resource not found: " + string(e)
+}
+
+// readResource dispatches a resource URI to the matching fetch function,
+// unless the verb that fetches the same content is disabled.
+func readResource(ctx context.Context, uri string) (string, error) {
+	if uri == formatResourceURI {
+		if err := requireVerbs("get_format"); err != nil {
+			return "", err
+		}
+		return types.FormatDocumentation, nil
+	}
+
+	scheme, path, ok := strings.Cut(uri, "://")
+	if !ok {
+		return "", resourceNotFoundError(uri)
+	}
+	parts := strings.Split(path, "/")
+	// An issue or page URL in place of the key or ID is percent-encoded
+	// into one segment, and selects the site serving it
+	var target string
+	if len(parts) >= 2 {
+		target, _ = url.PathUnescape(parts[1])
+	}
+	ctx, err := selectSite(ctx, "", siteURL(target))
+	if err != nil {
+		return "", err
+	}
+	c := client.FromContext(ctx)
+
+	switch {
+	case scheme == "jira" && len(parts) >= 2 && parts[0] == "issue":
+		issueKey, err := config.ExtractIssueKey(target)
+		if err != nil {
+			return "", resourceNotFoundError(uri)
+		}
+		switch {
+		case len(parts) == 2:
+			if err := requireVerbs("jira_get_issue"); err != nil {
+				return "", err
+			}
+			return resultText(jira.FetchIssue(ctx, c, issueKey))
+		case len(parts) == 3 && parts[2] == "comments":
+			if err := requireVerbs("jira_get_comments"); err != nil {
+				return "", err
+			}
+			return resultText(jira.FetchComments(ctx, c, issueKey, client.PageRequest{}))
+		}
+
+	case scheme == "confluence" && len(parts) >= 2 && parts[0] == "page":
+		pageID, err := config.ExtractPageID(target)
+		if err != nil {
+			return "", resourceNotFoundError(uri)
+		}
+		switch {
+		case len(parts) == 2:
+			if err := requireVerbs("confluence_get_page"); err != nil {
+				return "", err
+			}
+			return resultText(confluence.GetPage(ctx, c, pageID))
+		case len(parts) == 3 && parts[2] == "comments":
+			if err := requireVerbs("confluence_get_comments"); err != nil {
+				return "", err
+			}
+			return resultText(confluence.GetComments(ctx, c, pageID, client.PageRequest{}))
+		}
+	}
+
+	return "", resourceNotFoundError(fmt.Sprintf("%s (supported: jira://issue/{key}, confluence://page/{id})", uri))
+}
+
+// resultText unwraps the markdown of a fetch result.
+func resultText(res types.Result, err error) (string, error) {
+	return res.Text, err
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/types"
+)
+
+func TestHandleResourcesList(t *testing.T) {
+	resources := handleResourcesList().(map[string]any)["resources"].([]types.Resource)
+	if len(resources) != 1 || resources[0].URI != formatResourceURI || resources[0].MimeType != markdownMimeType {
+		t.Errorf("resources = %+v, want the format reference", resources)
+	}
+
+	templates := handleResourceTemplatesList().(map[string]any)["resourceTemplates"].([]types.ResourceTemplate)
+	if len(templates) != len(resourceTemplates) {
+		t.Errorf("resource templates = %d, want %d", len(templates), len(resourceTemplates))
+	}
+	for _, rt := range templates {
+		if rt.Name == "" || rt.MimeType != markdownMimeType {
+			t.Errorf("resource template %s has no name or the wrong MIME type", rt.URITemplate)
+		}
+	}
+}
+
+func TestHandleResourcesRead(t *testing.T) {
+	ts := fakeJira(t)
+	c := &client.Client{
+		JiraURL: ts.URL,
+		Auth:    client.BasicAuth{Email: "me@example.com", Token: "secret"},
+		HTTP:    ts.Client(),
+	}
+	ctx := client.NewContext(context.Background(), c)
+
+	tests := []struct {
+		name     string
+		params   string
+		want     string
+		wantCode int
+	}{
+		{"format reference", `{"uri":"atlassian://format"}`, "Extended Markdown", 0},
+		{"issue", `{"uri":"jira://issue/PROJ-1"}`, "**Summary:** Fix login", 0},
+		{"missing uri", `{}`, "uri is required", -32602},
+		{"unknown scheme", `{"uri":"gitlab://issue/1"}`, "Resource not found", errCodeResourceNotFound},
+		{"invalid issue key", `{"uri":"jira://issue/not-a-key"}`, "Resource not found", errCodeResourceNotFound},
+		{"unknown subresource", `{"uri":"jira://issue/PROJ-1/history"}`, "Resource not found", errCodeResourceNotFound},
+		{"issue not found", `{"uri":"jira://issue/PROJ-404"}`, "Issue does not exist", errCodeResourceNotFound},
+		{"access denied", `{"uri":"jira://issue/PROJ-403"}`, "You do not have permission", -32602},
+		{"server fault", `{"uri":"jira://issue/PROJ-500"}`, "Jira", -32603},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := handleResourcesRead(ctx, types.Request{JSONRPC: "2.0", ID: 1, Params: json.RawMessage(tt.params)})
+			if tt.wantCode != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.wantCode || !strings.Contains(resp.Error.Message, tt.want) {
+					t.Errorf("resources/read error = %+v, want code %d containing %q", resp.Error, tt.wantCode, tt.want)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("resources/read error = %s", resp.Error.Message)
+			}
+			contents := resp.Result.(map[string]any)["contents"].([]types.ResourceContents)
+			if len(contents) != 1 || contents[0].MimeType != markdownMimeType || !strings.Contains(contents[0].Text, tt.want) {
+				t.Errorf("resources/read contents = %+v, want text containing %q", contents, tt.want)
+			}
+		})
+	}
+}
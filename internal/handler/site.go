@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/config"
+)
+
+type sitesKey struct{}
+
+// withSites records the clients of every configured site. The client
+// carried by ctx remains the one calls run against by default.
+func withSites(ctx context.Context, sites []*client.Client) context.Context {
+	return context.WithValue(ctx, sitesKey{}, sites)
+}
+
+// withSite selects the site a verb call runs against: the site named by the
+// call, else the site serving the issue or page URL in param, else the site
+// already carried by ctx.
+func withSite(ctx context.Context, v verbDef, site, param string) (context.Context, error) {
+	return selectSite(ctx, site, v.targetURL(param))
+}
+
+// selectSite selects site, else the site serving target, an issue or page
+// URL, else the site already carried by ctx. Resources and prompts, which
+// name no site, select one by the URL they are given. A URL that no
+// configured site serves is an error rather than a call to the default site.
+func selectSite(ctx context.Context, site, target string) (context.Context, error) {
+	sites, _ := ctx.Value(sitesKey{}).([]*client.Client)
+	if site == "" {
+		if target == "" {
+			return ctx, nil
+		}
+		p, ok := config.ProfileForURL(target)
+		if !ok {
+			return siteForHost(ctx, sites, target)
+		}
+		site = p.Name
+	}
+
+	var names []string
+	for _, c := range sites {
+		if c.Site == site {
+			return client.NewContext(ctx, c), nil
+		}
+		names = append(names, c.Site)
+	}
+	return ctx, fmt.Errorf("Unknown site %q. Configured: %s", site, strings.Join(names, ", "))
+}
+
+// siteForHost selects the site whose base URLs share the host of target, for
+// sites built without a profile.
+func siteForHost(ctx context.Context, sites []*client.Client, target string) (context.Context, error) {
+	if len(sites) == 0 {
+		if c := client.FromContext(ctx); c != nil {
+			sites = []*client.Client{c}
+		}
+	}
+	host := urlHost(target)
+
+	var configured []string
+	for _, c := range sites {
+		var hosts []string
+		for _, base := range []string{c.JiraURL, c.ConfluenceURL} {
+			if h := urlHost(base); h != "" && !slices.Contains(hosts, h) {
+				hosts = append(hosts, h)
+			}
+		}
+		if host != "" && slices.Contains(hosts, host) {
+			return client.NewContext(ctx, c), nil
+		}
+		if c.Site != "" {
+			configured = append(configured, c.Site+" ("+strings.Join(hosts, ", ")+")")
+		} else {
+			configured = append(configured, strings.Join(hosts, ", "))
+		}
+	}
+	return ctx, fmt.Errorf("No configured site serves %s. Configured: %s", target, strings.Join(configured, "; "))
+}
+
+// urlHost returns the lowercased host, with any port, of rawURL.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// targetURL returns the issue or page URL that param refers to, if any.
+// Other URLs, such as links in a body, never select a site.
+func (v verbDef) targetURL(param string) string {
+	target := param
+	if v.takesJSON() || v.isListObject(param) {
+		var obj map[string]any
+		_ = json.Unmarshal([]byte(param), &obj)
+		target = ""
+		for _, name := range []string{v.listField, "issue", "pageId"} {
+			if s, ok := obj[name].(string); ok && name != "" {
+				target = s
+				break
+			}
+		}
+	}
+	return siteURL(target)
+}
+
+// siteURL returns s if it is a URL, else "".
+func siteURL(s string) string {
+	if s = strings.TrimSpace(s); strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://") {
+		return s
+	}
+	return ""
+}
+
+// siteDefault returns value, or else the default the site of c configures
+// for the named param.
+func siteDefault(c *client.Client, name, value string, get func(config.Profile) string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if p, ok := config.FindProfile(c.Site); ok && get(p) != "" {
+		return get(p), nil
+	}
+	if c.Site == "" {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	return "", fmt.Errorf("%s is required: site %s has no default", name, c.Site)
+}
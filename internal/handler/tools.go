@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/types"
+)
+
+// Verb kinds. Each tool accepts verbs of exactly one kind.
+const (
+	kindRead  = "read"
+	kindWrite = "write"
+)
+
+// toolDef describes an exposed tool: the kind of verbs it accepts and, in the
+// per-service layout, the service it is scoped to.
+type toolDef struct {
+	name    string
+	kind    string
+	service string // "jira", "confluence", or "" for both
+}
+
+var (
+	combinedTools = []toolDef{
+		{name: "atlassian_read", kind: kindRead},
+		{name: "atlassian_write", kind: kindWrite},
+	}
+	perServiceTools = []toolDef{
+		{name: "jira_read", kind: kindRead, service: "jira"},
+		{name: "jira_write", kind: kindWrite, service: "jira"},
+		{name: "confluence_read", kind: kindRead, service: "confluence"},
+		{name: "confluence_write", kind: kindWrite, service: "confluence"},
+	}
+)
+
+// activeTools returns the tools exposed under the current configuration.
+// Tools left without any enabled verb are hidden.
+func activeTools() []toolDef {
+	defs := combinedTools
+	if config.ToolLayout == config.LayoutPerService {
+		defs = perServiceTools
+	}
+
+	var active []toolDef
+	for _, d := range defs {
+		if len(d.verbs()) > 0 {
+			active = append(active, d)
+		}
+	}
+	return active
+}
+
+// findTool looks up an active tool by name.
+func findTool(name string) (toolDef, bool) {
+	for _, d := range activeTools() {
+		if d.name == name {
+			return d, true
+		}
+	}
+	return toolDef{}, false
+}
+
+// toolFor returns the name of the active tool that accepts verb, so prompts
+// can reference the right tool under any layout.
+func toolFor(verb string) string {
+	for _, d := range activeTools() {
+		if slices.Contains(d.verbs(), verb) {
+			return d.name
+		}
+	}
+	v, _ := findVerb(verb)
+	if v.kind == kindWrite {
+		return "atlassian_write"
+	}
+	return "atlassian_read"
+}
+
+// verbs lists the enabled verbs the tool accepts, in display order.
+func (d toolDef) verbs() []string {
+	var verbs []string
+	for _, v := range registry {
+		if d.accepts(v) && verbEnabled(v) {
+			verbs = append(verbs, v.name)
+		}
+	}
+	return verbs
+}
+
+// accepts reports whether verb matches the tool's kind and service. Shared
+// verbs belong to every tool of their kind.
+func (d toolDef) accepts(v verbDef) bool {
+	if v.kind != d.kind {
+		return false
+	}
+	return d.service == "" || v.service == "" || v.service == d.service
+}
+
+// checkVerb rejects verbs the tool does not offer, either because they belong
+// to another tool or because configuration disables them.
+func (d toolDef) checkVerb(verb string) error {
+	verbs := d.verbs()
+	if slices.Contains(verbs, verb) {
+		return nil
+	}
+	return fmt.Errorf("Verb %s is not available in %s. Valid: %s", verb, d.name, strings.Join(verbs, ", "))
+}
+
+// label names the products the tool covers.
+func (d toolDef) label() string {
+	switch d.service {
+	case "jira":
+		return "Jira"
+	case "confluence":
+		return "Confluence"
+	default:
+		return "Jira/Confluence"
+	}
+}
+
+// definition builds the MCP tool definition advertised in tools/list.
+func (d toolDef) definition() types.Tool {
+	verbs := strings.Join(d.verbs(), ", ")
+
+	description := "Read from " + d.label()
+	paramDescription := "Issue key/URL, page ID/URL, query, or \"help\" for usage"
+	outputSchema := readOutputSchema
+	if d.kind == kindWrite {
+		description = "Write to " + d.label()
+		paramDescription = "JSON params or \"help\" for usage"
+		outputSchema = writeOutputSchema
+	}
+
+	properties := map[string]any{
+		"verb": map[string]any{
+			"type":        "string",
+			"description": "Operation: " + verbs,
+		},
+		"param": map[string]any{
+			"type":        "string",
+			"description": paramDescription,
+		},
+	}
+	if len(config.Profiles) > 1 {
+		properties["site"] = siteProperty()
+	}
+
+	return types.Tool{
+		Name:        d.name,
+		Description: description + ". Verbs: " + verbs + ". IMPORTANT: Call with param=\"help\" first to learn verb usage.",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   []string{"verb", "param"},
+		},
+		OutputSchema: outputSchema,
+	}
+}
+
+// siteProperty declares the site argument offered when several sites are
+// configured.
+func siteProperty() map[string]any {
+	var names []string
+	for _, p := range config.Profiles {
+		names = append(names, p.Name)
+	}
+	return map[string]any{
+		"type":        "string",
+		"enum":        names,
+		"description": "Site to call, default " + names[0] + ". Issue and page URLs select their site automatically",
+	}
+}
+
+// requireVerbs rejects resources and prompts that do the work of a verb
+// configuration disables, so they cannot bypass the verb allow/deny lists.
+func requireVerbs(names ...string) error {
+	for _, name := range names {
+		v, ok := findVerb(name)
+		if !ok || !verbEnabled(v) {
+			return fmt.Errorf("Verb %s is not available on this server", name)
+		}
+	}
+	return nil
+}
+
+// verbsEnabled reports whether every named verb is enabled.
+func verbsEnabled(names ...string) bool {
+	return requireVerbs(names...) == nil
+}
+
+// verbEnabled applies read-only mode and the verb allowlist/denylist.
+func verbEnabled(v verbDef) bool {
+	if v.kind == kindWrite && config.ReadOnly {
+		return false
+	}
+	return config.VerbAllowed(v.name)
+}
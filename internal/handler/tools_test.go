@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/types"
+)
+
+// setToolConfig overrides the tool exposure settings for one test. Tests
+// using it cannot run in parallel because the settings are package globals.
+func setToolConfig(t *testing.T, layout string, readOnly bool, allowed, denied []string) {
+	t.Helper()
+	prevLayout, prevReadOnly := config.ToolLayout, config.ReadOnly
+	prevAllowed, prevDenied := config.AllowedVerbs, config.DeniedVerbs
+	t.Cleanup(func() {
+		config.ToolLayout, config.ReadOnly = prevLayout, prevReadOnly
+		config.AllowedVerbs, config.DeniedVerbs = prevAllowed, prevDenied
+	})
+	config.ToolLayout, config.ReadOnly = layout, readOnly
+	config.AllowedVerbs, config.DeniedVerbs = allowed, denied
+}
+
+func TestActiveTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   string
+		readOnly bool
+		allowed  []string
+		denied   []string
+		want     []string
+	}{
+		{"combined", config.LayoutCombined, false, nil, nil, []string{"atlassian_read", "atlassian_write"}},
+		{"combined read-only", config.LayoutCombined, true, nil, nil, []string{"atlassian_read"}},
+		{"per-service", config.LayoutPerService, false, nil, nil, []string{"jira_read", "jira_write", "confluence_read", "confluence_write"}},
+		{"per-service read-only", config.LayoutPerService, true, nil, nil, []string{"jira_read", "confluence_read"}},
+		{"deny confluence writes", config.LayoutPerService, false, nil, []string{"confluence_*"}, []string{"jira_read", "jira_write", "confluence_read"}},
+		{"allow jira only", config.LayoutPerService, false, []string{"jira_*"}, nil, []string{"jira_read", "jira_write"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setToolConfig(t, tt.layout, tt.readOnly, tt.allowed, tt.denied)
+			var got []string
+			for _, d := range activeTools() {
+				got = append(got, d.name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("activeTools() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckVerb(t *testing.T) {
+	setToolConfig(t, config.LayoutPerService, false, nil, []string{"jira_create_issue"})
+	tests := []struct {
+		tool    string
+		verb    string
+		wantErr bool
+	}{
+		{"jira_read", "jira_get_issue", false},
+		{"jira_read", "get_format", false},
+		{"jira_read", "confluence_get_page", true},
+		{"jira_write", "jira_update_issue", false},
+		{"jira_write", "jira_create_issue", true},
+		{"jira_write", "jira_get_issue", true},
+		{"confluence_write", "get_format", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool+"/"+tt.verb, func(t *testing.T) {
+			d, ok := findTool(tt.tool)
+			if !ok {
+				t.Fatalf("tool %s not found", tt.tool)
+			}
+			if err := d.checkVerb(tt.verb); (err != nil) != tt.wantErr {
+				t.Errorf("checkVerb(%q) error = %v, wantErr %v", tt.verb, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerbGating_ResourcesAndPrompts(t *testing.T) {
+	tests := []struct {
+		name          string
+		readOnly      bool
+		denied        []string
+		wantTemplates []string
+		wantPrompts   []string
+	}{
+		{
+			name:          "all enabled",
+			wantTemplates: []string{"jira://issue/{key}", "jira://issue/{key}/comments", "confluence://page/{id}", "confluence://page/{id}/comments"},
+			wantPrompts:   []string{"triage_issue", "design_doc_from_epic", "summarize_new_comments", "draft_issue"},
+		},
+		{
+			name:          "read-only",
+			readOnly:      true,
+			wantTemplates: []string{"jira://issue/{key}", "jira://issue/{key}/comments", "confluence://page/{id}", "confluence://page/{id}/comments"},
+			wantPrompts:   []string{"triage_issue", "summarize_new_comments"},
+		},
+		{
+			name:          "deny jira",
+			denied:        []string{"jira_*"},
+			wantTemplates: []string{"confluence://page/{id}", "confluence://page/{id}/comments"},
+			wantPrompts:   []string{"summarize_new_comments"},
+		},
+		{
+			name:          "deny comments",
+			denied:        []string{"*_get_comments"},
+			wantTemplates: []string{"jira://issue/{key}", "confluence://page/{id}"},
+			wantPrompts:   []string{"design_doc_from_epic", "draft_issue"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setToolConfig(t, config.LayoutCombined, tt.readOnly, nil, tt.denied)
+
+			var templates []string
+			for _, rt := range handleResourceTemplatesList().(map[string]any)["resourceTemplates"].([]types.ResourceTemplate) {
+				templates = append(templates, rt.URITemplate)
+			}
+			if !slices.Equal(templates, tt.wantTemplates) {
+				t.Errorf("resource templates = %v, want %v", templates, tt.wantTemplates)
+			}
+
+			var names []string
+			for _, p := range handlePromptsList().(map[string]any)["prompts"].([]types.Prompt) {
+				names = append(names, p.Name)
+			}
+			if !slices.Equal(names, tt.wantPrompts) {
+				t.Errorf("prompts = %v, want %v", names, tt.wantPrompts)
+			}
+		})
+	}
+}
+
+func TestVerbGating_Read(t *testing.T) {
+	setToolConfig(t, config.LayoutCombined, false, nil, []string{"get_format", "jira_get_issue"})
+	ctx := context.Background()
+
+	if resources := handleResourcesList().(map[string]any)["resources"].([]types.Resource); len(resources) != 0 {
+		t.Errorf("resources = %v, want none with get_format denied", resources)
+	}
+	for _, uri := range []string{formatResourceURI, "jira://issue/PROJ-1"} {
+		resp := handleResourcesRead(ctx, types.Request{ID: 1, Params: json.RawMessage(`{"uri":"` + uri + `"}`)})
+		if resp.Error == nil || !strings.Contains(resp.Error.Message, "not available") {
+			t.Errorf("resources/read %s = %+v, want a disabled verb error", uri, resp)
+		}
+	}
+
+	resp := handlePromptsGet(ctx, types.Request{ID: 1, Params: json.RawMessage(`{"name":"triage_issue","arguments":{"issue":"PROJ-1"}}`)})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "Unknown prompt") {
+		t.Errorf("prompts/get triage_issue = %+v, want unknown prompt", resp)
+	}
+}
@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/schema"
+	"atlassian-mcp/internal/types"
+	"atlassian-mcp/internal/users"
+)
+
+// verbDef declares one verb. The registry is the single source for dispatch,
+// help output, tool descriptions and parameter validation.
+type verbDef struct {
+	name        string // full verb name, e.g. "jira_get_issue"
+	service     string // "jira", "confluence", or "" for shared verbs
+	kind        string // kindRead or kindWrite
+	description string // one line, shown in verb listings
+	notes       string // usage notes appended to the generated help
+
+	// schema describes param. Verbs with an object or array schema take
+	// param as a JSON string; all others take it verbatim.
+	schema   map[string]any
+	examples []string
+	run      func(ctx context.Context, c *client.Client, param string) (types.Result, error)
+
+	// listField marks a list verb. Its plain param may also be given as a
+	// JSON object holding it under this name, with limit and cursor.
+	listField string
+}
+
+// registry lists every verb in display order.
+var registry = slices.Concat(jiraVerbs, confluenceVerbs, sharedVerbs)
+
+// sharedVerbs are not tied to a service and are offered by every read tool.
+var sharedVerbs = []verbDef{
+	{
+		name:        "get_format",
+		kind:        kindRead,
+		description: "Get extended markdown format documentation",
+		notes:       "Returns full syntax reference for the extended markdown format used by this MCP.",
+		schema:      map[string]any{"type": "string", "description": "ignored"},
+		run: func(context.Context, *client.Client, string) (types.Result, error) {
+			return types.Result{Text: types.FormatDocumentation}, nil
+		},
+	},
+	{
+		name:        "search_users",
+		kind:        kindRead,
+		description: "Search for users by name or email",
+		notes: `Returns matching users with:
+- Display name
+- Account ID
+- Ready-to-use mention format: @[Name](accountId:xxx)
+
+Use the mention format in comments, descriptions, or page content.`,
+		schema:   stringParam("search query"),
+		examples: []string{"John", "john@example.com"},
+		run:      users.SearchUsers,
+	},
+	auditLogVerb,
+}
+
+// findVerb looks up a verb by name.
+func findVerb(name string) (verbDef, bool) {
+	for _, v := range registry {
+		if v.name == name {
+			return v, true
+		}
+	}
+	return verbDef{}, false
+}
+
+// stringParam declares a required plain-string param.
+func stringParam(description string) map[string]any {
+	return map[string]any{"type": "string", "minLength": 1, "description": description}
+}
+
+// objectParam declares a JSON object param. Fields not in properties are
+// rejected so typos surface instead of being silently ignored.
+func objectParam(properties map[string]any, required ...string) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// field declares an object property.
+func field(typ, description string) map[string]any {
+	return map[string]any{"type": typ, "description": description}
+}
+
+// dryRunField declares the dryRun property of write verbs.
+func dryRunField() map[string]any {
+	return field("boolean", "Validate and return the request and a diff without writing")
+}
+
+// confirmField declares the confirm property of write verbs.
+func confirmField() map[string]any {
+	return field("boolean", "Set once the user has approved a change that needs confirmation")
+}
+
+// listSchema describes the object form of a list verb's param.
+func (v verbDef) listSchema() map[string]any {
+	return objectParam(map[string]any{
+		v.listField: v.schema,
+		"limit": map[string]any{
+			"type":        "integer",
+			"minimum":     1,
+			"maximum":     client.MaxPageLimit,
+			"description": fmt.Sprintf("Maximum results, 1-%d (default %d)", client.MaxPageLimit, client.DefaultPageLimit),
+		},
+		"cursor": field("string", "nextCursor of a previous result, to continue after it"),
+	}, v.listField)
+}
+
+// isListObject reports whether param is the object form of a list verb's
+// param. Plain params (keys, IDs, JQL, CQL) never start with a brace.
+func (v verbDef) isListObject(param string) bool {
+	return v.listField != "" && strings.HasPrefix(strings.TrimSpace(param), "{")
+}
+
+// withPage adapts a list handler. param is either the plain value or, after
+// validation against listSchema, an object of that value, limit and cursor.
+func withPage(fn func(ctx context.Context, c *client.Client, param string, page client.PageRequest) (types.Result, error)) func(context.Context, *client.Client, string) (types.Result, error) {
+	return func(ctx context.Context, c *client.Client, param string) (types.Result, error) {
+		if !strings.HasPrefix(strings.TrimSpace(param), "{") {
+			return fn(ctx, c, param, client.PageRequest{})
+		}
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(param), &obj); err != nil {
+			return types.Result{}, fmt.Errorf("Invalid JSON params: %w", err)
+		}
+
+		var page client.PageRequest
+		if limit, ok := obj["limit"].(float64); ok {
+			page.Limit = int(limit)
+		}
+		page.Cursor, _ = obj["cursor"].(string)
+		delete(obj, "limit")
+		delete(obj, "cursor")
+
+		// The list field is the only one left
+		var value string
+		for _, v := range obj {
+			value, _ = v.(string)
+		}
+		return fn(ctx, c, value, page)
+	}
+}
+
+// checksumsField declares the checksums property of update verbs.
+func checksumsField(description string) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"description":          description,
+		"additionalProperties": map[string]any{"type": "string"},
+	}
+}
+
+// withParams adapts a handler taking a decoded parameter struct.
+func withParams[P any](fn func(ctx context.Context, c *client.Client, p P) (types.Result, error)) func(context.Context, *client.Client, string) (types.Result, error) {
+	return func(ctx context.Context, c *client.Client, param string) (types.Result, error) {
+		var p P
+		if err := json.Unmarshal([]byte(param), &p); err != nil {
+			return types.Result{}, fmt.Errorf("Invalid JSON params: %w", err)
+		}
+		return fn(ctx, c, p)
+	}
+}
+
+// takesJSON reports whether param is a JSON-encoded object or array.
+func (v verbDef) takesJSON() bool {
+	return v.schema["type"] == "object" || v.schema["type"] == "array"
+}
+
+// validate checks param against the verb's schema before dispatch.
+func (v verbDef) validate(param string) error {
+	s := v.schema
+	switch {
+	case v.isListObject(param):
+		s = v.listSchema()
+	case !v.takesJSON():
+		return schema.Validate(v.schema, param)
+	}
+	var value any
+	if err := json.Unmarshal([]byte(param), &value); err != nil {
+		return fmt.Errorf("Invalid JSON params: %w", err)
+	}
+	if err := schema.Validate(s, value); err != nil {
+		return fmt.Errorf("Invalid params: %w", err)
+	}
+	return nil
+}
+
+// call validates param and runs the verb with the client carried by ctx.
+func (v verbDef) call(ctx context.Context, param string) any {
+	if err := v.validate(param); err != nil {
+		return errorResult(err.Error() + "\n\n" + v.help())
+	}
+	result, err := v.run(ctx, client.FromContext(ctx), param)
+	if err != nil {
+		return errorResult(err.Error())
+	}
+	return dataResult(result)
+}
+
+type toolKey struct{}
+
+// withTool records the tool a verb was called through, so verbs that
+// dispatch other verbs can apply the same restrictions.
+func withTool(ctx context.Context, tool toolDef) context.Context {
+	return context.WithValue(ctx, toolKey{}, tool)
+}
+
+// toolFromContext returns the tool recorded by withTool.
+func toolFromContext(ctx context.Context) (toolDef, bool) {
+	tool, ok := ctx.Value(toolKey{}).(toolDef)
+	return tool, ok
+}
+
+// help renders the verb's usage from its declaration.
+func (v verbDef) help() string {
+	var sb strings.Builder
+	sb.WriteString(v.name + ": " + v.description + ".\n\n")
+
+	switch v.schema["type"] {
+	case "object":
+		sb.WriteString("Param: JSON object\n")
+		writeFields(&sb, v.schema)
+	case "array":
+		items, _ := v.schema["items"].(map[string]any)
+		sb.WriteString("Param: JSON array of objects, each with:\n")
+		writeFields(&sb, items)
+	default:
+		sb.WriteString(fmt.Sprintf("Param: %s\n", v.schema["description"]))
+		if v.listField != "" {
+			sb.WriteString("Or a JSON object, to page through results:\n")
+			writeFields(&sb, v.listSchema())
+		}
+	}
+
+	for _, example := range v.examples {
+		sb.WriteString("Example: " + example + "\n")
+	}
+
+	if v.notes != "" {
+		sb.WriteString("\n" + v.notes)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writeFields documents the properties of an object schema, one per line.
+func writeFields(sb *strings.Builder, s map[string]any) {
+	props, _ := s["properties"].(map[string]any)
+	required, _ := s["required"].([]string)
+	for _, name := range orderedFields(props, required) {
+		prop, _ := props[name].(map[string]any)
+		flag := "optional"
+		if slices.Contains(required, name) {
+			flag = "required"
+		}
+		sb.WriteString(fmt.Sprintf("- %s (%s, %s): %s\n", name, prop["type"], flag, prop["description"]))
+	}
+}
+
+// orderedFields lists required fields first, then optional ones, each
+// alphabetically.
+func orderedFields(props map[string]any, required []string) []string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := slices.Contains(required, names[i]), slices.Contains(required, names[j])
+		if ri != rj {
+			return ri
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	seen := map[string]bool{}
+	for _, v := range registry {
+		if seen[v.name] {
+			t.Errorf("duplicate verb %s", v.name)
+		}
+		seen[v.name] = true
+
+		if v.kind != kindRead && v.kind != kindWrite {
+			t.Errorf("%s: invalid kind %q", v.name, v.kind)
+		}
+		if v.service != "" && !strings.HasPrefix(v.name, v.service+"_") {
+			t.Errorf("%s: name does not match service %q", v.name, v.service)
+		}
+		if v.description == "" || v.schema == nil || v.run == nil {
+			t.Errorf("%s: description, schema and run are required", v.name)
+		}
+		for _, example := range v.examples {
+			if err := v.validate(example); err != nil {
+				t.Errorf("%s: example %s fails validation: %v", v.name, example, err)
+			}
+		}
+	}
+}
+
+func TestVerbValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		verb    string
+		param   string
+		wantErr string
+	}{
+		{"jira_get_issue", "PROJ-1", ""},
+		{"jira_get_issue", "", "param: must not be empty"},
+		{"get_format", "", ""},
+		{"jira_add_comment", `{"issue":"PROJ-1","body":"hi"}`, ""},
+		{"jira_add_comment", `{"issue":"PROJ-1"}`, "body: missing required field"},
+		{"jira_add_comment", `{"issue":"PROJ-1","body":"hi","bdy":"x"}`, "bdy: unknown field"},
+		{"jira_add_comment", `not json`, "Invalid JSON params"},
+		{"confluence_update_page", `{"pageId":"1","checksums":{"title":1}}`, "checksums.title: expected string"},
+		{"jira_search", "project = PROJ", ""},
+		{"jira_search", `{"jql":"project = PROJ","limit":100,"cursor":"t2"}`, ""},
+		{"jira_search", `{"jql":"project = PROJ","limit":0}`, "limit: must be at least 1"},
+		{"confluence_search", `{"cql":"type=page","limit":501}`, "limit: must be at most 500"},
+		{"jira_get_comments", `{"limit":10}`, "issue: missing required field"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.verb+"/"+tt.param, func(t *testing.T) {
+			t.Parallel()
+			v, ok := findVerb(tt.verb)
+			if !ok {
+				t.Fatalf("verb %s not found", tt.verb)
+			}
+			err := v.validate(tt.param)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("validate() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerbHelp(t *testing.T) {
+	t.Parallel()
+	v, _ := findVerb("jira_create_issue")
+	help := v.help()
+	for _, want := range []string{
+		"jira_create_issue: Create new issue.",
+		"- issuetype (string, required): Issue type name",
+		"- description (string, optional):",
+		`Example: {"project": "PROJ"`,
+		"Returns created issue key.",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help missing %q:\n%s", want, help)
+		}
+	}
+}
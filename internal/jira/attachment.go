@@ -2,7 +2,7 @@ package jira
 
 import (
 	"bytes"
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,7 +14,8 @@ import (
 	"strings"
 
 	"atlassian-mcp/internal/client"
-	"atlassian-mcp/internal/config"
+	"atlassian-mcp/internal/preview"
+	"atlassian-mcp/internal/session"
 	"atlassian-mcp/internal/types"
 )
 
@@ -44,9 +45,8 @@ type pendingUpload struct {
 }
 
 // UploadAttachment uploads a file to a Jira issue and returns attachment info
-func UploadAttachment(issueKey string, fileData []byte, filename string) (*types.JiraAttachmentInfo, error) {
-	endpoint := fmt.Sprintf("/rest/api/3/issue/%s/attachments", issueKey)
-	reqURL := fmt.Sprintf("https://%s%s", config.Domain, endpoint)
+func UploadAttachment(ctx context.Context, c *client.Client, issueKey string, fileData []byte, filename string) (*types.JiraAttachmentInfo, error) {
+	reqURL := c.URL(client.Jira, fmt.Sprintf("%s/issue/%s/attachments", api(c), issueKey))
 
 	// Create multipart form
 	var buf bytes.Buffer
@@ -65,18 +65,16 @@ func UploadAttachment(issueKey string, fileData []byte, filename string) (*types
 		return nil, fmt.Errorf("failed to close multipart writer: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", reqURL, &buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, &buf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request")
 	}
 
-	auth := base64.StdEncoding.EncodeToString([]byte(config.Email + ":" + config.Token))
-	req.Header.Set("Authorization", "Basic "+auth)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("X-Atlassian-Token", "no-check") // Required for attachment uploads
 
-	resp, err := client.HTTPClient.Do(req)
+	resp, err := c.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Jira: %v", err)
 	}
@@ -88,7 +86,7 @@ func UploadAttachment(issueKey string, fileData []byte, filename string) (*types
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("attachment upload failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("attachment upload failed: %w", client.NewAPIError(client.Jira, resp, respBody))
 	}
 
 	// Response is an array of attachments
@@ -102,28 +100,20 @@ func UploadAttachment(issueKey string, fileData []byte, filename string) (*types
 	}
 
 	att := &attachments[0]
+	if c.DataCenter {
+		// Data Center embeds attachments by filename, not media ID
+		att.MediaID = att.ID
+		return att, nil
+	}
+
 	// Extract media ID from content URL
 	att.MediaID = extractMediaIDFromURL(att.Content)
 
 	// If no UUID found in URL, try to get it by following redirect
 	if att.MediaID == "" {
 		// Try HEAD request to get redirect URL
-		req, _ := http.NewRequest("HEAD", att.Content, nil)
-		auth := base64.StdEncoding.EncodeToString([]byte(config.Email + ":" + config.Token))
-		req.Header.Set("Authorization", "Basic "+auth)
-
-		// Use a client that doesn't follow redirects
-		noRedirectClient := &http.Client{
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
-		}
-		resp, err := noRedirectClient.Do(req)
-		if err == nil {
-			defer resp.Body.Close()
-			if location := resp.Header.Get("Location"); location != "" {
-				att.MediaID = extractMediaIDFromURL(location)
-			}
+		if location, err := c.Location(ctx, att.Content); err == nil && location != "" {
+			att.MediaID = extractMediaIDFromURL(location)
 		}
 	}
 
@@ -149,9 +139,9 @@ func extractMediaIDFromURL(contentURL string) string {
 
 // UploadPendingMedia walks the ADF tree, validates all pending media, and uploads them.
 // All files are validated before any uploads occur to prevent partial uploads.
-func UploadPendingMedia(issueKey string, adf map[string]any) error {
+func UploadPendingMedia(ctx context.Context, c *client.Client, issueKey string, adf map[string]any) error {
 	// Phase 1: Collect all pending uploads into memory
-	pending, err := collectPendingUploads(adf)
+	pending, err := collectPendingUploads(ctx, adf)
 	if err != nil {
 		return fmt.Errorf("failed to collect uploads: %w", err)
 	}
@@ -159,19 +149,27 @@ func UploadPendingMedia(issueKey string, adf map[string]any) error {
 		return nil
 	}
 
+	session.ExpectSteps(ctx, 1+len(pending))
+
 	// Phase 2: Validate all uploads
 	if err := validatePendingUploads(pending, maxJiraAttachmentSize); err != nil {
 		return err
 	}
+	session.Progress(ctx, fmt.Sprintf("Validated %d attachment(s)", len(pending)))
 
 	// Phase 3: Upload all files (only reached if validation passed)
-	for _, p := range pending {
-		attInfo, err := UploadAttachment(issueKey, p.data, p.filename)
+	for i, p := range pending {
+		attInfo, err := UploadAttachment(ctx, c, issueKey, p.data, p.filename)
 		if err != nil {
 			return fmt.Errorf("upload failed for %s: %w", p.source, err)
 		}
+		session.Progress(ctx, fmt.Sprintf("Uploaded %s (%d/%d)", p.filename, i+1, len(pending)))
 
-		// Update ADF node with real media ID
+		// Update ADF node with real media ID, and the filename that wiki
+		// markup embeds on Data Center
+		if c.DataCenter {
+			p.nodeAttrs["filename"] = attInfo.Filename
+		}
 		p.nodeAttrs["id"] = attInfo.MediaID
 		p.nodeAttrs["collection"] = "mediaServiceAttachments"
 		delete(p.nodeAttrs, "_source")
@@ -180,9 +178,31 @@ func UploadPendingMedia(issueKey string, adf map[string]any) error {
 	return nil
 }
 
+// PreviewPendingMedia fetches and validates the pending media in an ADF
+// document like UploadPendingMedia, but does not upload them.
+func PreviewPendingMedia(ctx context.Context, adf map[string]any) ([]preview.Media, error) {
+	pending, err := collectPendingUploads(ctx, adf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect uploads: %w", err)
+	}
+	if err := validatePendingUploads(pending, maxJiraAttachmentSize); err != nil {
+		return nil, err
+	}
+
+	media := make([]preview.Media, 0, len(pending))
+	for _, p := range pending {
+		media = append(media, preview.Media{Source: p.source, Filename: p.filename, Size: len(p.data)})
+	}
+	return media, nil
+}
+
 // downloadFile fetches a file from a URL and returns its contents
-func downloadFile(url string) ([]byte, string, error) {
-	resp, err := http.Get(url)
+func downloadFile(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create download request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to download file: %v", err)
 	}
@@ -267,7 +287,7 @@ func sanitizeFilename(name string) string {
 
 // collectPendingUploads walks the ADF tree and collects all pending media uploads.
 // It downloads URLs and reads local files into memory.
-func collectPendingUploads(adf map[string]any) ([]pendingUpload, error) {
+func collectPendingUploads(ctx context.Context, adf map[string]any) ([]pendingUpload, error) {
 	var uploads []pendingUpload
 
 	content, ok := adf["content"].([]any)
@@ -315,8 +335,10 @@ func collectPendingUploads(adf map[string]any) ([]pendingUpload, error) {
 			var filename string
 			var err error
 
+			session.ExpectSteps(ctx, 1)
+
 			if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-				fileData, filename, err = downloadFile(source)
+				fileData, filename, err = downloadFile(ctx, source)
 				if err != nil {
 					return nil, fmt.Errorf("failed to download %s: %w", source, err)
 				}
@@ -327,6 +349,7 @@ func collectPendingUploads(adf map[string]any) ([]pendingUpload, error) {
 				}
 				filename = filepath.Base(source)
 			}
+			session.Progress(ctx, "Fetched "+source)
 
 			// Use alt text as filename if available
 			if alt != "" && alt != "attachment" {
@@ -348,7 +371,7 @@ func collectPendingUploads(adf map[string]any) ([]pendingUpload, error) {
 		// Recursively process nested content
 		if innerContent, ok := nodeMap["content"].([]any); ok {
 			innerADF := map[string]any{"content": innerContent}
-			innerUploads, err := collectPendingUploads(innerADF)
+			innerUploads, err := collectPendingUploads(ctx, innerADF)
 			if err != nil {
 				return nil, err
 			}
@@ -26,9 +26,13 @@ func GetCanonicalFieldValue(fieldName string, fields map[string]any) string {
 			return v
 		}
 	case "description":
-		if v, ok := fields["description"].(map[string]any); ok {
+		switch v := fields["description"].(type) {
+		case map[string]any:
 			data, _ := json.Marshal(v)
 			return string(data)
+		case string:
+			// Wiki markup on Data Center
+			return v
 		}
 	case "status":
 		if v, ok := fields["status"].(map[string]any); ok {
@@ -38,9 +42,7 @@ func GetCanonicalFieldValue(fieldName string, fields map[string]any) string {
 		}
 	case "assignee":
 		if v, ok := fields["assignee"].(map[string]any); ok {
-			if id, ok := v["accountId"].(string); ok {
-				return id
-			}
+			return userID(v)
 		}
 	case "priority":
 		if v, ok := fields["priority"].(map[string]any); ok {
@@ -0,0 +1,105 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+
+	"atlassian-mcp/internal/adf"
+	"atlassian-mcp/internal/client"
+)
+
+// The Jira Data Center REST API v2 has the shape of the Cloud REST API v3,
+// except that rich text fields are wiki markup strings instead of ADF
+// documents and users are identified by username ("name") instead of
+// accountId. The helpers below cover the differences so the operations
+// serve both deployments.
+
+// api returns the REST API root: v3 on Cloud, v2 on Data Center.
+func api(c *client.Client) string {
+	if c.DataCenter {
+		return "/rest/api/2"
+	}
+	return "/rest/api/3"
+}
+
+// richText returns the value of a rich text field for a write: the ADF
+// document on Cloud, wiki markup on Data Center.
+func richText(c *client.Client, doc map[string]any) any {
+	if c.DataCenter {
+		return adf.ToWiki(doc)
+	}
+	return doc
+}
+
+// readable returns a rich text field for output: ADF converted to markdown,
+// or the wiki markup of Data Center unchanged.
+func readable(v any) (string, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		return adf.ToMarkdown(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+// userID returns the accountId of a Cloud user or the username of a Data
+// Center user.
+func userID(user map[string]any) string {
+	if id, ok := user["accountId"].(string); ok {
+		return id
+	}
+	name, _ := user["name"].(string)
+	return name
+}
+
+// dataCenterUsers rewrites user fields set by accountId, as the mention
+// format suggests, to the username Data Center expects.
+func dataCenterUsers(fields map[string]any) {
+	for _, name := range []string{"assignee", "reporter"} {
+		user, ok := fields[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		if id, ok := user["accountId"]; ok {
+			user = maps.Clone(user)
+			delete(user, "accountId")
+			user["name"] = id
+			fields[name] = user
+		}
+	}
+}
+
+// search returns the pager of a JQL search: the enhanced search on Cloud,
+// /rest/api/2/search with offsets on Data Center.
+func search(c *client.Client, jql string, fields []string) client.Pager {
+	if c.DataCenter {
+		q := url.Values{"jql": {jql}, "fields": {strings.Join(fields, ",")}}
+		return c.Offset(client.Jira, "/rest/api/2/search?"+q.Encode(), "issues")
+	}
+	payload := map[string]any{"jql": jql, "fields": fields}
+	return c.Token(client.Jira, "/rest/api/3/search/jql", payload, "issues")
+}
+
+// listProjects returns the raw project list. Data Center has no paginated
+// project search and returns every project at once.
+func listProjects(ctx context.Context, c *client.Client) (client.Page, error) {
+	if !c.DataCenter {
+		return c.Offset(client.Jira, "/rest/api/3/project/search", "values").Collect(ctx, client.PageRequest{Limit: client.MaxPageLimit})
+	}
+
+	body, err := c.Request(ctx, client.Jira, "/rest/api/2/project")
+	if err != nil {
+		return client.Page{}, err
+	}
+	page := client.Page{Total: -1}
+	if err := json.Unmarshal(body, &page.Items); err != nil {
+		return client.Page{}, fmt.Errorf("failed to parse project list")
+	}
+	page.Items = page.Items[:min(len(page.Items), client.MaxPageLimit)]
+	return page, nil
+}
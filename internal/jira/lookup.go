@@ -0,0 +1,59 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"atlassian-mcp/internal/client"
+)
+
+// Project identifies a Jira project.
+type Project struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// ListProjects returns the projects visible to the authenticated user, up to
+// client.MaxPageLimit.
+func ListProjects(ctx context.Context, c *client.Client) ([]Project, error) {
+	results, err := listProjects(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	var projects []Project
+	if err := results.Decode(&projects); err != nil {
+		return nil, fmt.Errorf("failed to parse project list")
+	}
+	return projects, nil
+}
+
+// ListIssueTypes returns the names of the issue types available in a project.
+func ListIssueTypes(ctx context.Context, c *client.Client, projectKey string) ([]string, error) {
+	body, err := c.Request(ctx, client.Jira, api(c)+"/project/"+url.PathEscape(projectKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project %s: %w", projectKey, err)
+	}
+
+	var project struct {
+		IssueTypes []struct {
+			Name    string `json:"name"`
+			Subtask bool   `json:"subtask"`
+		} `json:"issueTypes"`
+	}
+	if err := json.Unmarshal(body, &project); err != nil {
+		return nil, fmt.Errorf("failed to parse project %s", projectKey)
+	}
+
+	var names []string
+	for _, t := range project.IssueTypes {
+		// Subtasks need a parent, which jira_create_issue does not take
+		if !t.Subtask {
+			names = append(names, t.Name)
+		}
+	}
+	return names, nil
+}
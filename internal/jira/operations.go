@@ -1,44 +1,89 @@
 package jira
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
 	"atlassian-mcp/internal/adf"
+	"atlassian-mcp/internal/audit"
 	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/confirm"
+	"atlassian-mcp/internal/preview"
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
 )
 
+// issueChecksumFields are the fields tracked for conflict detection on issues.
+var issueChecksumFields = []string{"summary", "description", "status", "assignee", "priority", "labels", "components"}
+
 // FetchIssue fetches an issue by key and returns formatted markdown.
-func FetchIssue(issueKey string) (string, error) {
+func FetchIssue(ctx context.Context, c *client.Client, issueKey string) (types.Result, error) {
 	// Fetch issue with expanded fields
-	body, err := client.Request(client.Jira, fmt.Sprintf("/rest/api/3/issue/%s?expand=renderedFields", issueKey))
+	body, err := c.Request(ctx, client.Jira, fmt.Sprintf("%s/issue/%s?expand=renderedFields", api(c), issueKey))
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
 	var issue map[string]any
 	if err := json.Unmarshal(body, &issue); err != nil {
-		return "", fmt.Errorf("failed to parse issue response")
+		return types.Result{}, fmt.Errorf("failed to parse issue response")
 	}
 
-	return formatIssue(issue), nil
+	fields, _ := issue["fields"].(map[string]any)
+	return types.Result{
+		Text: formatIssue(c.Site, issue),
+		Data: map[string]any{
+			"site":      c.Site,
+			"issue":     issueData(issue),
+			"checksums": ComputeFieldsChecksums(fields, issueChecksumFields),
+		},
+	}, nil
 }
 
-// FetchComments fetches comments for an issue.
-func FetchComments(issueKey string) (string, error) {
-	commentsBody, err := client.Request(client.Jira, fmt.Sprintf("/rest/api/3/issue/%s/comment?orderBy=created&maxResults=50", issueKey))
+// FetchComments fetches comments for an issue, oldest first.
+func FetchComments(ctx context.Context, c *client.Client, issueKey string, page client.PageRequest) (types.Result, error) {
+	endpoint := fmt.Sprintf("%s/issue/%s/comment?orderBy=created", api(c), issueKey)
+	results, err := c.Offset(client.Jira, endpoint, "comments").Collect(ctx, page)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
-	var comments map[string]any
-	if err := json.Unmarshal(commentsBody, &comments); err != nil {
-		return "", fmt.Errorf("failed to parse response")
+	var commentList []any
+	if err := results.Decode(&commentList); err != nil {
+		return types.Result{}, fmt.Errorf("failed to parse response")
 	}
+	comments := map[string]any{"comments": commentList}
+
+	return types.Result{
+		Text: formatComments(issueKey, comments) + results.Summary(),
+		Data: results.Annotate(map[string]any{"issue": issueKey, "comments": commentsData(comments)}),
+	}, nil
+}
 
-	return formatComments(issueKey, comments), nil
+// commentsData extracts structured comment metadata with markdown bodies.
+func commentsData(comments map[string]any) []map[string]any {
+	commentList, _ := comments["comments"].([]any)
+	data := make([]map[string]any, 0, len(commentList))
+	for _, c := range commentList {
+		comment, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		entry := map[string]any{}
+		entry["id"], _ = comment["id"].(string)
+		entry["created"], _ = comment["created"].(string)
+		if a, ok := comment["author"].(map[string]any); ok {
+			entry["author"] = userData(a)
+		}
+		if body, ok := readable(comment["body"]); ok {
+			entry["body"] = body
+		}
+		data = append(data, entry)
+	}
+	return data
 }
 
 func formatComments(issueKey string, comments map[string]any) string {
@@ -62,7 +107,7 @@ func formatComments(issueKey string, comments map[string]any) string {
 		authorID := ""
 		if a, ok := comment["author"].(map[string]any); ok {
 			author, _ = a["displayName"].(string)
-			authorID, _ = a["accountId"].(string)
+			authorID = userID(a)
 		}
 		created, _ := comment["created"].(string)
 
@@ -71,8 +116,8 @@ func formatComments(issueKey string, comments map[string]any) string {
 			authorInfo = fmt.Sprintf("%s {user:%s}", author, authorID)
 		}
 		sb.WriteString(fmt.Sprintf("### %s (%s)\n\n", authorInfo, created))
-		if body, ok := comment["body"].(map[string]any); ok {
-			sb.WriteString(adf.ToMarkdown(body))
+		if body, ok := readable(comment["body"]); ok {
+			sb.WriteString(body)
 		}
 		sb.WriteString("\n---\n\n")
 	}
@@ -80,13 +125,16 @@ func formatComments(issueKey string, comments map[string]any) string {
 	return sb.String()
 }
 
-func formatIssue(issue map[string]any) string {
+func formatIssue(site string, issue map[string]any) string {
 	var sb strings.Builder
 
 	key, _ := issue["key"].(string)
 	fields, _ := issue["fields"].(map[string]any)
 
 	sb.WriteString(fmt.Sprintf("# %s\n\n", key))
+	if site != "" {
+		sb.WriteString(fmt.Sprintf("**Site:** %s\n\n", site))
+	}
 
 	if summary, ok := fields["summary"].(string); ok {
 		sb.WriteString(fmt.Sprintf("**Summary:** %s\n\n", summary))
@@ -112,7 +160,7 @@ func formatIssue(issue map[string]any) string {
 
 	if assignee, ok := fields["assignee"].(map[string]any); ok {
 		if name, ok := assignee["displayName"].(string); ok {
-			if accountID, ok := assignee["accountId"].(string); ok {
+			if accountID := userID(assignee); accountID != "" {
 				sb.WriteString(fmt.Sprintf("**Assignee:** %s {user:%s}\n", name, accountID))
 			} else {
 				sb.WriteString(fmt.Sprintf("**Assignee:** %s\n", name))
@@ -122,7 +170,7 @@ func formatIssue(issue map[string]any) string {
 
 	if reporter, ok := fields["reporter"].(map[string]any); ok {
 		if name, ok := reporter["displayName"].(string); ok {
-			if accountID, ok := reporter["accountId"].(string); ok {
+			if accountID := userID(reporter); accountID != "" {
 				sb.WriteString(fmt.Sprintf("**Reporter:** %s {user:%s}\n", name, accountID))
 			} else {
 				sb.WriteString(fmt.Sprintf("**Reporter:** %s\n", name))
@@ -183,9 +231,9 @@ func formatIssue(issue map[string]any) string {
 
 	sb.WriteString("\n")
 
-	if description, ok := fields["description"].(map[string]any); ok {
+	if description, ok := readable(fields["description"]); ok {
 		sb.WriteString("__DESCRIPTION__\n")
-		sb.WriteString(adf.ToMarkdown(description))
+		sb.WriteString(description)
 		sb.WriteString("__END_DESCRIPTION__\n\n")
 	}
 
@@ -233,11 +281,10 @@ func formatIssue(issue map[string]any) string {
 	}
 
 	// Compute and append checksums for optimistic concurrency control
-	checksumFields := []string{"summary", "description", "status", "assignee", "priority", "labels", "components"}
-	checksums := ComputeFieldsChecksums(fields, checksumFields)
+	checksums := ComputeFieldsChecksums(fields, issueChecksumFields)
 
 	sb.WriteString("\n__CHECKSUMS__\n")
-	for _, field := range checksumFields {
+	for _, field := range issueChecksumFields {
 		sb.WriteString(fmt.Sprintf("%s=%s\n", field, checksums[field]))
 	}
 	sb.WriteString("__END_CHECKSUMS__\n")
@@ -245,36 +292,87 @@ func formatIssue(issue map[string]any) string {
 	return sb.String()
 }
 
-// SearchIssues searches for issues using JQL (enhanced search endpoint)
-func SearchIssues(jql string) (string, error) {
-	endpoint := "/rest/api/3/search/jql"
+// issueData extracts the structured fields of an issue.
+func issueData(issue map[string]any) map[string]any {
+	key, _ := issue["key"].(string)
+	fields, _ := issue["fields"].(map[string]any)
 
-	payload := map[string]any{
-		"jql":        jql,
-		"maxResults": 50,
-		"fields":     []string{"key", "summary", "status", "assignee", "issuetype", "priority"},
+	data := map[string]any{"key": key}
+	for _, name := range []string{"summary", "created", "updated"} {
+		if v, ok := fields[name].(string); ok {
+			data[name] = v
+		}
+	}
+	for _, name := range []string{"status", "issuetype", "priority"} {
+		if v, ok := fields[name].(map[string]any); ok {
+			data[name], _ = v["name"].(string)
+		}
+	}
+	for _, name := range []string{"assignee", "reporter"} {
+		if v, ok := fields[name].(map[string]any); ok {
+			data[name] = userData(v)
+		}
 	}
 
-	reqBody, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal search request")
+	labels := []string{}
+	if v, ok := fields["labels"].([]any); ok {
+		for _, l := range v {
+			if s, ok := l.(string); ok {
+				labels = append(labels, s)
+			}
+		}
 	}
+	data["labels"] = labels
 
-	body, err := client.Post(client.Jira, endpoint, reqBody)
-	if err != nil {
-		return "", err
+	components := []string{}
+	if v, ok := fields["components"].([]any); ok {
+		for _, c := range v {
+			if comp, ok := c.(map[string]any); ok {
+				if name, ok := comp["name"].(string); ok {
+					components = append(components, name)
+				}
+			}
+		}
 	}
+	data["components"] = components
 
-	var result map[string]any
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse search response")
+	if parent, ok := fields["parent"].(map[string]any); ok {
+		data["parent"], _ = parent["key"].(string)
+	}
+
+	return data
+}
+
+// userData extracts the display name and account ID of a user object. On
+// Data Center the account ID is the username.
+func userData(user map[string]any) map[string]any {
+	data := map[string]any{}
+	data["displayName"], _ = user["displayName"].(string)
+	if id := userID(user); id != "" {
+		data["accountId"] = id
 	}
+	return data
+}
 
-	issues, ok := result["issues"].([]any)
-	if !ok {
-		return "No issues found.\n", nil
+// SearchIssues searches for issues using JQL (enhanced search endpoint on
+// Cloud)
+func SearchIssues(ctx context.Context, c *client.Client, jql string, page client.PageRequest) (types.Result, error) {
+	fields := []string{"key", "summary", "status", "assignee", "issuetype", "priority"}
+	results, err := search(c, jql, fields).Collect(ctx, page)
+	if err != nil {
+		return types.Result{}, err
+	}
+
+	var issues []any
+	if err := results.Decode(&issues); err != nil {
+		return types.Result{}, fmt.Errorf("failed to parse search response")
+	}
+	if len(issues) == 0 {
+		return types.Result{Text: "No issues found.\n", Data: map[string]any{"results": []any{}}}, nil
 	}
 
+	hits := make([]map[string]any, 0, len(issues))
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("# Search Results (%d issues)\n\n", len(issues)))
 
@@ -296,7 +394,7 @@ func SearchIssues(jql string) (string, error) {
 		assignee := "Unassigned"
 		if a, ok := fields["assignee"].(map[string]any); ok {
 			name, _ := a["displayName"].(string)
-			if accountID, ok := a["accountId"].(string); ok {
+			if accountID := userID(a); accountID != "" {
 				assignee = fmt.Sprintf("%s {user:%s}", name, accountID)
 			} else {
 				assignee = name
@@ -309,41 +407,68 @@ func SearchIssues(jql string) (string, error) {
 		}
 
 		sb.WriteString(fmt.Sprintf("- **%s** [%s] %s (%s) - %s\n", key, issueType, summary, status, assignee))
+
+		hit := map[string]any{"key": key, "issuetype": issueType, "summary": summary, "status": status}
+		if a, ok := fields["assignee"].(map[string]any); ok {
+			hit["assignee"] = userData(a)
+		}
+		hits = append(hits, hit)
 	}
+	sb.WriteString(results.Summary())
 
-	return sb.String(), nil
+	return types.Result{Text: sb.String(), Data: results.Annotate(map[string]any{"results": hits})}, nil
 }
 
-// AddComment adds a comment to an issue
-func AddComment(issueKey, commentBody string) (string, error) {
-	endpoint := fmt.Sprintf("/rest/api/3/issue/%s/comment", issueKey)
+// AddComment adds a comment to an issue. A dry run returns the request
+// without sending it.
+func AddComment(ctx context.Context, c *client.Client, issueKey, commentBody string, dryRun bool) (types.Result, error) {
+	endpoint := fmt.Sprintf("%s/issue/%s/comment", api(c), issueKey)
 
 	payload := map[string]any{
-		"body": adf.FromMarkdown(commentBody),
+		"body": richText(c, adf.FromMarkdown(commentBody)),
+	}
+
+	changes := []preview.Change{{Field: "comment", After: commentBody}}
+	if dryRun {
+		return preview.Write{
+			Title:    "Add comment to " + issueKey,
+			Method:   "POST",
+			Endpoint: endpoint,
+			Payload:  payload,
+			Changes:  changes,
+		}.Result(map[string]any{"issue": issueKey}), nil
+	}
+	if err := confirm.Changes(ctx, changes); err != nil {
+		return types.Result{}, err
 	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal comment")
+		return types.Result{}, fmt.Errorf("failed to marshal comment")
 	}
 
-	resp, err := client.Post(client.Jira, endpoint, body)
+	resp, err := c.Post(ctx, client.Jira, endpoint, body)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
 	var result map[string]any
 	if err := json.Unmarshal(resp, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response")
+		return types.Result{}, fmt.Errorf("failed to parse response")
 	}
 
 	commentID, _ := result["id"].(string)
-	return fmt.Sprintf("Comment added successfully (ID: %s)", commentID), nil
+	return types.Result{
+		Text: fmt.Sprintf("Comment added successfully (ID: %s)", commentID),
+		Data: map[string]any{"issue": issueKey, "commentId": commentID},
+	}, nil
 }
 
 // UpdateIssue updates fields on an issue with optimistic concurrency control.
-// Checksums are required for all fields being updated.
-func UpdateIssue(issueKey string, fields map[string]any, checksums map[string]string) (string, error) {
+// Checksums are required for all fields being updated. A dry run verifies the
+// checksums and fetches pending media, then returns the request and a diff of
+// each field without sending it.
+func UpdateIssue(ctx context.Context, c *client.Client, issueKey string, fields map[string]any, checksums map[string]string, dryRun bool) (types.Result, error) {
 	// Validate: checksums required for all fields being updated
 	var missingChecksums []string
 	for fieldName := range fields {
@@ -353,80 +478,96 @@ func UpdateIssue(issueKey string, fields map[string]any, checksums map[string]st
 	}
 	if len(missingChecksums) > 0 {
 		sort.Strings(missingChecksums)
-		return "", fmt.Errorf("missing checksums for fields: %s", strings.Join(missingChecksums, ", "))
+		return types.Result{}, fmt.Errorf("missing checksums for fields: %s", strings.Join(missingChecksums, ", "))
 	}
 
-	// Fetch current issue to verify checksums
-	currentBody, err := client.Request(client.Jira, fmt.Sprintf("/rest/api/3/issue/%s", issueKey))
-	if err != nil {
-		return "", err
-	}
+	// Verify, update, and re-fetch; media uploads add their own steps
+	session.ExpectSteps(ctx, 3)
 
-	var currentIssue map[string]any
-	if err := json.Unmarshal(currentBody, &currentIssue); err != nil {
-		return "", fmt.Errorf("failed to parse issue for verification")
+	changes, err := verifyIssue(ctx, c, issueKey, fields, checksums)
+	if err != nil {
+		return types.Result{}, err
 	}
+	session.Progress(ctx, "Verified checksums")
 
-	currentFields, _ := currentIssue["fields"].(map[string]any)
-
-	// Check each field being updated against its checksum
-	var mismatched []string
-	for fieldName := range fields {
-		expectedChecksum := checksums[fieldName]
-		currentCanonical := GetCanonicalFieldValue(fieldName, currentFields)
-		currentChecksum := ComputeFieldChecksum(currentCanonical)
-		if currentChecksum != expectedChecksum {
-			mismatched = append(mismatched, fieldName)
+	// Confirm before uploading media, which is already a write. The user may
+	// take minutes to answer, so verify again after asking them
+	if !dryRun {
+		asked := confirm.Asks(ctx, changes)
+		if err := confirm.Changes(ctx, changes); err != nil {
+			return types.Result{}, err
+		}
+		if asked {
+			if _, err := verifyIssue(ctx, c, issueKey, fields, checksums); err != nil {
+				return types.Result{}, err
+			}
 		}
-	}
-
-	if len(mismatched) > 0 {
-		sort.Strings(mismatched)
-		return "", fmt.Errorf("conflict: fields modified since read: %s", strings.Join(mismatched, ", "))
 	}
 
 	// Proceed with update
-	endpoint := fmt.Sprintf("/rest/api/3/issue/%s", issueKey)
+	endpoint := fmt.Sprintf("%s/issue/%s", api(c), issueKey)
 
-	// Convert description to ADF if it's a string
+	// Convert description to ADF (wiki markup on Data Center) if it's a string
+	var media []preview.Media
 	if desc, ok := fields["description"].(string); ok {
 		adfDoc := adf.FromMarkdown(desc)
 
 		// Upload any pending media (images from URLs or local paths)
-		if err := UploadPendingMedia(issueKey, adfDoc); err != nil {
-			return "", fmt.Errorf("failed to upload media: %v", err)
+		if dryRun {
+			media, err = PreviewPendingMedia(ctx, adfDoc)
+		} else {
+			err = UploadPendingMedia(ctx, c, issueKey, adfDoc)
+		}
+		if err != nil {
+			return types.Result{}, fmt.Errorf("failed to upload media: %v", err)
 		}
 
-		fields["description"] = adfDoc
+		fields["description"] = richText(c, adfDoc)
+	}
+	if c.DataCenter {
+		dataCenterUsers(fields)
 	}
 
 	payload := map[string]any{
 		"fields": fields,
 	}
 
+	if dryRun {
+		return preview.Write{
+			Title:    "Update issue " + issueKey,
+			Method:   "PUT",
+			Endpoint: endpoint,
+			Payload:  payload,
+			Changes:  changes,
+			Media:    media,
+		}.Result(map[string]any{"issue": issueKey}), nil
+	}
+
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal update")
+		return types.Result{}, fmt.Errorf("failed to marshal update")
 	}
 
-	_, err = client.Put(client.Jira, endpoint, body)
+	_, err = c.Put(ctx, client.Jira, endpoint, body)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
+	session.Progress(ctx, fmt.Sprintf("Updated issue %s", issueKey))
 
 	// Re-fetch issue to get fresh checksums
-	updatedBody, err := client.Request(client.Jira, fmt.Sprintf("/rest/api/3/issue/%s", issueKey))
+	updatedBody, err := c.Request(ctx, client.Jira, fmt.Sprintf("%s/issue/%s", api(c), issueKey))
 	if err != nil {
 		// Update succeeded but couldn't fetch fresh checksums
-		return fmt.Sprintf("Issue %s updated successfully (could not fetch fresh checksums)", issueKey), nil
+		return updatedResult(fmt.Sprintf("Issue %s updated successfully (could not fetch fresh checksums)", issueKey), issueKey, nil), nil
 	}
 
 	var updatedIssue map[string]any
 	if err := json.Unmarshal(updatedBody, &updatedIssue); err != nil {
-		return fmt.Sprintf("Issue %s updated successfully (could not parse fresh checksums)", issueKey), nil
+		return updatedResult(fmt.Sprintf("Issue %s updated successfully (could not parse fresh checksums)", issueKey), issueKey, nil), nil
 	}
 
 	updatedFields, _ := updatedIssue["fields"].(map[string]any)
+	session.Progress(ctx, "Fetched fresh checksums")
 
 	// Compute checksums only for the fields that were updated
 	var updatedFieldNames []string
@@ -445,12 +586,69 @@ func UpdateIssue(issueKey string, fields map[string]any, checksums map[string]st
 	sb.WriteString(string(checksumJSON))
 	sb.WriteString("\n```\n")
 
-	return sb.String(), nil
+	return updatedResult(sb.String(), issueKey, newChecksums), nil
+}
+
+// beforeValue returns the audit form of a field value: descriptions as
+// markdown, everything else in its canonical form.
+func beforeValue(fieldName string, fields map[string]any, canonical string) string {
+	if description, ok := readable(fields["description"]); ok && fieldName == "description" {
+		return description
+	}
+	return canonical
+}
+
+// verifyIssue fetches the current issue and checks the fields to be updated
+// against their checksums. It returns the change to each field, sorted.
+func verifyIssue(ctx context.Context, c *client.Client, issueKey string, fields map[string]any, checksums map[string]string) ([]preview.Change, error) {
+	currentBody, err := c.Request(client.NoCache(ctx), client.Jira, fmt.Sprintf("%s/issue/%s", api(c), issueKey))
+	if err != nil {
+		return nil, err
+	}
+
+	var currentIssue map[string]any
+	if err := json.Unmarshal(currentBody, &currentIssue); err != nil {
+		return nil, fmt.Errorf("failed to parse issue for verification")
+	}
+
+	currentFields, _ := currentIssue["fields"].(map[string]any)
+
+	// Check each field being updated against its checksum
+	var mismatched []string
+	var changes []preview.Change
+	for fieldName := range fields {
+		expectedChecksum := checksums[fieldName]
+		currentCanonical := GetCanonicalFieldValue(fieldName, currentFields)
+		before := beforeValue(fieldName, currentFields, currentCanonical)
+		audit.CaptureBefore(ctx, fieldName, before)
+		changes = append(changes, preview.Change{Field: fieldName, Before: before, After: preview.Value(fields[fieldName])})
+		currentChecksum := ComputeFieldChecksum(currentCanonical)
+		if currentChecksum != expectedChecksum {
+			mismatched = append(mismatched, fieldName)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		return nil, fmt.Errorf("conflict: fields modified since read: %s", strings.Join(mismatched, ", "))
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
 }
 
-// CreateIssue creates a new issue
-func CreateIssue(project, issueType, summary, description string) (string, error) {
-	endpoint := "/rest/api/3/issue"
+// updatedResult builds the result of a successful issue update.
+func updatedResult(text, issueKey string, checksums map[string]string) types.Result {
+	data := map[string]any{"issue": issueKey}
+	if checksums != nil {
+		data["checksums"] = checksums
+	}
+	return types.Result{Text: text, Data: data}
+}
+
+// CreateIssue creates a new issue. A dry run returns the request without
+// sending it.
+func CreateIssue(ctx context.Context, c *client.Client, project, issueType, summary, description string, dryRun bool) (types.Result, error) {
+	endpoint := api(c) + "/issue"
 
 	fields := map[string]any{
 		"project":   map[string]any{"key": project},
@@ -459,28 +657,48 @@ func CreateIssue(project, issueType, summary, description string) (string, error
 	}
 
 	if description != "" {
-		fields["description"] = adf.FromMarkdown(description)
+		fields["description"] = richText(c, adf.FromMarkdown(description))
 	}
 
 	payload := map[string]any{
 		"fields": fields,
 	}
 
+	changes := []preview.Change{
+		{Field: "summary", After: summary},
+		{Field: "description", After: description},
+	}
+	if dryRun {
+		return preview.Write{
+			Title:    fmt.Sprintf("Create %s in %s", issueType, project),
+			Method:   "POST",
+			Endpoint: endpoint,
+			Payload:  payload,
+			Changes:  changes,
+		}.Result(nil), nil
+	}
+	if err := confirm.Changes(ctx, changes); err != nil {
+		return types.Result{}, err
+	}
+
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal issue")
+		return types.Result{}, fmt.Errorf("failed to marshal issue")
 	}
 
-	resp, err := client.Post(client.Jira, endpoint, body)
+	resp, err := c.Post(ctx, client.Jira, endpoint, body)
 	if err != nil {
-		return "", err
+		return types.Result{}, err
 	}
 
 	var result map[string]any
 	if err := json.Unmarshal(resp, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response")
+		return types.Result{}, fmt.Errorf("failed to parse response")
 	}
 
 	key, _ := result["key"].(string)
-	return fmt.Sprintf("Issue created: %s", key), nil
+	return types.Result{
+		Text: fmt.Sprintf("Issue created: %s", key),
+		Data: map[string]any{"issue": key},
+	}, nil
 }
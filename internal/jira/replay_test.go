@@ -0,0 +1,145 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/confirm"
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+// replayClient returns a client for example.atlassian.net that answers from
+// the cassette recorded in testdata/cassettes/name.
+func replayClient(t *testing.T, name string) *client.Client {
+	t.Helper()
+	cassette, err := client.ReplayCassette(filepath.Join("testdata", "cassettes", name))
+	if err != nil {
+		t.Fatalf("ReplayCassette() error = %v", err)
+	}
+	c := client.New("example.atlassian.net", client.BasicAuth{})
+	c.HTTP = cassette.HTTPClient()
+	return c
+}
+
+func TestFetchIssue_Replay(t *testing.T) {
+	t.Parallel()
+	c := replayClient(t, "fetch_issue")
+	c.Site = "example"
+
+	got, err := FetchIssue(context.Background(), c, "PROJ-1")
+	if err != nil {
+		t.Fatalf("FetchIssue() error = %v", err)
+	}
+	want := `# PROJ-1
+
+**Site:** example
+
+**Summary:** Checkout fails for saved cards
+
+**Status:** In Progress
+**Type:** Bug
+**Priority:** High
+**Assignee:** Ada Lovelace {user:5b10ac8d82e05b22cc7d4ef5}
+**Reporter:** Grace Hopper {user:5b10a2844c20165700ede21g}
+**Labels:** payments, regression
+**Components:** Checkout
+**Parent:** PROJ-0 - Payments revamp
+**Created:** 2024-05-02T09:14:03.000+0000
+**Updated:** 2024-05-06T16:41:27.000+0000
+
+__DESCRIPTION__
+Paying with a **saved** card returns HTTP 500.
+
+- New cards work
+- Reported by @[Grace Hopper](accountId:5b10a2844c20165700ede21g)__END_DESCRIPTION__
+
+## Subtasks
+
+- [PROJ-2] Add regression test - To Do
+
+## Linked Issues
+
+- blocks: PROJ-7 - Release 2.4
+
+
+__CHECKSUMS__
+summary=ea7456340b5732e2
+description=d09009d08923a4a4
+status=b4cc4b07c300103a
+assignee=ecaec9686ed681b5
+priority=c4ebc6d4a5832cd9
+labels=c5b7b4a147b7405d
+components=99e71f48c48f121b
+__END_CHECKSUMS__
+`
+	if got.Text != want {
+		t.Errorf("FetchIssue() text =\n%s\nwant\n%s", got.Text, want)
+	}
+}
+
+func TestSearchIssues_Replay(t *testing.T) {
+	t.Parallel()
+	c := replayClient(t, "search_issues")
+
+	got, err := SearchIssues(context.Background(), c, "project = PROJ ORDER BY key", client.PageRequest{Limit: 3})
+	if err != nil {
+		t.Fatalf("SearchIssues() error = %v", err)
+	}
+	want := `# Search Results (3 issues)
+
+- **PROJ-1** [Bug] Checkout fails for saved cards (In Progress) - Ada Lovelace {user:5b10ac8d82e05b22cc7d4ef5}
+- **PROJ-4** [Story] Show card brand icons (To Do) - Unassigned
+- **PROJ-6** [Task] Retry declined payments (Done) - Grace Hopper {user:5b10a2844c20165700ede21g}
+`
+	if got.Text != want {
+		t.Errorf("SearchIssues() text =\n%s\nwant\n%s", got.Text, want)
+	}
+}
+
+func TestUpdateIssue_Replay(t *testing.T) {
+	t.Parallel()
+	c := replayClient(t, "update_issue")
+
+	fields := map[string]any{"summary": "Checkout fails for saved Visa cards", "labels": []any{"payments"}}
+	checksums := map[string]string{"summary": "ea7456340b5732e2", "labels": "c5b7b4a147b7405d"}
+	got, err := UpdateIssue(context.Background(), c, "PROJ-1", fields, checksums, false)
+	if err != nil {
+		t.Fatalf("UpdateIssue() error = %v", err)
+	}
+	want := "Issue PROJ-1 updated successfully\n\n## Checksums\n\n```json\n{\"labels\":\"df384ae97c77ad30\",\"summary\":\"e2b0c6b33409f38e\"}\n```\n"
+	if got.Text != want {
+		t.Errorf("UpdateIssue() text =\n%s\nwant\n%s", got.Text, want)
+	}
+	if !reflect.DeepEqual(got.Data["checksums"], map[string]string{"labels": "df384ae97c77ad30", "summary": "e2b0c6b33409f38e"}) {
+		t.Errorf("UpdateIssue() checksums = %v", got.Data["checksums"])
+	}
+}
+
+func TestUpdateIssue_ChangedWhileConfirming(t *testing.T) {
+	t.Parallel()
+	c := replayClient(t, "update_issue_changed")
+
+	// The client accepts the change, by which time the issue was reassigned
+	var s *session.Session
+	s = session.New(func(msg any) {
+		if req, ok := msg.(types.Request); ok {
+			go s.HandleResponse(json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"action":"accept"}}`, req.ID)))
+		}
+	})
+	s.SetClientCapabilities(map[string]any{"elicitation": map[string]any{}})
+	ctx := confirm.NewContext(session.NewContext(context.Background(), s), "jira_update_issue", false)
+
+	fields := map[string]any{"assignee": map[string]any{"accountId": "5b10ac8d82e05b22cc7d4ef6"}}
+	checksums := map[string]string{"assignee": "ecaec9686ed681b5"}
+	_, err := UpdateIssue(ctx, c, "PROJ-1", fields, checksums, false)
+	if err == nil || !strings.Contains(err.Error(), "conflict: fields modified since read: assignee") {
+		t.Errorf("UpdateIssue() error = %v, want a conflict on assignee", err)
+	}
+}
@@ -0,0 +1,40 @@
+package logging
+
+import "log/slog"
+
+// MCP log levels (RFC 5424 severities) mapped onto slog levels. slog's
+// built-in levels line up with debug, info, warning and error.
+var mcpLevels = []struct {
+	name  string
+	level slog.Level
+}{
+	{"debug", slog.LevelDebug},
+	{"info", slog.LevelInfo},
+	{"notice", slog.LevelInfo + 2},
+	{"warning", slog.LevelWarn},
+	{"error", slog.LevelError},
+	{"critical", slog.LevelError + 4},
+	{"alert", slog.LevelError + 8},
+	{"emergency", slog.LevelError + 12},
+}
+
+// ParseMCPLevel converts an MCP log level name to a slog level.
+func ParseMCPLevel(name string) (slog.Level, bool) {
+	for _, l := range mcpLevels {
+		if l.name == name {
+			return l.level, true
+		}
+	}
+	return 0, false
+}
+
+// MCPLevel returns the most severe MCP level at or below level.
+func MCPLevel(level slog.Level) string {
+	name := mcpLevels[0].name
+	for _, l := range mcpLevels {
+		if level >= l.level {
+			name = l.name
+		}
+	}
+	return name
+}
@@ -0,0 +1,196 @@
+// Package logging configures structured server logs and forwards them to MCP
+// clients that enabled the logging capability.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+// loggerName identifies this server in notifications/message.
+const loggerName = "atlassian-mcp"
+
+// redacted replaces credential values in log output.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are always redacted.
+var sensitiveKeys = []string{"authorization", "token", "password", "secret", "cookie"}
+
+// Options configures Setup.
+type Options struct {
+	Level slog.Level
+	// File receives logs instead of stderr when set.
+	File string
+	// Secrets are credential values to scrub from every log entry.
+	Secrets []string
+}
+
+// Setup installs the default slog logger. Logs are written as JSON to stderr
+// (stdout carries the stdio transport) or to opts.File, and are also sent to
+// MCP clients that called logging/setLevel. The returned closer releases the
+// log file, if any.
+func Setup(opts Options) (io.Closer, error) {
+	var w io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w, closer = f, f
+	}
+
+	r := newRedactor(opts.Secrets)
+	base := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       opts.Level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr { return r.attr(a) },
+	})
+	slog.SetDefault(slog.New(&handler{base: base, redact: r}))
+	return closer, nil
+}
+
+type loggerKey struct{}
+
+// NewContext returns a context whose logger carries args in addition to
+// those already attached to ctx.
+func NewContext(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey{}, FromContext(ctx).With(args...))
+}
+
+// FromContext returns the logger attached to ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// NewRequestID returns a random correlation ID for one MCP request.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// handler writes records to base and mirrors them to the MCP client of the
+// current request when its level is enabled.
+type handler struct {
+	base   slog.Handler
+	redact *redactor
+	attrs  []slog.Attr
+	group  string
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.base.Enabled(ctx, level) || clientEnabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.base.Enabled(ctx, r.Level) {
+		err = h.base.Handle(ctx, r)
+	}
+	if clientEnabled(ctx, r.Level) {
+		session.Notify(ctx, "notifications/message", types.LoggingMessageParams{
+			Level:  MCPLevel(r.Level),
+			Logger: loggerName,
+			Data:   h.data(r),
+		})
+	}
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.base = h.base.WithAttrs(attrs)
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), h.prefixed(attrs)...)
+	return &clone
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.base = h.base.WithGroup(name)
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+func (h *handler) prefixed(attrs []slog.Attr) []slog.Attr {
+	if h.group == "" {
+		return attrs
+	}
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = slog.Attr{Key: h.group + a.Key, Value: a.Value}
+	}
+	return out
+}
+
+// data flattens a record into the notification payload.
+func (h *handler) data(r slog.Record) map[string]any {
+	data := map[string]any{"message": r.Message}
+	add := func(a slog.Attr) {
+		a = h.redact.attr(a)
+		data[a.Key] = a.Value.Resolve().Any()
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		add(slog.Attr{Key: h.group + a.Key, Value: a.Value})
+		return true
+	})
+	return data
+}
+
+// clientEnabled reports whether the client of the current request asked for
+// logs at level.
+func clientEnabled(ctx context.Context, level slog.Level) bool {
+	min, ok := session.FromContext(ctx).LogLevel()
+	return ok && level >= min
+}
+
+// redactor scrubs credentials from attributes.
+type redactor struct {
+	secrets []string
+}
+
+func newRedactor(secrets []string) *redactor {
+	r := &redactor{}
+	for _, s := range secrets {
+		if s != "" {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	return r
+}
+
+func (r *redactor) attr(a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	if a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, r.scrub(a.Value.String()))
+	}
+	return a
+}
+
+func (r *redactor) scrub(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+func TestMCPLevel(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"debug", "debug"},
+		{"info", "info"},
+		{"notice", "notice"},
+		{"warning", "warning"},
+		{"error", "error"},
+		{"critical", "critical"},
+		{"alert", "alert"},
+		{"emergency", "emergency"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			level, ok := ParseMCPLevel(tt.name)
+			if !ok {
+				t.Fatalf("ParseMCPLevel(%q) not ok", tt.name)
+			}
+			if got := MCPLevel(level); got != tt.want {
+				t.Errorf("MCPLevel(%v) = %q, want %q", level, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := ParseMCPLevel("verbose"); ok {
+		t.Error("ParseMCPLevel(verbose) ok, want false")
+	}
+	if got := MCPLevel(slog.LevelDebug - 4); got != "debug" {
+		t.Errorf("MCPLevel(below debug) = %q, want debug", got)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	t.Parallel()
+	r := newRedactor([]string{"s3cret", ""})
+	tests := []struct {
+		attr slog.Attr
+		want string
+	}{
+		{slog.String("Authorization", "Basic abc"), redacted},
+		{slog.String("http_token", "anything"), redacted},
+		{slog.String("body", `{"error":"bad token s3cret"}`), `{"error":"bad token [REDACTED]"}`},
+		{slog.String("endpoint", "/rest/api/3/issue/PROJ-1"), "/rest/api/3/issue/PROJ-1"},
+	}
+	for _, tt := range tests {
+		if got := r.attr(tt.attr).Value.String(); got != tt.want {
+			t.Errorf("attr(%s) = %q, want %q", tt.attr.Key, got, tt.want)
+		}
+	}
+}
+
+func TestHandler_ForwardsToClient(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	r := newRedactor([]string{"s3cret"})
+	base := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level:       slog.LevelInfo,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr { return r.attr(a) },
+	})
+	logger := slog.New(&handler{base: base, redact: r}).With("request_id", "abc")
+
+	var got []types.LoggingMessageParams
+	s := session.New(func(msg any) {
+		n := msg.(types.Notification)
+		got = append(got, n.Params.(types.LoggingMessageParams))
+	})
+	ctx := session.NewContext(context.Background(), s)
+
+	// Nothing is forwarded until the client sets a level
+	logger.WarnContext(ctx, "before")
+	s.SetLogLevel(slog.LevelDebug)
+	logger.DebugContext(ctx, "call", "endpoint", "/x", "token", "s3cret")
+
+	if len(got) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(got))
+	}
+	msg := got[0]
+	data := msg.Data.(map[string]any)
+	if msg.Level != "debug" || data["message"] != "call" || data["request_id"] != "abc" || data["token"] != redacted {
+		t.Errorf("unexpected notification %+v", msg)
+	}
+
+	// Debug is below the stderr level, so only the warning reaches the log
+	if out := buf.String(); !strings.Contains(out, `"msg":"before"`) || strings.Contains(out, `"msg":"call"`) {
+		t.Errorf("unexpected log output: %s", out)
+	}
+}
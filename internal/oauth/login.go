@@ -0,0 +1,144 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// loginTimeout bounds how long Login waits for the user to approve access.
+const loginTimeout = 5 * time.Minute
+
+// Login runs the authorization-code flow with PKCE. It listens on the
+// loopback RedirectURL, calls prompt with the URL the user must open in a
+// browser, and stores the refresh token once access is granted.
+func (p *Provider) Login(ctx context.Context, prompt func(authorizeURL string)) error {
+	redirect, err := url.Parse(p.opts.RedirectURL)
+	if err != nil || redirect.Scheme != "http" || !isLoopback(redirect.Hostname()) {
+		return fmt.Errorf("OAuth redirect URL must be an http loopback URL such as http://127.0.0.1:8976/callback, got %q", p.opts.RedirectURL)
+	}
+
+	ln, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return fmt.Errorf("failed to listen for the OAuth redirect: %w", err)
+	}
+	defer ln.Close()
+	if redirect.Port() == "0" {
+		// A free port was picked; the app must accept any loopback port
+		redirect.Host = ln.Addr().String()
+	}
+
+	verifier := randomString(32)
+	state := randomString(16)
+	q := url.Values{
+		"audience":              {"api.atlassian.com"},
+		"client_id":             {p.opts.ClientID},
+		"scope":                 {strings.Join(p.opts.Scopes, " ")},
+		"redirect_uri":          {redirect.String()},
+		"state":                 {state},
+		"response_type":         {"code"},
+		"prompt":                {"consent"},
+		"code_challenge":        {challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	codes := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != state {
+			// Not the redirect of this login; keep waiting for it
+			http.Error(w, "Authorization state mismatch", http.StatusBadRequest)
+			return
+		}
+		res := parseCallback(r.URL.Query())
+		if res.err != nil {
+			http.Error(w, "Authorization failed: "+res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete. You can close this window and return to your agent.")
+		}
+		select {
+		case codes <- res:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	prompt(p.authURL + "?" + q.Encode())
+
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+	var res callbackResult
+	select {
+	case res = <-codes:
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for OAuth authorization")
+	}
+	if res.err != nil {
+		return res.err
+	}
+
+	tok, err := p.requestToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          res.code,
+		"redirect_uri":  redirect.String(),
+		"code_verifier": verifier,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	if tok.RefreshToken == "" {
+		return errors.New("Atlassian returned no refresh token; add the offline_access scope to the app")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.setToken(tok)
+}
+
+// callbackResult is the outcome of the redirect back to the listener.
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// parseCallback extracts the code of a redirect, or the error it reports.
+func parseCallback(q url.Values) callbackResult {
+	switch {
+	case q.Get("error") != "":
+		return callbackResult{err: fmt.Errorf("authorization denied: %s %s", q.Get("error"), q.Get("error_description"))}
+	case q.Get("code") == "":
+		return callbackResult{err: errors.New("authorization code missing")}
+	}
+	return callbackResult{code: q.Get("code")}
+}
+
+// challenge derives the S256 PKCE code challenge of verifier.
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes encoded for use in URLs.
+func randomString(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
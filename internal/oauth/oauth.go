@@ -0,0 +1,261 @@
+// Package oauth authorizes requests with Atlassian OAuth 2.0 (3LO): an
+// authorization-code login with PKCE through a loopback redirect, encrypted
+// storage of the refresh token, and access token refresh.
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"atlassian-mcp/internal/client"
+)
+
+const (
+	authURL      = "https://auth.atlassian.com/authorize"
+	tokenURL     = "https://auth.atlassian.com/oauth/token"
+	resourcesURL = "https://api.atlassian.com/oauth/token/accessible-resources"
+
+	// expiryMargin renews access tokens shortly before they expire so a
+	// request never leaves with a token about to lapse.
+	expiryMargin = time.Minute
+
+	// minRefreshInterval keeps concurrent requests that all got 401 from
+	// refreshing one after another; Atlassian rotates the refresh token on
+	// every refresh.
+	minRefreshInterval = 10 * time.Second
+)
+
+// DefaultScopes are requested when Options.Scopes is empty. They cover the
+// classic Jira scopes and the granular Confluence scopes the v2 API needs;
+// offline_access is required for a refresh token.
+var DefaultScopes = []string{
+	"read:jira-work", "write:jira-work", "read:jira-user",
+	"read:page:confluence", "write:page:confluence", "read:space:confluence",
+	"read:comment:confluence", "write:comment:confluence", "read:attachment:confluence",
+	"read:confluence-content.all", "write:confluence-content", "write:confluence-file",
+	"read:confluence-user", "search:confluence",
+	"offline_access",
+}
+
+// ErrNotLoggedIn is returned when no refresh token is stored yet.
+var ErrNotLoggedIn = errors.New("not logged in to Atlassian: restart the server with -login to authorize it")
+
+// Options configures New.
+type Options struct {
+	// ClientID and ClientSecret identify the OAuth 2.0 (3LO) app registered in
+	// the Atlassian developer console.
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the loopback callback registered with the app, e.g.
+	// http://127.0.0.1:8976/callback.
+	RedirectURL string
+	Scopes      []string
+	// TokenFile holds the encrypted refresh token.
+	TokenFile string
+	// Passphrase encrypts TokenFile.
+	Passphrase string
+}
+
+// Provider authorizes requests with OAuth access tokens, refreshing them as
+// they expire. It implements client.Auth and client.Refresher.
+type Provider struct {
+	opts  Options
+	store *store
+	http  *http.Client
+
+	// Atlassian endpoints, replaced in tests.
+	authURL      string
+	tokenURL     string
+	resourcesURL string
+
+	mu           sync.Mutex
+	refreshToken string
+	accessToken  string
+	expires      time.Time
+	refreshed    time.Time
+}
+
+// New returns a provider for opts, loading the refresh token from
+// opts.TokenFile when one was stored by an earlier Login.
+func New(opts Options) (*Provider, error) {
+	if len(opts.Scopes) == 0 {
+		opts.Scopes = DefaultScopes
+	}
+	p := &Provider{
+		opts:         opts,
+		store:        newStore(opts.TokenFile, opts.Passphrase),
+		http:         client.HTTPClient,
+		authURL:      authURL,
+		tokenURL:     tokenURL,
+		resourcesURL: resourcesURL,
+	}
+	token, err := p.store.load()
+	if err != nil {
+		return nil, err
+	}
+	p.refreshToken = token
+	return p, nil
+}
+
+// LoggedIn reports whether a refresh token is available.
+func (p *Provider) LoggedIn() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refreshToken != ""
+}
+
+// Authorize sets a bearer access token on req, refreshing it first when it
+// has expired.
+func (p *Provider) Authorize(req *http.Request) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken == "" || time.Now().Add(expiryMargin).After(p.expires) {
+		if err := p.refreshLocked(req.Context()); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+p.accessToken)
+	return nil
+}
+
+// Refresh gets a new access token after Atlassian rejected the current one.
+func (p *Provider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.refreshed) < minRefreshInterval {
+		return nil
+	}
+	return p.refreshLocked(ctx)
+}
+
+func (p *Provider) refreshLocked(ctx context.Context) error {
+	if p.refreshToken == "" {
+		return ErrNotLoggedIn
+	}
+	tok, err := p.requestToken(ctx, map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": p.refreshToken,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	return p.setToken(tok)
+}
+
+// tokenResponse is the reply of the token endpoint.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// setToken keeps a new access token and stores the refresh token, which
+// Atlassian rotates on every refresh. p.mu must be held.
+func (p *Provider) setToken(tok tokenResponse) error {
+	p.accessToken = tok.AccessToken
+	p.expires = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	p.refreshed = time.Now()
+	if tok.RefreshToken != "" && tok.RefreshToken != p.refreshToken {
+		p.refreshToken = tok.RefreshToken
+		if err := p.store.save(tok.RefreshToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestToken posts a grant to the token endpoint with the app credentials.
+func (p *Provider) requestToken(ctx context.Context, grant map[string]string) (tokenResponse, error) {
+	grant["client_id"] = p.opts.ClientID
+	if p.opts.ClientSecret != "" {
+		grant["client_secret"] = p.opts.ClientSecret
+	}
+	body, err := json.Marshal(grant)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, bytes.NewReader(body))
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var tok tokenResponse
+	if err := p.doJSON(req, &tok); err != nil {
+		return tokenResponse{}, err
+	}
+	if tok.AccessToken == "" {
+		return tokenResponse{}, errors.New("token response has no access token")
+	}
+	return tok, nil
+}
+
+// Resource is a site the authorized user granted the app access to.
+type Resource struct {
+	ID   string `json:"id"`
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// CloudID returns the cloud ID of the site at domain, e.g.
+// example.atlassian.net, which API gateway URLs are built from.
+func (p *Provider) CloudID(ctx context.Context, domain string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.resourcesURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request")
+	}
+	if err := p.Authorize(req); err != nil {
+		return "", err
+	}
+
+	var resources []Resource
+	if err := p.doJSON(req, &resources); err != nil {
+		return "", fmt.Errorf("failed to list accessible sites: %w", err)
+	}
+	var names []string
+	for _, r := range resources {
+		if strings.EqualFold(strings.TrimPrefix(r.URL, "https://"), domain) {
+			return r.ID, nil
+		}
+		names = append(names, r.URL)
+	}
+	return "", fmt.Errorf("the OAuth grant does not include %s (granted: %s); run with -login and select that site", domain, strings.Join(names, ", "))
+}
+
+// doJSON sends req and decodes a JSON reply into v.
+func (p *Provider) doJSON(req *http.Request, v any) error {
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Atlassian")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return fmt.Errorf("%s (HTTP %d): %s", oauthErr.Error, resp.StatusCode, oauthErr.Description)
+		}
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response")
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeAuth serves the token and accessible-resources endpoints. Every token
+// grant returns a new access token and rotates the refresh token.
+type fakeAuth struct {
+	t         *testing.T
+	challenge string // code_challenge of the login, checked on exchange
+	grants    atomic.Int32
+}
+
+func (f *fakeAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/oauth/token":
+		body, _ := io.ReadAll(r.Body)
+		var grant map[string]string
+		_ = json.Unmarshal(body, &grant)
+		if grant["client_id"] != "app" || grant["client_secret"] != "shh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"access_denied","error_description":"Unauthorized"}`))
+			return
+		}
+		switch grant["grant_type"] {
+		case "authorization_code":
+			if grant["code"] != "the-code" || challenge(grant["code_verifier"]) != f.challenge {
+				f.t.Errorf("authorization_code grant = %v", grant)
+			}
+		case "refresh_token":
+			if !strings.HasPrefix(grant["refresh_token"], "refresh-") {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":"unauthorized_client","error_description":"refresh_token is invalid"}`))
+				return
+			}
+		}
+		n := f.grants.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access-" + string(rune('0'+n)),
+			"refresh_token": "refresh-" + string(rune('0'+n)),
+			"expires_in":    3600,
+		})
+	case "/oauth/token/accessible-resources":
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"id":"cloud-1","url":"https://example.atlassian.net","name":"example"}]`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// testProvider returns a provider using the fake endpoints and a fast key
+// derivation.
+func testProvider(t *testing.T, f *fakeAuth) *Provider {
+	t.Helper()
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+
+	opts := Options{
+		ClientID:     "app",
+		ClientSecret: "shh",
+		RedirectURL:  "http://127.0.0.1:0/callback",
+		Scopes:       DefaultScopes,
+		TokenFile:    filepath.Join(t.TempDir(), "token.json"),
+		Passphrase:   "correct horse",
+	}
+	return &Provider{
+		opts:         opts,
+		store:        &store{path: opts.TokenFile, passphrase: opts.Passphrase, iterations: 1},
+		http:         ts.Client(),
+		authURL:      ts.URL + "/authorize",
+		tokenURL:     ts.URL + "/oauth/token",
+		resourcesURL: ts.URL + "/oauth/token/accessible-resources",
+	}
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+	f := &fakeAuth{t: t}
+	p := testProvider(t, f)
+
+	err := p.Login(context.Background(), func(authorizeURL string) {
+		u, err := url.Parse(authorizeURL)
+		if err != nil {
+			t.Errorf("authorize URL %q: %v", authorizeURL, err)
+			return
+		}
+		q := u.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("audience") != "api.atlassian.com" || !strings.Contains(q.Get("scope"), "offline_access") {
+			t.Errorf("authorize URL query = %v", q)
+		}
+		f.challenge = q.Get("code_challenge")
+
+		// A redirect with another state is ignored
+		go func() {
+			resp, err := http.Get(q.Get("redirect_uri") + "?state=forged&code=evil")
+			if err == nil {
+				resp.Body.Close()
+			}
+			resp, err = http.Get(q.Get("redirect_uri") + "?state=" + url.QueryEscape(q.Get("state")) + "&code=the-code")
+			if err != nil {
+				t.Errorf("redirect: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if !p.LoggedIn() {
+		t.Fatal("LoggedIn() = false after Login")
+	}
+
+	stored, err := p.store.load()
+	if err != nil || stored != "refresh-1" {
+		t.Errorf("stored token = %q, %v; want refresh-1", stored, err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.atlassian.net", nil)
+	if err := p.Authorize(req); err != nil || req.Header.Get("Authorization") != "Bearer access-1" {
+		t.Errorf("Authorize() = %q, %v; want the login access token", req.Header.Get("Authorization"), err)
+	}
+}
+
+func TestLogin_Denied(t *testing.T) {
+	t.Parallel()
+	p := testProvider(t, &fakeAuth{t: t})
+
+	err := p.Login(context.Background(), func(authorizeURL string) {
+		u, _ := url.Parse(authorizeURL)
+		q := u.Query()
+		go func() {
+			resp, err := http.Get(q.Get("redirect_uri") + "?state=" + url.QueryEscape(q.Get("state")) + "&error=access_denied")
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	})
+	if err == nil || !strings.Contains(err.Error(), "authorization denied: access_denied") {
+		t.Errorf("Login() error = %v", err)
+	}
+	if p.LoggedIn() {
+		t.Error("LoggedIn() = true after a denied login")
+	}
+}
+
+func TestAuthorize_Refresh(t *testing.T) {
+	t.Parallel()
+	f := &fakeAuth{t: t}
+	p := testProvider(t, f)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.atlassian.net", nil)
+	if err := p.Authorize(req); err != ErrNotLoggedIn {
+		t.Fatalf("Authorize() before login error = %v, want ErrNotLoggedIn", err)
+	}
+
+	p.refreshToken = "refresh-0"
+	if err := p.Authorize(req); err != nil || req.Header.Get("Authorization") != "Bearer access-1" {
+		t.Fatalf("Authorize() = %q, %v", req.Header.Get("Authorization"), err)
+	}
+	if err := p.Authorize(req); err != nil || f.grants.Load() != 1 {
+		t.Errorf("Authorize() refreshed a valid token: %d grants, %v", f.grants.Load(), err)
+	}
+
+	// Refresh right after a refresh is skipped
+	if err := p.Refresh(context.Background()); err != nil || f.grants.Load() != 1 {
+		t.Errorf("Refresh() = %d grants, %v; want 1", f.grants.Load(), err)
+	}
+	p.refreshed = p.refreshed.Add(-minRefreshInterval)
+	if err := p.Refresh(context.Background()); err != nil || p.accessToken != "access-2" {
+		t.Errorf("Refresh() access token = %q, %v; want access-2", p.accessToken, err)
+	}
+	if stored, _ := p.store.load(); stored != "refresh-2" {
+		t.Errorf("stored token = %q, want the rotated refresh-2", stored)
+	}
+
+	p.refreshToken = "revoked"
+	p.refreshed = p.refreshed.Add(-minRefreshInterval)
+	if err := p.Refresh(context.Background()); err == nil || !strings.Contains(err.Error(), "refresh_token is invalid") {
+		t.Errorf("Refresh() with a revoked token error = %v", err)
+	}
+}
+
+func TestCloudID(t *testing.T) {
+	t.Parallel()
+	p := testProvider(t, &fakeAuth{t: t})
+	p.refreshToken = "refresh-0"
+
+	if id, err := p.CloudID(context.Background(), "example.atlassian.net"); err != nil || id != "cloud-1" {
+		t.Errorf("CloudID() = %q, %v; want cloud-1", id, err)
+	}
+	if _, err := p.CloudID(context.Background(), "other.atlassian.net"); err == nil || !strings.Contains(err.Error(), "does not include other.atlassian.net") {
+		t.Errorf("CloudID() of a site not granted error = %v", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "nested", "token.json")
+	s := &store{path: path, passphrase: "secret", iterations: 1}
+
+	if token, err := s.load(); err != nil || token != "" {
+		t.Fatalf("load() without a file = %q, %v", token, err)
+	}
+	if err := s.save("refresh-abc"); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+	if token, err := s.load(); err != nil || token != "refresh-abc" {
+		t.Errorf("load() = %q, %v; want refresh-abc", token, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "refresh-abc") {
+		t.Error("token file holds the refresh token in clear text")
+	}
+
+	wrong := &store{path: path, passphrase: "guess", iterations: 1}
+	if _, err := wrong.load(); err == nil || !strings.Contains(err.Error(), "wrong ATLASSIAN_OAUTH_PASSPHRASE") {
+		t.Errorf("load() with the wrong passphrase error = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package oauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-SHA256.
+const pbkdf2Iterations = 600_000
+
+// store keeps the refresh token in a file encrypted with AES-256-GCM under a
+// key derived from a passphrase, so a copied file is useless on its own.
+type store struct {
+	path       string
+	passphrase string
+	iterations int
+}
+
+func newStore(path, passphrase string) *store {
+	return &store{path: path, passphrase: passphrase, iterations: pbkdf2Iterations}
+}
+
+// storedToken is the file format. Salt and nonce are fresh on every save.
+type storedToken struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// load returns the stored refresh token, or "" when none is stored yet.
+func (s *store) load() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read OAuth token file: %w", err)
+	}
+
+	var st storedToken
+	if err := json.Unmarshal(data, &st); err != nil {
+		return "", fmt.Errorf("OAuth token file %s is corrupt; delete it and log in again", s.path)
+	}
+	aead, err := s.cipher(st.Salt)
+	if err != nil {
+		return "", err
+	}
+	if len(st.Nonce) != aead.NonceSize() {
+		return "", fmt.Errorf("OAuth token file %s is corrupt; delete it and log in again", s.path)
+	}
+	token, err := aead.Open(nil, st.Nonce, st.Ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt OAuth token file %s: wrong ATLASSIAN_OAUTH_PASSPHRASE?", s.path)
+	}
+	return string(token), nil
+}
+
+// save encrypts token and replaces the file atomically with mode 0600.
+func (s *store) save(token string) error {
+	st := storedToken{Salt: make([]byte, 16)}
+	_, _ = rand.Read(st.Salt)
+	aead, err := s.cipher(st.Salt)
+	if err != nil {
+		return err
+	}
+	st.Nonce = make([]byte, aead.NonceSize())
+	_, _ = rand.Read(st.Nonce)
+	st.Ciphertext = aead.Seal(nil, st.Nonce, []byte(token), nil)
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create OAuth token directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".oauth-token-*")
+	if err != nil {
+		return fmt.Errorf("failed to write OAuth token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write OAuth token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write OAuth token file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write OAuth token file: %w", err)
+	}
+	return nil
+}
+
+// cipher derives the AES-GCM cipher for salt from the passphrase.
+func (s *store) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, s.iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
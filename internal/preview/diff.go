@@ -0,0 +1,124 @@
+package preview
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around each change.
+	diffContext = 3
+
+	// maxDiffCells bounds the LCS table. Larger inputs are shown as a full
+	// replacement rather than spending time and memory on alignment.
+	maxDiffCells = 4_000_000
+)
+
+// Diff returns a line diff of before and after in unified style: removed
+// lines start with "-", added lines with "+", and unchanged context lines
+// with a space. Runs of unchanged lines far from any change are elided.
+func Diff(before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+	ops := diffLines(a, b)
+
+	// Mark unchanged lines within diffContext of a change as visible
+	visible := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		for j := max(0, i-diffContext); j <= min(len(ops)-1, i+diffContext); j++ {
+			visible[j] = true
+		}
+	}
+
+	var sb strings.Builder
+	skipped := 0
+	for i, op := range ops {
+		if !visible[i] {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			sb.WriteString(fmt.Sprintf("@@ %d unchanged lines @@\n", skipped))
+			skipped = 0
+		}
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		sb.WriteByte('\n')
+	}
+	if skipped > 0 && sb.Len() > 0 {
+		sb.WriteString(fmt.Sprintf("@@ %d unchanged lines @@\n", skipped))
+	}
+	if sb.Len() == 0 {
+		return " (no changes)\n"
+	}
+	return sb.String()
+}
+
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines aligns a and b on their longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	if len(a)*len(b) > maxDiffCells {
+		ops := make([]diffOp, 0, len(a)+len(b))
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// splitLines splits s into lines, treating an empty string as no lines.
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
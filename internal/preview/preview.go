@@ -0,0 +1,95 @@
+// Package preview renders dry runs of write operations: the REST request that
+// would be sent and a diff of each field it changes.
+package preview
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"atlassian-mcp/internal/types"
+)
+
+// Change is a field the write would set, with its current and new values.
+// Before is empty for fields that do not exist yet, such as a new comment.
+type Change struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Media is an image that would be uploaded as an attachment.
+type Media struct {
+	Source   string `json:"source"`
+	Filename string `json:"filename"`
+	Size     int    `json:"size"`
+}
+
+// Write describes a write that was validated but not sent.
+type Write struct {
+	// Title summarizes the write, e.g. "Update issue PROJ-123".
+	Title    string
+	Method   string
+	Endpoint string
+	// Payload is the request body. Pending media keep their placeholder IDs,
+	// which are replaced by attachment IDs once uploaded.
+	Payload any
+	Changes []Change
+	Media   []Media
+}
+
+// Result renders the dry run as markdown plus structured data. data holds
+// extra structured fields, such as the target issue key.
+func (w Write) Result(data map[string]any) types.Result {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Dry run: %s\n\n", w.Title))
+	sb.WriteString("Nothing was changed. Repeat the call without dryRun to apply it.\n")
+
+	changes := make([]map[string]any, 0, len(w.Changes))
+	if len(w.Changes) > 0 {
+		sb.WriteString("\n## Changes\n")
+	}
+	for _, c := range w.Changes {
+		diff := Diff(c.Before, c.After)
+		sb.WriteString(fmt.Sprintf("\n### %s\n\n```diff\n%s```\n", c.Field, diff))
+		changes = append(changes, map[string]any{"field": c.Field, "before": c.Before, "after": c.After, "diff": diff})
+	}
+
+	if len(w.Media) > 0 {
+		sb.WriteString("\n## Attachments to upload\n\n")
+		for _, m := range w.Media {
+			sb.WriteString(fmt.Sprintf("- %s (%d bytes) from %s\n", m.Filename, m.Size, m.Source))
+		}
+	}
+
+	payload, _ := json.MarshalIndent(w.Payload, "", "  ")
+	sb.WriteString(fmt.Sprintf("\n## Request\n\n`%s %s`\n\n```json\n%s\n```\n", w.Method, w.Endpoint, payload))
+
+	out := map[string]any{
+		"dryRun":   true,
+		"method":   w.Method,
+		"endpoint": w.Endpoint,
+		"payload":  w.Payload,
+		"changes":  changes,
+	}
+	if len(w.Media) > 0 {
+		out["media"] = w.Media
+	}
+	for k, v := range data {
+		out[k] = v
+	}
+	return types.Result{Text: sb.String(), Data: out}
+}
+
+// Value renders a field value for diffing: strings as-is, anything else as
+// JSON.
+func Value(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,85 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{"added", "", "one\ntwo", "+one\n+two\n"},
+		{"removed", "one", "", "-one\n"},
+		{"unchanged", "same", "same", " (no changes)\n"},
+		{"replaced line", "a\nb\nc", "a\nB\nc", " a\n-b\n+B\n c\n"},
+		{"trailing newline ignored", "a\n", "a", " (no changes)\n"},
+		{
+			"distant context elided",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\nten",
+			"@@ 6 unchanged lines @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			"context after change elided",
+			"a\n1\n2\n3\n4\n5",
+			"A\n1\n2\n3\n4\n5",
+			"-a\n+A\n 1\n 2\n 3\n@@ 2 unchanged lines @@\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Diff(tt.before, tt.after); got != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite_Result(t *testing.T) {
+	t.Parallel()
+	w := Write{
+		Title:    "Update issue PROJ-1",
+		Method:   "PUT",
+		Endpoint: "/rest/api/3/issue/PROJ-1",
+		Payload:  map[string]any{"fields": map[string]any{"summary": "New"}},
+		Changes:  []Change{{Field: "summary", Before: "Old", After: "New"}},
+		Media:    []Media{{Source: "/tmp/a.png", Filename: "a.png", Size: 3}},
+	}
+	res := w.Result(map[string]any{"issue": "PROJ-1"})
+
+	for _, want := range []string{"# Dry run: Update issue PROJ-1", "-Old\n+New", "`PUT /rest/api/3/issue/PROJ-1`", `"summary": "New"`, "a.png (3 bytes)"} {
+		if !strings.Contains(res.Text, want) {
+			t.Errorf("Result().Text missing %q:\n%s", want, res.Text)
+		}
+	}
+	if res.Data["dryRun"] != true || res.Data["issue"] != "PROJ-1" || res.Data["method"] != "PUT" {
+		t.Errorf("Result().Data = %v", res.Data)
+	}
+	if changes, _ := res.Data["changes"].([]map[string]any); len(changes) != 1 || changes[0]["field"] != "summary" {
+		t.Errorf("Result().Data[changes] = %v", res.Data["changes"])
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{"text", "text"},
+		{[]any{"a", "b"}, `["a","b"]`},
+		{map[string]any{"name": "High"}, `{"name":"High"}`},
+	}
+	for _, tt := range tests {
+		if got := Value(tt.in); got != tt.want {
+			t.Errorf("Value(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
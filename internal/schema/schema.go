@@ -0,0 +1,195 @@
+// Package schema validates decoded JSON values against the subset of JSON
+// Schema used by verb parameter declarations.
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Validate checks value, as decoded by encoding/json into any, against s.
+// Supported keywords: type, properties, required, additionalProperties
+// (boolean or schema), items, enum, minLength, minimum, and maximum. Unknown
+// keywords are ignored.
+func Validate(s map[string]any, value any) error {
+	return validate(s, value, "")
+}
+
+func validate(s map[string]any, value any, path string) error {
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		return fmt.Errorf("%s: expected %s, got %s", label(path), typeNames(t), typeOf(value))
+	}
+
+	if enum, ok := s["enum"].([]string); ok {
+		str, _ := value.(string)
+		if !slices.Contains(enum, str) {
+			return fmt.Errorf("%s: must be one of %s", label(path), strings.Join(enum, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if min, ok := intValue(s["minLength"]); ok && len(v) < min {
+			if min == 1 {
+				return fmt.Errorf("%s: must not be empty", label(path))
+			}
+			return fmt.Errorf("%s: must be at least %d characters", label(path), min)
+		}
+
+	case float64:
+		if min, ok := intValue(s["minimum"]); ok && v < float64(min) {
+			return fmt.Errorf("%s: must be at least %d", label(path), min)
+		}
+		if max, ok := intValue(s["maximum"]); ok && v > float64(max) {
+			return fmt.Errorf("%s: must be at most %d", label(path), max)
+		}
+
+	case map[string]any:
+		return validateObject(s, v, path)
+
+	case []any:
+		items, ok := s["items"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range v {
+			if err := validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateObject(s map[string]any, obj map[string]any, path string) error {
+	required, _ := s["required"].([]string)
+	for _, name := range required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: missing required field", label(join(path, name)))
+		}
+	}
+
+	props, _ := s["properties"].(map[string]any)
+
+	// Visit fields in order so errors are deterministic
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := join(path, name)
+		if prop, ok := props[name].(map[string]any); ok {
+			if err := validate(prop, obj[name], field); err != nil {
+				return err
+			}
+			continue
+		}
+		switch extra := s["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				return fmt.Errorf("%s: unknown field (allowed: %s)", label(field), strings.Join(sortedKeys(props), ", "))
+			}
+		case map[string]any:
+			if err := validate(extra, obj[name], field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value has the JSON type t, which is a type name
+// or a list of names.
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []string:
+		for _, name := range t {
+			if matchesTypeName(name, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchesTypeName(name string, value any) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeOf(value) == name
+	}
+}
+
+// typeOf names the JSON type of a decoded value.
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func typeNames(t any) string {
+	if names, ok := t.([]string); ok {
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func intValue(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func label(path string) string {
+	if path == "" {
+		return "param"
+	}
+	return path
+}
@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	comment := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"issue":  map[string]any{"type": "string", "minLength": 1},
+			"body":   map[string]any{"type": "string"},
+			"labels": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"mode":   map[string]any{"type": "string", "enum": []string{"append", "replace"}},
+			"count":  map[string]any{"type": "integer", "minimum": 1, "maximum": 10},
+			"key":    map[string]any{"type": []string{"object", "string"}},
+			"checksums": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+		},
+		"required":             []string{"issue", "body"},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		name    string
+		schema  map[string]any
+		input   string
+		wantErr string
+	}{
+		{"valid", comment, `{"issue":"PROJ-1","body":"hi"}`, ""},
+		{"valid with optionals", comment, `{"issue":"PROJ-1","body":"","labels":["a"],"mode":"append","count":2,"key":{},"checksums":{"a":"b"}}`, ""},
+		{"not an object", comment, `"PROJ-1"`, "param: expected object, got string"},
+		{"missing required", comment, `{"issue":"PROJ-1"}`, "body: missing required field"},
+		{"wrong type", comment, `{"issue":1,"body":"x"}`, "issue: expected string, got number"},
+		{"empty string", comment, `{"issue":"","body":"x"}`, "issue: must not be empty"},
+		{"unknown field", comment, `{"issue":"A-1","body":"x","bdy":"y"}`, "bdy: unknown field"},
+		{"array item", comment, `{"issue":"A-1","body":"x","labels":["a",2]}`, "labels[1]: expected string, got number"},
+		{"enum", comment, `{"issue":"A-1","body":"x","mode":"merge"}`, "mode: must be one of append, replace"},
+		{"integer", comment, `{"issue":"A-1","body":"x","count":1.5}`, "count: expected integer, got number"},
+		{"below minimum", comment, `{"issue":"A-1","body":"x","count":0}`, "count: must be at least 1"},
+		{"above maximum", comment, `{"issue":"A-1","body":"x","count":11}`, "count: must be at most 10"},
+		{"type union", comment, `{"issue":"A-1","body":"x","key":true}`, "key: expected object or string, got boolean"},
+		{"additional schema", comment, `{"issue":"A-1","body":"x","checksums":{"a":1}}`, "checksums.a: expected string, got number"},
+		{"string param", map[string]any{"type": "string", "minLength": 1}, `""`, "param: must not be empty"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var value any
+			if err := json.Unmarshal([]byte(tt.input), &value); err != nil {
+				t.Fatal(err)
+			}
+			err := Validate(tt.schema, value)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
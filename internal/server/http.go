@@ -0,0 +1,575 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+const (
+	// sessionHeader carries the session ID assigned during initialize.
+	sessionHeader = "Mcp-Session-Id"
+
+	// protocolVersionHeader carries the negotiated MCP revision.
+	protocolVersionHeader = "MCP-Protocol-Version"
+
+	// maxHTTPBodySize caps a single POST body.
+	maxHTTPBodySize = 32 * 1024 * 1024
+
+	// sseKeepAlive is how often idle SSE streams receive a comment line so
+	// proxies and clients don't treat them as dead.
+	sseKeepAlive = 30 * time.Second
+
+	// sessionIdleTimeout ends sessions that see no requests and hold no
+	// streams for this long, since clients may vanish without a DELETE.
+	sessionIdleTimeout = 30 * time.Minute
+
+	// sessionSweepInterval is how often idle sessions are looked for.
+	sessionSweepInterval = time.Minute
+)
+
+// HTTPHandler serves the MCP Streamable HTTP transport on a single endpoint.
+// POST carries client messages, GET opens a server-to-client SSE stream, and
+// DELETE terminates a session.
+type HTTPHandler struct {
+	srv   *Server
+	token string
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// httpSession is the state for one MCP session over HTTP.
+type httpSession struct {
+	id   string
+	conn *conn
+
+	// ctx outlives individual HTTP requests so that a dropped connection is
+	// not mistaken for cancellation; it ends when the session is deleted.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mcp *session.Session
+
+	// lastUsed is when a request last started or finished; guarded by the
+	// handler's mu.
+	lastUsed time.Time
+
+	mu      sync.Mutex
+	streams map[chan []byte]struct{}
+}
+
+// idle reports whether the session has no request in flight and no GET
+// stream open.
+func (s *httpSession) idle() bool {
+	s.mu.Lock()
+	streams := len(s.streams)
+	s.mu.Unlock()
+	return streams == 0 && s.conn.idle()
+}
+
+// publish delivers a server-initiated message to every open GET stream and
+// reports whether any stream took it. Messages are dropped for streams that
+// are not keeping up.
+func (s *httpSession) publish(msg any) bool {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivered := false
+	for stream := range s.streams {
+		select {
+		case stream <- data:
+			delivered = true
+		default:
+		}
+	}
+	return delivered
+}
+
+// send delivers a server-initiated message outside of a POST's own stream.
+// A request no GET stream can take fails at once rather than waiting for an
+// answer that cannot come.
+func (s *httpSession) send(msg any) {
+	if s.publish(msg) {
+		return
+	}
+	if req, ok := msg.(types.Request); ok {
+		s.mcp.Undelivered(req.ID)
+	}
+}
+
+// NewHTTPHandler creates a Streamable HTTP handler. If token is non-empty,
+// every request must present it as a bearer token.
+func (s *Server) NewHTTPHandler(token string) *HTTPHandler {
+	h := &HTTPHandler{
+		srv:      s,
+		token:    token,
+		sessions: make(map[string]*httpSession),
+		stop:     make(chan struct{}),
+	}
+	go h.sweepIdle()
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="atlassian-mcp"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Reject cross-origin browser requests to prevent DNS rebinding attacks
+	if !allowedOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Close terminates all sessions and cancels their in-flight requests.
+func (h *HTTPHandler) Close() {
+	h.stopOnce.Do(func() { close(h.stop) })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, sess := range h.sessions {
+		sess.cancel()
+		delete(h.sessions, id)
+	}
+}
+
+// authorized checks the bearer token in constant time.
+func (h *HTTPHandler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) == 1
+}
+
+// allowedOrigin accepts requests without an Origin header (non-browser
+// clients) and browser requests originating from the loopback interface.
+func allowedOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	default:
+		return false
+	}
+}
+
+// handlePost processes a JSON-RPC message or batch sent by the client.
+func (h *HTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPBodySize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	raws, batch, errResp := decodeMessages(body)
+	if errResp != nil {
+		writeJSON(w, http.StatusBadRequest, errResp)
+		return
+	}
+
+	var msgs []types.Request
+	var invalid []types.Response
+	var responses []json.RawMessage
+	for _, raw := range raws {
+		req, errResp := parseMessage(raw)
+		if errResp != nil {
+			invalid = append(invalid, *errResp)
+			continue
+		}
+		if req.Method == "" {
+			responses = append(responses, raw)
+		}
+		msgs = append(msgs, req)
+	}
+	if !batch && len(invalid) == 1 {
+		writeJSON(w, http.StatusBadRequest, invalid[0])
+		return
+	}
+
+	// Clients echo the negotiated revision on every request after initialize
+	if v := r.Header.Get(protocolVersionHeader); v != "" && !session.IsSupportedProtocolVersion(v) {
+		http.Error(w, "unsupported protocol version: "+v, http.StatusBadRequest)
+		return
+	}
+
+	sess, status := h.sessionFor(r, msgs)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	defer h.touch(sess)
+	w.Header().Set(sessionHeader, sess.id)
+
+	// Responses from the client to server-initiated requests
+	for _, raw := range responses {
+		sess.mcp.HandleResponse(raw)
+	}
+
+	// Invalid batch entries are answered alongside the valid requests
+	requests := len(invalid)
+	for _, m := range msgs {
+		if m.ID != nil && m.Method != "" {
+			requests++
+		}
+	}
+
+	// Requests get their own stream so request-scoped notifications (such as
+	// progress) travel alongside the response when the client accepts SSE.
+	stream := newEventStream(requests)
+	for _, resp := range invalid {
+		stream.send(resp)
+	}
+	ctx := sess.ctx
+	useSSE := requests > 0 && acceptsSSE(r)
+	if useSSE {
+		ctx = session.WithSender(ctx, func(msg any) { stream.send(msg) })
+	}
+	send := func(resp types.Response) { stream.send(resp) }
+	if init, ok := initializeRequest(msgs); ok {
+		// A new session is registered only once initialize succeeds, just
+		// before the client learns of it, so failed handshakes leave nothing
+		// behind
+		key := requestKey(init.ID)
+		send = func(resp types.Response) {
+			if resp.Error == nil && requestKey(resp.ID) == key {
+				h.register(sess)
+			}
+			stream.send(resp)
+		}
+	}
+
+	var pending []<-chan struct{}
+	for _, m := range msgs {
+		if m.Method == "" {
+			continue
+		}
+		done := sess.conn.dispatch(ctx, m, send)
+		if m.ID != nil {
+			pending = append(pending, done)
+		}
+	}
+
+	if requests == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Close the stream once every request has finished
+	go func() {
+		for _, done := range pending {
+			<-done
+		}
+		close(stream.ch)
+	}()
+
+	if useSSE {
+		stream.writeSSE(w, r)
+		return
+	}
+
+	var collected []types.Response
+	for msg := range stream.ch {
+		if resp, ok := msg.(types.Response); ok {
+			collected = append(collected, resp)
+		}
+	}
+	switch {
+	case batch:
+		writeJSON(w, http.StatusOK, collected)
+	case len(collected) == 1:
+		writeJSON(w, http.StatusOK, collected[0])
+	default:
+		// The request was cancelled before producing a response
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// handleGet opens an SSE stream for server-initiated messages on a session.
+func (h *HTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsSSE(r) {
+		http.Error(w, "must accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	sess, status := h.lookupSession(r)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	defer h.touch(sess)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	stream := make(chan []byte, 16)
+	sess.mu.Lock()
+	sess.streams[stream] = struct{}{}
+	sess.mu.Unlock()
+	defer func() {
+		sess.mu.Lock()
+		delete(sess.streams, stream)
+		sess.mu.Unlock()
+	}()
+
+	setSSEHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sess.ctx.Done():
+			return
+		case data := <-stream:
+			writeSSEEvent(w, data)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// handleDelete terminates a session at the client's request.
+func (h *HTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess, status := h.lookupSession(r)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	h.mu.Lock()
+	delete(h.sessions, sess.id)
+	h.mu.Unlock()
+	sess.cancel()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sessionFor returns the session for a POST. An initialize request always
+// starts a new session; anything else must reference an existing one.
+func (h *HTTPHandler) sessionFor(r *http.Request, msgs []types.Request) (*httpSession, int) {
+	if _, ok := initializeRequest(msgs); ok {
+		return h.newSession(), http.StatusOK
+	}
+	return h.lookupSession(r)
+}
+
+// initializeRequest returns the initialize request among msgs, if any.
+func initializeRequest(msgs []types.Request) (types.Request, bool) {
+	for _, m := range msgs {
+		if m.Method == "initialize" {
+			return m, true
+		}
+	}
+	return types.Request{}, false
+}
+
+// lookupSession resolves the Mcp-Session-Id header. It returns 400 when the
+// header is missing and 404 when the session is unknown or expired, which
+// tells the client to re-initialize.
+func (h *HTTPHandler) lookupSession(r *http.Request) (*httpSession, int) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess, ok := h.sessions[id]
+	if !ok {
+		return nil, http.StatusNotFound
+	}
+	sess.lastUsed = time.Now()
+	return sess, http.StatusOK
+}
+
+// register makes a session reachable by its ID.
+func (h *HTTPHandler) register(sess *httpSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess.lastUsed = time.Now()
+	h.sessions[sess.id] = sess
+}
+
+// touch records that a request on sess finished, so the idle timeout runs
+// from the end of long requests and streams rather than their start.
+func (h *HTTPHandler) touch(sess *httpSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess.lastUsed = time.Now()
+}
+
+// sweepIdle periodically ends idle sessions until the handler is closed.
+func (h *HTTPHandler) sweepIdle() {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case now := <-ticker.C:
+			h.sweep(now)
+		}
+	}
+}
+
+// sweep ends the sessions that have been idle for longer than the idle
+// timeout. Clients of an ended session get 404 and re-initialize.
+func (h *HTTPHandler) sweep(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, sess := range h.sessions {
+		if now.Sub(sess.lastUsed) > sessionIdleTimeout && sess.idle() {
+			sess.cancel()
+			delete(h.sessions, id)
+		}
+	}
+}
+
+func (h *HTTPHandler) newSession() *httpSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	sess := &httpSession{
+		id:      newSessionID(),
+		conn:    h.srv.newConn(),
+		cancel:  cancel,
+		streams: make(map[chan []byte]struct{}),
+	}
+	sess.mcp = session.New(sess.send)
+	sess.ctx = session.NewContext(ctx, sess.mcp)
+	return sess
+}
+
+// newSessionID returns a cryptographically random session identifier.
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// acceptsSSE reports whether the client can receive an event stream.
+func acceptsSSE(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// eventStream carries the messages produced by one POST back to the client.
+type eventStream struct {
+	ch chan any
+	// quit is closed when the client goes away so senders never block.
+	quit chan struct{}
+}
+
+func newEventStream(size int) *eventStream {
+	return &eventStream{
+		ch:   make(chan any, size),
+		quit: make(chan struct{}),
+	}
+}
+
+func (s *eventStream) send(msg any) {
+	select {
+	case s.ch <- msg:
+	case <-s.quit:
+	}
+}
+
+// writeSSE writes each message as an SSE event as soon as it is ready.
+func (s *eventStream) writeSSE(w http.ResponseWriter, r *http.Request) {
+	defer close(s.quit)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	setSSEHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-s.ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			writeSSEEvent(w, data)
+			flusher.Flush()
+		}
+	}
+}
+
+func setSSEHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+}
+
+func writeSSEEvent(w io.Writer, data []byte) {
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
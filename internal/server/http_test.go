@@ -0,0 +1,237 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+func postMCP(t *testing.T, url, session, accept, body string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	if session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHTTPHandler_SessionLifecycle(t *testing.T) {
+	t.Parallel()
+	h := New(echoHandler, 2).NewHTTPHandler("")
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	resp := postMCP(t, ts.URL, "", "application/json", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize status = %d, want 200", resp.StatusCode)
+	}
+	session := resp.Header.Get(sessionHeader)
+	if session == "" {
+		t.Fatal("initialize did not assign a session ID")
+	}
+
+	resp = postMCP(t, ts.URL, "", "application/json", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing session status = %d, want 400", resp.StatusCode)
+	}
+
+	resp = postMCP(t, ts.URL, "unknown", "application/json", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want 404", resp.StatusCode)
+	}
+
+	resp = postMCP(t, ts.URL, session, "application/json", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, map[string]string{protocolVersionHeader: "1999-01-01"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unsupported protocol version status = %d, want 400", resp.StatusCode)
+	}
+
+	resp = postMCP(t, ts.URL, session, "application/json", `{"jsonrpc":"2.0","method":"notifications/initialized"}`, nil)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("notification status = %d, want 202", resp.StatusCode)
+	}
+
+	resp = postMCP(t, ts.URL, session, "application/json", `[{"jsonrpc":"2.0","id":3,"method":"a"},{"jsonrpc":"2.0","id":4,"method":"b"}]`, nil)
+	var batch []types.Response
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		t.Fatalf("decode batch: %v", err)
+	}
+	if len(batch) != 2 {
+		t.Errorf("batch responses = %d, want 2", len(batch))
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	req.Header.Set(sessionHeader, session)
+	delResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delResp.Body.Close()
+	if delResp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", delResp.StatusCode)
+	}
+
+	resp = postMCP(t, ts.URL, session, "application/json", `{"jsonrpc":"2.0","id":5,"method":"a"}`, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleted session status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandler_SSEResponse(t *testing.T) {
+	t.Parallel()
+	h := New(echoHandler, 2).NewHTTPHandler("")
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	resp := postMCP(t, ts.URL, "", "application/json, text/event-stream", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `data: {"jsonrpc":"2.0","id":1,"result":"initialize"}`) {
+		t.Errorf("unexpected SSE body: %q", body)
+	}
+}
+
+func TestHTTPHandler_Auth(t *testing.T) {
+	t.Parallel()
+	h := New(echoHandler, 2).NewHTTPHandler("s3cret")
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "No_Token", headers: nil, want: http.StatusUnauthorized},
+		{name: "Wrong_Token", headers: map[string]string{"Authorization": "Bearer nope"}, want: http.StatusUnauthorized},
+		{name: "Valid_Token", headers: map[string]string{"Authorization": "Bearer s3cret"}, want: http.StatusOK},
+		{name: "Foreign_Origin", headers: map[string]string{"Authorization": "Bearer s3cret", "Origin": "https://evil.example"}, want: http.StatusForbidden},
+		{name: "Local_Origin", headers: map[string]string{"Authorization": "Bearer s3cret", "Origin": "http://localhost:3000"}, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := postMCP(t, ts.URL, "", "application/json", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, tt.headers)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_SessionRegistration(t *testing.T) {
+	t.Parallel()
+	handle := func(ctx context.Context, req types.Request) types.Response {
+		if req.Method == "initialize" && string(req.Params) == `{"fail":true}` {
+			return types.Response{JSONRPC: "2.0", ID: req.ID, Error: &types.Error{Code: -32602, Message: "Invalid params"}}
+		}
+		return echoHandler(ctx, req)
+	}
+	h := New(handle, 2).NewHTTPHandler("")
+	t.Cleanup(h.Close)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		want    int
+	}{
+		{"failed initialize", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"fail":true}}`, nil, http.StatusOK},
+		{"unsupported protocol version", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, map[string]string{protocolVersionHeader: "1999-01-01"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp := postMCP(t, ts.URL, "", "application/json", tt.body, tt.headers)
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s status = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+		if session := resp.Header.Get(sessionHeader); session != "" {
+			resp = postMCP(t, ts.URL, session, "application/json", `{"jsonrpc":"2.0","id":2,"method":"a"}`, nil)
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("%s: session status = %d, want 404", tt.name, resp.StatusCode)
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.sessions) != 0 {
+		t.Errorf("sessions = %d, want none after failed handshakes", len(h.sessions))
+	}
+}
+
+func TestHTTPHandler_SessionIdleTimeout(t *testing.T) {
+	t.Parallel()
+	h := New(echoHandler, 2).NewHTTPHandler("")
+	t.Cleanup(h.Close)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	resp := postMCP(t, ts.URL, "", "application/json", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, nil)
+	session := resp.Header.Get(sessionHeader)
+
+	h.sweep(time.Now().Add(sessionIdleTimeout / 2))
+	resp = postMCP(t, ts.URL, session, "application/json", `{"jsonrpc":"2.0","id":2,"method":"a"}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("session status before the idle timeout = %d, want 200", resp.StatusCode)
+	}
+
+	h.sweep(time.Now().Add(sessionIdleTimeout + time.Second))
+	resp = postMCP(t, ts.URL, session, "application/json", `{"jsonrpc":"2.0","id":3,"method":"a"}`, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("session status after the idle timeout = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandler_RequestWithoutStream(t *testing.T) {
+	t.Parallel()
+	// The handler asks the client something while serving a plain JSON POST
+	handle := func(ctx context.Context, req types.Request) types.Response {
+		if req.Method == "ask" {
+			_, err := session.Request(ctx, "elicitation/create", nil)
+			return types.Response{JSONRPC: "2.0", ID: req.ID, Result: fmt.Sprint(err)}
+		}
+		return echoHandler(ctx, req)
+	}
+	h := New(handle, 2).NewHTTPHandler("")
+	t.Cleanup(h.Close)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	resp := postMCP(t, ts.URL, "", "application/json", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, nil)
+	sessionID := resp.Header.Get(sessionHeader)
+
+	resp = postMCP(t, ts.URL, sessionID, "application/json", `{"jsonrpc":"2.0","id":2,"method":"ask"}`, nil)
+	var got types.Response
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got.Result != session.ErrUndelivered.Error() {
+		t.Errorf("result = %v, want %q", got.Result, session.ErrUndelivered)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"atlassian-mcp/internal/types"
+)
+
+// JSON-RPC 2.0 error codes for malformed input.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+)
+
+// decodeMessages splits a JSON-RPC payload into its messages. A payload is a
+// single message or a batch array. It returns an error response when the
+// payload is not valid JSON or is an empty batch.
+func decodeMessages(data []byte) (msgs []json.RawMessage, batch bool, errResp *types.Response) {
+	trimmed := bytes.TrimSpace(data)
+	if !json.Valid(trimmed) {
+		return nil, false, errorResponse(nil, codeParseError, "Parse error")
+	}
+
+	if trimmed[0] != '[' {
+		return []json.RawMessage{trimmed}, false, nil
+	}
+	if err := json.Unmarshal(trimmed, &msgs); err != nil {
+		return nil, true, errorResponse(nil, codeParseError, "Parse error")
+	}
+	if len(msgs) == 0 {
+		return nil, true, errorResponse(nil, codeInvalidRequest, "Invalid Request: empty batch")
+	}
+	return msgs, true, nil
+}
+
+// parseMessage validates one message. Requests and notifications are
+// returned with their method set; responses to server-initiated requests are
+// returned with an empty method. Anything else yields an Invalid Request
+// error response.
+func parseMessage(raw json.RawMessage) (types.Request, *types.Response) {
+	var msg struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  json.RawMessage `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		Result  json.RawMessage `json:"result"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return types.Request{}, errorResponse(nil, codeInvalidRequest, "Invalid Request: not an object")
+	}
+
+	id, ok := decodeID(msg.ID)
+	if !ok {
+		return types.Request{}, errorResponse(nil, codeInvalidRequest, "Invalid Request: id must be a string or number")
+	}
+	if msg.JSONRPC != "2.0" {
+		return types.Request{}, errorResponse(id, codeInvalidRequest, `Invalid Request: jsonrpc must be "2.0"`)
+	}
+
+	if msg.Method == nil {
+		if msg.Result == nil && msg.Error == nil {
+			return types.Request{}, errorResponse(id, codeInvalidRequest, "Invalid Request: missing method")
+		}
+		return types.Request{JSONRPC: msg.JSONRPC, ID: id}, nil
+	}
+
+	var method string
+	if err := json.Unmarshal(msg.Method, &method); err != nil || method == "" {
+		return types.Request{}, errorResponse(id, codeInvalidRequest, "Invalid Request: method must be a non-empty string")
+	}
+	if p := bytes.TrimSpace(msg.Params); len(p) > 0 && p[0] != '{' && p[0] != '[' && !bytes.Equal(p, []byte("null")) {
+		return types.Request{}, errorResponse(id, codeInvalidRequest, "Invalid Request: params must be an object or array")
+	}
+
+	return types.Request{JSONRPC: msg.JSONRPC, ID: id, Method: method, Params: msg.Params}, nil
+}
+
+// decodeID decodes a request ID, which must be a string, a number, null, or
+// absent.
+func decodeID(raw json.RawMessage) (any, bool) {
+	if raw == nil {
+		return nil, true
+	}
+	var id any
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return nil, false
+	}
+	switch id.(type) {
+	case nil, string, float64:
+		return id, true
+	default:
+		return nil, false
+	}
+}
+
+func errorResponse(id any, code int, message string) *types.Response {
+	return &types.Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &types.Error{Code: code, Message: message},
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"atlassian-mcp/internal/types"
+)
+
+func TestParseMessage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		input      string
+		wantMethod string
+		wantCode   int
+	}{
+		{"request", `{"jsonrpc":"2.0","id":1,"method":"a","params":{}}`, "a", 0},
+		{"notification", `{"jsonrpc":"2.0","method":"n"}`, "n", 0},
+		{"client response", `{"jsonrpc":"2.0","id":1,"result":{}}`, "", 0},
+		{"not an object", `42`, "", codeInvalidRequest},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"method":"a"}`, "", codeInvalidRequest},
+		{"missing method", `{"jsonrpc":"2.0","id":1}`, "", codeInvalidRequest},
+		{"non-string method", `{"jsonrpc":"2.0","id":1,"method":7}`, "", codeInvalidRequest},
+		{"object id", `{"jsonrpc":"2.0","id":{},"method":"a"}`, "", codeInvalidRequest},
+		{"scalar params", `{"jsonrpc":"2.0","id":1,"method":"a","params":"x"}`, "", codeInvalidRequest},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req, errResp := parseMessage(json.RawMessage(tt.input))
+			if tt.wantCode != 0 {
+				if errResp == nil || errResp.Error.Code != tt.wantCode {
+					t.Fatalf("parseMessage() error = %+v, want code %d", errResp, tt.wantCode)
+				}
+				return
+			}
+			if errResp != nil {
+				t.Fatalf("parseMessage() error = %+v", errResp.Error)
+			}
+			if req.Method != tt.wantMethod {
+				t.Errorf("method = %q, want %q", req.Method, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestServe_Framing(t *testing.T) {
+	t.Parallel()
+	// Larger than the old 1MB line cap
+	big := strings.Repeat("x", 2*1024*1024)
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"` + big + `"}`,
+		`{"jsonrpc":"2.0","id":2,"method":`,
+		`{"jsonrpc":"2.0","id":3}`,
+		`[]`,
+		`[{"jsonrpc":"2.0","id":4,"method":"a"},{"jsonrpc":"2.0","method":"n"},{"bad":true},{"jsonrpc":"2.0","id":5,"method":"b"}]`,
+		`[{"jsonrpc":"2.0","method":"n"}]`,
+		``,
+	}, "\n")
+
+	var out syncBuffer
+	if err := New(echoHandler, 2).Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var singles []types.Response
+	var batches [][]types.Response
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if strings.HasPrefix(line, "[") {
+			var batch []types.Response
+			if err := json.Unmarshal([]byte(line), &batch); err != nil {
+				t.Fatalf("invalid batch response: %v", err)
+			}
+			batches = append(batches, batch)
+			continue
+		}
+		var resp types.Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		singles = append(singles, resp)
+	}
+
+	codes := map[int]int{}
+	for _, resp := range singles {
+		if resp.Error != nil {
+			codes[resp.Error.Code]++
+		} else if resp.Result != big {
+			t.Errorf("unexpected result for id %v", resp.ID)
+		}
+	}
+	if len(singles) != 4 || codes[codeParseError] != 1 || codes[codeInvalidRequest] != 2 {
+		t.Errorf("got %d single responses with error codes %v, want 4 with one parse error and two invalid requests", len(singles), codes)
+	}
+
+	if len(batches) != 1 {
+		t.Fatalf("got %d batch responses, want 1 (notification-only batches get none)", len(batches))
+	}
+	if len(batches[0]) != 3 {
+		t.Errorf("batch has %d responses, want 3", len(batches[0]))
+	}
+}
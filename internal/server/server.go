@@ -0,0 +1,284 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sync"
+
+	"atlassian-mcp/internal/logging"
+	"atlassian-mcp/internal/session"
+	"atlassian-mcp/internal/types"
+)
+
+// HandlerFunc processes a single MCP request and returns its response.
+// Notifications return an empty Response.
+type HandlerFunc func(ctx context.Context, req types.Request) types.Response
+
+// Server dispatches MCP requests concurrently on a bounded worker pool that
+// is shared by every connection. Each request runs with its own context so
+// that notifications/cancelled can abort in-flight Atlassian API calls.
+type Server struct {
+	handle  HandlerFunc
+	workers chan struct{}
+}
+
+// New creates a Server that runs at most maxWorkers requests at once.
+func New(handle HandlerFunc, maxWorkers int) *Server {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+	return &Server{
+		handle:  handle,
+		workers: make(chan struct{}, maxWorkers),
+	}
+}
+
+// conn holds per-client dispatch state. Request IDs are only unique within a
+// connection, so cancellation is tracked here rather than on the Server.
+type conn struct {
+	srv *Server
+
+	mu       sync.Mutex
+	inFlight map[string]*call
+
+	wg sync.WaitGroup
+}
+
+// call tracks an in-flight request so it can be cancelled by ID.
+type call struct {
+	cancel context.CancelFunc
+}
+
+func (s *Server) newConn() *conn {
+	return &conn{
+		srv:      s,
+		inFlight: make(map[string]*call),
+	}
+}
+
+// idle reports whether no request is in flight on the connection.
+func (c *conn) idle() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.inFlight) == 0
+}
+
+// Serve reads newline-delimited JSON-RPC messages and batches from r and
+// writes responses to w until r is exhausted. Lines have no length limit.
+// Malformed input is answered with a Parse error or Invalid Request response
+// rather than dropped. Serve waits for in-flight requests before returning.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	c := s.newConn()
+	out := &lineWriter{w: w}
+	ctx = session.NewContext(ctx, session.New(func(msg any) { out.writeMessage(msg) }))
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			c.serveLine(ctx, line, out)
+		}
+		if err != nil {
+			c.wg.Wait()
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// serveLine handles one line of input, which holds a message or a batch.
+func (c *conn) serveLine(ctx context.Context, line []byte, out *lineWriter) {
+	raws, batch, errResp := decodeMessages(line)
+	if errResp != nil {
+		out.write(*errResp)
+		return
+	}
+
+	if !batch {
+		req, errResp := parseMessage(raws[0])
+		switch {
+		case errResp != nil:
+			out.write(*errResp)
+		case req.Method != "":
+			c.dispatch(ctx, req, out.write)
+		default:
+			session.FromContext(ctx).HandleResponse(raws[0])
+		}
+		return
+	}
+
+	// Batch responses are written together once every request has finished
+	var mu sync.Mutex
+	var responses []types.Response
+	collect := func(resp types.Response) {
+		if isEmpty(resp) {
+			return
+		}
+		mu.Lock()
+		responses = append(responses, resp)
+		mu.Unlock()
+	}
+
+	var pending []<-chan struct{}
+	for _, raw := range raws {
+		req, errResp := parseMessage(raw)
+		switch {
+		case errResp != nil:
+			collect(*errResp)
+		case req.Method != "":
+			pending = append(pending, c.dispatch(ctx, req, collect))
+		default:
+			session.FromContext(ctx).HandleResponse(raw)
+		}
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for _, done := range pending {
+			<-done
+		}
+		// A batch of notifications gets no response at all
+		if len(responses) > 0 {
+			out.writeMessage(responses)
+		}
+	}()
+}
+
+// dispatch runs req and delivers its response through send. Requests are
+// handled on a goroutine; notifications are handled inline since they are
+// cheap and must be applied in arrival order. The returned channel is closed
+// once the request has finished, whether or not a response was sent.
+func (c *conn) dispatch(ctx context.Context, req types.Request, send func(types.Response)) <-chan struct{} {
+	done := make(chan struct{})
+
+	if req.Method == "notifications/cancelled" {
+		c.cancelRequest(req.Params)
+		close(done)
+		return done
+	}
+
+	if req.ID == nil {
+		c.srv.run(ctx, req)
+		close(done)
+		return done
+	}
+
+	key := requestKey(req.ID)
+	reqCtx, cancel := context.WithCancel(ctx)
+	cl := &call{cancel: cancel}
+
+	c.mu.Lock()
+	c.inFlight[key] = cl
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(done)
+		defer c.finish(key, cl)
+
+		// Wait for a worker slot, giving up if cancelled while queued
+		select {
+		case c.srv.workers <- struct{}{}:
+		case <-reqCtx.Done():
+			return
+		}
+		defer func() { <-c.srv.workers }()
+
+		resp := c.srv.run(reqCtx, req)
+
+		// Cancelled requests must not receive a response
+		if reqCtx.Err() != nil {
+			return
+		}
+		send(resp)
+	}()
+
+	return done
+}
+
+// run invokes the handler, converting a panic into an internal error response
+// so one bad request or notification cannot take down the whole server.
+func (s *Server) run(ctx context.Context, req types.Request) (resp types.Response) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "panic handling request",
+				"method", req.Method, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			resp = types.Response{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &types.Error{Code: -32603, Message: "Internal error"},
+			}
+		}
+	}()
+	return s.handle(ctx, req)
+}
+
+// finish removes a completed request from the in-flight table.
+func (c *conn) finish(key string, cl *call) {
+	cl.cancel()
+	c.mu.Lock()
+	if c.inFlight[key] == cl {
+		delete(c.inFlight, key)
+	}
+	c.mu.Unlock()
+}
+
+// cancelRequest handles notifications/cancelled by cancelling the matching request.
+func (c *conn) cancelRequest(raw json.RawMessage) {
+	var params types.CancelledParams
+	if err := json.Unmarshal(raw, &params); err != nil || params.RequestID == nil {
+		return
+	}
+
+	c.mu.Lock()
+	cl, ok := c.inFlight[requestKey(params.RequestID)]
+	c.mu.Unlock()
+	if ok {
+		cl.cancel()
+	}
+}
+
+// requestKey normalizes a JSON-RPC ID so that 1 and "1" stay distinct.
+func requestKey(id any) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// lineWriter serializes outgoing messages as newline-delimited JSON.
+type lineWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lineWriter) write(resp types.Response) {
+	if isEmpty(resp) {
+		return
+	}
+	lw.writeMessage(resp)
+}
+
+// isEmpty reports whether resp is the empty Response that handlers return
+// for notifications.
+func isEmpty(resp types.Response) bool {
+	return resp.ID == nil && resp.Result == nil && resp.Error == nil
+}
+
+func (lw *lineWriter) writeMessage(msg any) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.w.Write(append(data, '\n'))
+}
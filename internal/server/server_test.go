@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"atlassian-mcp/internal/types"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func echoHandler(ctx context.Context, req types.Request) types.Response {
+	if req.ID == nil {
+		return types.Response{}
+	}
+	return types.Response{JSONRPC: "2.0", ID: req.ID, Result: req.Method}
+}
+
+func TestServe_RespondsToEachRequest(t *testing.T) {
+	t.Parallel()
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"a"}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":"2","method":"b"}`,
+		``,
+	}, "\n")
+
+	var out syncBuffer
+	srv := New(echoHandler, 2)
+	if err := srv.Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d responses, want 2: %q", len(lines), out.String())
+	}
+	for _, line := range lines {
+		var resp types.Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Errorf("invalid response line %q: %v", line, err)
+		}
+	}
+}
+
+func TestDispatch_BoundsConcurrency(t *testing.T) {
+	t.Parallel()
+	var running, peak int32
+	handle := func(ctx context.Context, req types.Request) types.Response {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return types.Response{JSONRPC: "2.0", ID: req.ID}
+	}
+
+	c := New(handle, 3).newConn()
+	var sent int32
+	for i := range 10 {
+		c.dispatch(context.Background(), types.Request{ID: i, Method: "x"}, func(types.Response) {
+			atomic.AddInt32(&sent, 1)
+		})
+	}
+	c.wg.Wait()
+
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", peak)
+	}
+	if sent != 10 {
+		t.Errorf("sent = %d, want 10", sent)
+	}
+}
+
+func TestDispatch_NotificationPanic(t *testing.T) {
+	t.Parallel()
+	handle := func(ctx context.Context, req types.Request) types.Response {
+		if req.ID == nil {
+			panic("bad notification")
+		}
+		return types.Response{JSONRPC: "2.0", ID: req.ID}
+	}
+
+	c := New(handle, 1).newConn()
+	<-c.dispatch(context.Background(), types.Request{Method: "notifications/initialized"}, nil)
+
+	var sent atomic.Bool
+	c.dispatch(context.Background(), types.Request{ID: 1, Method: "x"}, func(types.Response) {
+		sent.Store(true)
+	})
+	c.wg.Wait()
+	if !sent.Load() {
+		t.Error("request after a panicking notification got no response")
+	}
+}
+
+func TestDispatch_CancelledRequest(t *testing.T) {
+	t.Parallel()
+	started := make(chan struct{})
+	var sawCancel atomic.Bool
+	handle := func(ctx context.Context, req types.Request) types.Response {
+		close(started)
+		<-ctx.Done()
+		sawCancel.Store(true)
+		return types.Response{JSONRPC: "2.0", ID: req.ID, Result: "late"}
+	}
+
+	c := New(handle, 1).newConn()
+	var sent atomic.Bool
+	c.dispatch(context.Background(), types.Request{ID: float64(7), Method: "slow"}, func(types.Response) {
+		sent.Store(true)
+	})
+	<-started
+
+	c.dispatch(context.Background(), types.Request{
+		Method: "notifications/cancelled",
+		Params: json.RawMessage(`{"requestId":7,"reason":"user aborted"}`),
+	}, nil)
+	c.wg.Wait()
+
+	if !sawCancel.Load() {
+		t.Error("handler context was not cancelled")
+	}
+	if sent.Load() {
+		t.Error("cancelled request should not receive a response")
+	}
+}
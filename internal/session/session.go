@@ -0,0 +1,353 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"atlassian-mcp/internal/types"
+)
+
+// Sender delivers a server-initiated message to the client: a
+// types.Notification, or a types.Request sent by Request.
+type Sender func(msg any)
+
+// ErrNoSession is returned by Request when the context has no session to
+// send through.
+var ErrNoSession = errors.New("no client session")
+
+// ErrUndelivered is returned by Request when the transport could not deliver
+// the request, such as an HTTP client with no stream open to receive it.
+var ErrUndelivered = errors.New("no open stream to the client")
+
+// SupportedProtocolVersions lists the MCP revisions this server speaks,
+// newest first.
+var SupportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// structuredContentVersion is the first revision with structuredContent and
+// outputSchema on tools.
+const structuredContentVersion = "2025-06-18"
+
+// NegotiateProtocolVersion returns requested if this server supports it and
+// the newest supported revision otherwise, as the spec prescribes.
+func NegotiateProtocolVersion(requested string) string {
+	if IsSupportedProtocolVersion(requested) {
+		return requested
+	}
+	return SupportedProtocolVersions[0]
+}
+
+// IsSupportedProtocolVersion reports whether v is a revision this server speaks.
+func IsSupportedProtocolVersion(v string) bool {
+	for _, supported := range SupportedProtocolVersions {
+		if v == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Session is the server's view of one connected MCP client. It is attached to
+// the context of every request the client makes.
+type Session struct {
+	send Sender
+
+	mu                 sync.Mutex
+	protocolVersion    string
+	clientCapabilities map[string]any
+	logLevel           *slog.Level
+
+	// Requests sent to the client, keyed by ID, awaiting a response
+	nextID  int64
+	pending map[string]chan clientResponse
+}
+
+// clientResponse is the client's answer to a server-initiated request.
+type clientResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *types.Error    `json:"error"`
+
+	// err is set when the request never reached the client.
+	err error
+}
+
+// New creates a Session whose notifications are delivered through send.
+func New(send Sender) *Session {
+	return &Session{send: send, pending: make(map[string]chan clientResponse)}
+}
+
+// SetProtocolVersion records the revision negotiated during initialize.
+func (s *Session) SetProtocolVersion(v string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.protocolVersion = v
+	s.mu.Unlock()
+}
+
+// ProtocolVersion returns the negotiated revision. Sessions that have not
+// been initialized, and requests without a session, get the oldest revision.
+func (s *Session) ProtocolVersion() string {
+	oldest := SupportedProtocolVersions[len(SupportedProtocolVersions)-1]
+	if s == nil {
+		return oldest
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.protocolVersion == "" {
+		return oldest
+	}
+	return s.protocolVersion
+}
+
+// SupportsStructuredContent reports whether tool results may carry
+// structuredContent and tools may declare an outputSchema.
+func (s *Session) SupportsStructuredContent() bool {
+	// Revisions are dates, so they order lexically
+	return s.ProtocolVersion() >= structuredContentVersion
+}
+
+// SetClientCapabilities records the capabilities the client declared in
+// initialize.
+func (s *Session) SetClientCapabilities(caps map[string]any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.clientCapabilities = caps
+	s.mu.Unlock()
+}
+
+// SupportsElicitation reports whether the client can answer
+// elicitation/create requests.
+func (s *Session) SupportsElicitation() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.clientCapabilities["elicitation"]
+	return ok
+}
+
+// SetLogLevel records the minimum level of log messages the client wants,
+// as requested with logging/setLevel.
+func (s *Session) SetLogLevel(level slog.Level) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.logLevel = &level
+	s.mu.Unlock()
+}
+
+// LogLevel returns the level set by the client. It reports false until the
+// client calls logging/setLevel, and for requests without a session.
+func (s *Session) LogLevel() (slog.Level, bool) {
+	if s == nil {
+		return 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.logLevel == nil {
+		return 0, false
+	}
+	return *s.logLevel, true
+}
+
+type sessionKey struct{}
+type senderKey struct{}
+type progressKey struct{}
+
+// NewContext returns a context carrying s.
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// FromContext returns the Session attached to ctx, or nil.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// WithSender routes messages emitted while handling a request through send
+// instead of the session default. Transports use this to deliver
+// request-scoped notifications on the same stream as the response.
+func WithSender(ctx context.Context, send Sender) context.Context {
+	return context.WithValue(ctx, senderKey{}, send)
+}
+
+// Notify sends a notification to the client that issued the current request.
+// It is a no-op when no session is attached.
+func Notify(ctx context.Context, method string, params any) {
+	send(ctx, types.Notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// send delivers msg through the request-scoped sender, or the session default.
+func send(ctx context.Context, msg any) bool {
+	if fn, ok := ctx.Value(senderKey{}).(Sender); ok && fn != nil {
+		fn(msg)
+		return true
+	}
+	if s := FromContext(ctx); s != nil && s.send != nil {
+		s.send(msg)
+		return true
+	}
+	return false
+}
+
+// Request sends a request to the client that issued the current request and
+// waits for its result. If ctx ends first, the client is told to cancel.
+func Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	s := FromContext(ctx)
+	if s == nil {
+		return nil, ErrNoSession
+	}
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s params: %w", method, err)
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	ch := make(chan clientResponse, 1)
+	s.pending[responseKey(id)] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, responseKey(id))
+		s.mu.Unlock()
+	}()
+
+	if !send(ctx, types.Request{JSONRPC: "2.0", ID: id, Method: method, Params: rawParams}) {
+		return nil, ErrNoSession
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.err != nil {
+			return nil, resp.err
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s failed: %s", method, resp.Error.Message)
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		Notify(context.WithoutCancel(ctx), "notifications/cancelled", types.CancelledParams{RequestID: id, Reason: ctx.Err().Error()})
+		return nil, ctx.Err()
+	}
+}
+
+// HandleResponse delivers a client response to the Request awaiting it. It
+// reports false when no request is waiting for the response's ID.
+func (s *Session) HandleResponse(raw json.RawMessage) bool {
+	if s == nil {
+		return false
+	}
+	var resp struct {
+		ID any `json:"id"`
+		clientResponse
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return false
+	}
+	return s.deliver(resp.ID, resp.clientResponse)
+}
+
+// Undelivered fails the Request awaiting id with ErrUndelivered, for
+// transports that find no way to send it. Senders may call it from within
+// the send.
+func (s *Session) Undelivered(id any) {
+	if s == nil {
+		return
+	}
+	s.deliver(id, clientResponse{err: ErrUndelivered})
+}
+
+// deliver hands resp to the Request awaiting id, if any.
+func (s *Session) deliver(id any, resp clientResponse) bool {
+	s.mu.Lock()
+	ch, ok := s.pending[responseKey(id)]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	// A duplicate response must not block the transport
+	select {
+	case ch <- resp:
+	default:
+	}
+	return true
+}
+
+// responseKey normalizes a request ID so the int sent and the float64
+// decoded from the client's response match.
+func responseKey(id any) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// progressState tracks progress for one request. Steps may be discovered
+// while the request runs, so the total can grow.
+type progressState struct {
+	token any
+
+	mu       sync.Mutex
+	progress float64
+	total    float64
+
+	// sendMu is taken before mu is released, so notifications are sent in
+	// the order their steps were counted without holding mu while sending.
+	sendMu sync.Mutex
+}
+
+// WithProgressToken enables progress reporting for the current request.
+// A nil token leaves ctx unchanged.
+func WithProgressToken(ctx context.Context, token any) context.Context {
+	if token == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, &progressState{token: token})
+}
+
+// ExpectSteps adds n steps to the total for the current request.
+func ExpectSteps(ctx context.Context, n int) {
+	p, ok := ctx.Value(progressKey{}).(*progressState)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	p.total += float64(n)
+	p.mu.Unlock()
+}
+
+// Progress marks one step as complete and emits notifications/progress with
+// message. It is a no-op unless the client supplied a progressToken.
+func Progress(ctx context.Context, message string) {
+	p, ok := ctx.Value(progressKey{}).(*progressState)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	p.progress++
+	if p.total < p.progress {
+		p.total = p.progress
+	}
+	params := types.ProgressParams{
+		ProgressToken: p.token,
+		Progress:      p.progress,
+		Total:         p.total,
+		Message:       message,
+	}
+	p.sendMu.Lock()
+	p.mu.Unlock()
+	defer p.sendMu.Unlock()
+
+	Notify(ctx, "notifications/progress", params)
+}
@@ -0,0 +1,228 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"atlassian-mcp/internal/types"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+	var got []types.ProgressParams
+	s := New(func(msg any) {
+		n := msg.(types.Notification)
+		if n.Method != "notifications/progress" {
+			t.Errorf("method = %q, want notifications/progress", n.Method)
+		}
+		got = append(got, n.Params.(types.ProgressParams))
+	})
+
+	ctx := WithProgressToken(NewContext(context.Background(), s), "tok")
+	ExpectSteps(ctx, 2)
+	Progress(ctx, "first")
+	Progress(ctx, "second")
+	Progress(ctx, "unplanned")
+
+	want := []types.ProgressParams{
+		{ProgressToken: "tok", Progress: 1, Total: 2, Message: "first"},
+		{ProgressToken: "tok", Progress: 2, Total: 2, Message: "second"},
+		{ProgressToken: "tok", Progress: 3, Total: 3, Message: "unplanned"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d notifications, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("notification %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestProgress_Concurrent(t *testing.T) {
+	t.Parallel()
+	var got []float64
+	s := New(func(msg any) {
+		got = append(got, msg.(types.Notification).Params.(types.ProgressParams).Progress)
+	})
+	ctx := WithProgressToken(NewContext(context.Background(), s), "tok")
+
+	const steps = 50
+	ExpectSteps(ctx, steps)
+	var wg sync.WaitGroup
+	for range steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Progress(ctx, "step")
+		}()
+	}
+	wg.Wait()
+
+	if len(got) != steps {
+		t.Fatalf("got %d notifications, want %d", len(got), steps)
+	}
+	for i, progress := range got {
+		if progress != float64(i+1) {
+			t.Fatalf("notification %d has progress %v, want %d: %v", i, progress, i+1, got)
+		}
+	}
+}
+
+func TestProgress_NoToken(t *testing.T) {
+	t.Parallel()
+	s := New(func(msg any) {
+		n := msg.(types.Notification)
+		t.Errorf("unexpected notification %q", n.Method)
+	})
+	ctx := WithProgressToken(NewContext(context.Background(), s), nil)
+	ExpectSteps(ctx, 1)
+	Progress(ctx, "ignored")
+}
+
+func TestNotify_RequestSender(t *testing.T) {
+	t.Parallel()
+	var sessionCount, requestCount int
+	s := New(func(any) { sessionCount++ })
+	ctx := NewContext(context.Background(), s)
+
+	Notify(ctx, "a", nil)
+	Notify(WithSender(ctx, func(any) { requestCount++ }), "b", nil)
+
+	if sessionCount != 1 || requestCount != 1 {
+		t.Errorf("session = %d, request = %d, want 1 and 1", sessionCount, requestCount)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	t.Parallel()
+	var s *Session
+	s = New(func(msg any) {
+		req := msg.(types.Request)
+		if req.Method != "elicitation/create" {
+			t.Errorf("method = %q, want elicitation/create", req.Method)
+		}
+		// Answer as a client would, with the ID decoded as a float64
+		go s.HandleResponse(json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"action":"accept"}}`, float64(req.ID.(int64)))))
+	})
+
+	got, err := Request(NewContext(context.Background(), s), "elicitation/create", map[string]any{})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if string(got) != `{"action":"accept"}` {
+		t.Errorf("Request() = %s", got)
+	}
+	if s.HandleResponse(json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{}}`)) {
+		t.Error("HandleResponse() delivered a response to a finished request")
+	}
+}
+
+func TestRequest_Undelivered(t *testing.T) {
+	t.Parallel()
+	var s *Session
+	s = New(func(msg any) { s.Undelivered(msg.(types.Request).ID) })
+	if _, err := Request(NewContext(context.Background(), s), "elicitation/create", nil); !errors.Is(err, ErrUndelivered) {
+		t.Errorf("Request() error = %v, want ErrUndelivered", err)
+	}
+}
+
+func TestRequest_Error(t *testing.T) {
+	t.Parallel()
+	var s *Session
+	s = New(func(msg any) {
+		id := msg.(types.Request).ID
+		go s.HandleResponse(json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"Method not found"}}`, id)))
+	})
+	if _, err := Request(NewContext(context.Background(), s), "elicitation/create", nil); err == nil {
+		t.Error("Request() error = nil, want the client error")
+	}
+}
+
+func TestRequest_Cancelled(t *testing.T) {
+	t.Parallel()
+	var methods []string
+	s := New(func(msg any) {
+		switch m := msg.(type) {
+		case types.Request:
+			methods = append(methods, m.Method)
+		case types.Notification:
+			methods = append(methods, m.Method)
+		}
+	})
+	ctx, cancel := context.WithCancel(NewContext(context.Background(), s))
+	cancel()
+
+	if _, err := Request(ctx, "elicitation/create", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Request() error = %v, want context.Canceled", err)
+	}
+	if len(methods) != 2 || methods[1] != "notifications/cancelled" {
+		t.Errorf("sent %v, want the request then notifications/cancelled", methods)
+	}
+}
+
+func TestRequest_NoSession(t *testing.T) {
+	t.Parallel()
+	if _, err := Request(context.Background(), "elicitation/create", nil); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Request() error = %v, want ErrNoSession", err)
+	}
+}
+
+func TestSupportsElicitation(t *testing.T) {
+	t.Parallel()
+	s := New(nil)
+	if s.SupportsElicitation() {
+		t.Error("SupportsElicitation() = true before initialize")
+	}
+	s.SetClientCapabilities(map[string]any{"elicitation": map[string]any{}})
+	if !s.SupportsElicitation() {
+		t.Error("SupportsElicitation() = false after the client declared it")
+	}
+}
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		requested  string
+		want       string
+		structured bool
+	}{
+		{"latest", "2025-06-18", "2025-06-18", true},
+		{"older supported", "2025-03-26", "2025-03-26", false},
+		{"oldest supported", "2024-11-05", "2024-11-05", false},
+		{"newer than supported", "2099-01-01", "2025-06-18", true},
+		{"unknown", "draft", "2025-06-18", true},
+		{"empty", "", "2025-06-18", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := NegotiateProtocolVersion(tt.requested)
+			if got != tt.want {
+				t.Errorf("NegotiateProtocolVersion(%q) = %q, want %q", tt.requested, got, tt.want)
+			}
+			s := New(nil)
+			s.SetProtocolVersion(got)
+			if s.SupportsStructuredContent() != tt.structured {
+				t.Errorf("SupportsStructuredContent() = %v, want %v", !tt.structured, tt.structured)
+			}
+		})
+	}
+}
+
+func TestProtocolVersion_NoSession(t *testing.T) {
+	t.Parallel()
+	var s *Session
+	if got := s.ProtocolVersion(); got != "2024-11-05" {
+		t.Errorf("ProtocolVersion() = %q, want 2024-11-05", got)
+	}
+	if s.SupportsStructuredContent() {
+		t.Error("SupportsStructuredContent() = true without a session")
+	}
+}
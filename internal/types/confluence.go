@@ -6,22 +6,25 @@ package types
 type ConfluenceCreatePageParams struct {
 	SpaceID  string `json:"spaceId"`
 	Title    string `json:"title"`
-	Body     string `json:"body"`     // Markdown content
-	ParentID string `json:"parentId"` // Optional parent page ID
+	Body     string `json:"body"`             // Markdown content
+	ParentID string `json:"parentId"`         // Optional parent page ID
+	DryRun   bool   `json:"dryRun,omitempty"` // Validate and preview without writing
 }
 
 // ConfluenceUpdatePageParams represents parameters for updating a Confluence page.
 type ConfluenceUpdatePageParams struct {
 	PageID    string            `json:"pageId"`
-	Title     string            `json:"title"`     // Optional, empty means no change
-	Body      string            `json:"body"`      // Optional, empty means no change
-	Checksums map[string]string `json:"checksums"` // Required for conflict detection
+	Title     string            `json:"title"`            // Optional, empty means no change
+	Body      string            `json:"body"`             // Optional, empty means no change
+	Checksums map[string]string `json:"checksums"`        // Required for conflict detection
+	DryRun    bool              `json:"dryRun,omitempty"` // Validate and preview without writing
 }
 
 // ConfluenceAddCommentParams represents parameters for adding a comment to a Confluence page.
 type ConfluenceAddCommentParams struct {
 	PageID string `json:"pageId"`
-	Body   string `json:"body"` // Markdown content
+	Body   string `json:"body"`             // Markdown content
+	DryRun bool   `json:"dryRun,omitempty"` // Validate and preview without writing
 }
 
 // ConfluenceAttachmentInfo represents metadata from a Confluence attachment upload.
@@ -32,32 +35,6 @@ type ConfluenceAttachmentInfo struct {
 	FileSize int64  `json:"fileSize"`
 }
 
-// ConfluenceReadVerbHelp maps read verbs to their help text.
-var ConfluenceReadVerbHelp = map[string]string{
-	"get_page": `Get page content. Param: page ID or URL
-
-Returns: title, status, space, author, version, body (as markdown), checksums.
-
-Roundtrip formats in output (copy into confluence_update_page):
-- Mentions: @[Name](accountId:xxx)
-- Dates: {date:2024-01-15}
-- Status: {status:TODO|color=blue}
-
-Returns __CHECKSUMS__ section with SHA256 hashes for: title, body, version.
-Required for confluence_update_page.`,
-
-	"get_comments": `Get page comments. Param: page ID or URL
-
-Returns all comments with author, timestamp, and body in markdown.`,
-
-	"search": `Search pages with CQL. Param: CQL query string
-
-Example: space = DEV AND title ~ 'API'
-Returns matching pages with: ID, title, space, status.
-
-CQL Reference: https://developer.atlassian.com/cloud/confluence/cql-functions/`,
-}
-
 // ConfluenceFormatDocumentation contains the full extended markdown syntax reference for Confluence.
 const ConfluenceFormatDocumentation = `# Extended Markdown Format Reference (Confluence)
 
@@ -192,31 +169,3 @@ Colors: neutral, purple, blue, green, yellow, red
 3. **Media uploads**: URLs and local paths are automatically uploaded
    as attachments when you update/create a page.
 `
-
-// ConfluenceWriteVerbHelp maps write verbs to their help text.
-var ConfluenceWriteVerbHelp = map[string]string{
-	"add_comment": "Add comment to page. Param: {\"pageId\": \"123456\", \"body\": \"Comment text\"}\n\nBody supports markdown:\n- Blocks: headings, code blocks, blockquotes, lists, tables\n- Inline: **bold**, *italic*, ~~strike~~, `code`, [link](url)\n- Mentions: @[Name](accountId:xxx)",
-
-	"update_page": `Update page. Param: {"pageId": "123456", "title": "New Title", "body": "Content", "checksums": {...}}
-
-Workflow:
-1. Call get_format to learn extended markdown syntax
-2. Call confluence_get_page to get current values and checksums
-3. Include checksums for fields you're updating
-4. If page changed since read, returns conflict error
-
-Checksum fields: title, body, version (all required)
-
-Returns fresh checksums on success.`,
-
-	"create_page": `Create new page. Param: {"spaceId": "123", "title": "Title", "body": "Content", "parentId": "456"}
-
-Workflow:
-1. Call get_format to learn extended markdown syntax
-2. Create page with fields
-
-Required: spaceId, title
-Optional: body (markdown), parentId (for child pages)
-
-Returns created page ID.`,
-}
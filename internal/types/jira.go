@@ -4,8 +4,9 @@ package types
 
 // JiraAddCommentParams represents parameters for adding a comment to a Jira issue.
 type JiraAddCommentParams struct {
-	Issue string `json:"issue"`
-	Body  string `json:"body"`
+	Issue  string `json:"issue"`
+	Body   string `json:"body"`
+	DryRun bool   `json:"dryRun,omitempty"`
 }
 
 // JiraUpdateIssueParams represents parameters for updating a Jira issue.
@@ -13,6 +14,7 @@ type JiraUpdateIssueParams struct {
 	Issue     string            `json:"issue"`
 	Fields    map[string]any    `json:"fields"`
 	Checksums map[string]string `json:"checksums"`
+	DryRun    bool              `json:"dryRun,omitempty"`
 }
 
 // JiraCreateIssueParams represents parameters for creating a Jira issue.
@@ -21,6 +23,7 @@ type JiraCreateIssueParams struct {
 	IssueType   string `json:"issuetype"`
 	Summary     string `json:"summary"`
 	Description string `json:"description,omitempty"`
+	DryRun      bool   `json:"dryRun,omitempty"`
 }
 
 // JiraAttachmentInfo represents metadata from a Jira attachment upload.
@@ -32,28 +35,6 @@ type JiraAttachmentInfo struct {
 	Content  string `json:"content"` // URL to file content
 }
 
-// JiraReadVerbHelp maps read verbs to their help text.
-var JiraReadVerbHelp = map[string]string{
-	"get_issue": `Get issue details. Param: issue key or URL (e.g., PROJ-123)
-
-Returns: summary, status, type, priority, assignee, reporter, labels, components, parent, dates, description, subtasks, linked issues.
-
-Roundtrip formats in output (copy into jira_add_comment/jira_update_issue):
-- Mentions: @[Name](accountId:xxx)
-- Media: ![alt](jira-media:id:collection:type)
-
-Returns __CHECKSUMS__ section with SHA256 hashes for: summary, description, status, assignee, priority, labels, components. Required for jira_update_issue.`,
-	"get_comments": `Get issue comments. Param: issue key or URL
-
-Returns up to 50 comments (oldest first) with author, timestamp, and body in markdown.`,
-	"search": `Search issues with JQL. Param: JQL query string
-
-Example: assignee=currentUser() AND status=Open
-Returns up to 50 issues with: key, type, summary, status, assignee.
-
-JQL Reference: https://support.atlassian.com/jira-software-cloud/docs/use-advanced-search-with-jira-query-language-jql/`,
-}
-
 // JiraFormatDocumentation contains the full extended markdown syntax reference for Jira.
 const JiraFormatDocumentation = `# Extended Markdown Format Reference (Jira)
 
@@ -187,42 +168,3 @@ Colors: neutral, purple, blue, green, yellow, red
 3. **Media uploads**: URLs and local paths in descriptions are automatically
    uploaded as attachments when you update an issue. Max 10MB per file.
 `
-
-// JiraWriteVerbHelp maps write verbs to their help text.
-var JiraWriteVerbHelp = map[string]string{
-	"add_comment": `Add comment to issue. Param: {"issue": "PROJ-123", "body": "Comment text"}
-
-Body supports markdown:
-- Blocks: headings, code blocks (with lang), blockquotes, lists, tables, horizontal rules
-- Inline: **bold**, *italic*, ~~strike~~, ` + "`code`" + `, [link](url)
-- Mentions: @[Name](accountId:xxx) - use format from jira_get_issue output
-- Existing media: ![alt](jira-media:id:collection:type)
-
-Note: Image uploads not supported in comments. To add images, update the issue description.`,
-	"update_issue": `Update issue fields. Param: {"issue": "PROJ-123", "fields": {...}, "checksums": {...}}
-
-Workflow:
-1. Call get_format to learn extended markdown syntax
-2. Call jira_get_issue to get current values and checksums
-3. Include checksum for each field you update
-4. If field changed since read, returns conflict error
-
-Checksum fields: summary, description, status, assignee, priority, labels, components
-
-Image uploads supported:
-- New: ![alt](url) or ![alt](/path) - auto-uploaded as attachment (10MB limit)
-- Existing: ![alt](jira-media:id:collection:type) from jira_get_issue
-
-Returns fresh checksums on success.`,
-	"create_issue": `Create new issue. Param: {"project": "PROJ", "issuetype": "Task", "summary": "Title", "description": "Details"}
-
-Workflow:
-1. Call get_format to learn extended markdown syntax
-2. Create issue with fields
-
-Required: project (key), issuetype (name), summary
-Optional: description (markdown)
-
-To add images: create issue first, then use jira_update_issue with description containing ![alt](url).
-Returns created issue key.`,
-}
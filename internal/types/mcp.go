@@ -20,23 +20,85 @@ type Response struct {
 	Error   *Error `json:"error,omitempty"`
 }
 
+// Notification represents a JSON-RPC 2.0 notification sent by the server.
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
 // Error represents a JSON-RPC 2.0 error.
 type Error struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
 
+// CancelledParams represents parameters for a notifications/cancelled message.
+type CancelledParams struct {
+	RequestID any    `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// InitializeParams represents parameters for an initialize request.
+type InitializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      map[string]any `json:"clientInfo"`
+}
+
 // Tool represents an MCP tool definition.
 type Tool struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	InputSchema any    `json:"inputSchema"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	InputSchema  any    `json:"inputSchema"`
+	OutputSchema any    `json:"outputSchema,omitempty"`
 }
 
 // ToolCallParams represents parameters for a tool call.
 type ToolCallParams struct {
 	Name      string          `json:"name"`
 	Arguments json.RawMessage `json:"arguments"`
+	Meta      *RequestMeta    `json:"_meta,omitempty"`
+}
+
+// RequestMeta represents the _meta field of a request.
+type RequestMeta struct {
+	ProgressToken any `json:"progressToken,omitempty"`
+}
+
+// ProgressParams represents parameters for a notifications/progress message.
+type ProgressParams struct {
+	ProgressToken any     `json:"progressToken"`
+	Progress      float64 `json:"progress"`
+	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
+
+// SetLevelParams represents parameters for a logging/setLevel request.
+type SetLevelParams struct {
+	Level string `json:"level"`
+}
+
+// LoggingMessageParams represents parameters for a notifications/message
+// notification.
+type LoggingMessageParams struct {
+	Level  string `json:"level"`
+	Logger string `json:"logger,omitempty"`
+	Data   any    `json:"data"`
+}
+
+// ElicitParams represents parameters for an elicitation/create request sent
+// to the client.
+type ElicitParams struct {
+	Message         string         `json:"message"`
+	RequestedSchema map[string]any `json:"requestedSchema"`
+}
+
+// ElicitResult represents the client's answer to elicitation/create. Action
+// is "accept", "decline" or "cancel".
+type ElicitResult struct {
+	Action  string         `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
 }
 
 // TextContent represents text content in a tool response.
@@ -45,23 +107,111 @@ type TextContent struct {
 	Text string `json:"text"`
 }
 
-// VerbArgs represents verb-based dispatching arguments.
-type VerbArgs struct {
-	Verb  string `json:"verb"`
-	Param string `json:"param"`
+// Resource represents a concrete MCP resource.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate represents a parameterized MCP resource (RFC 6570 URI template).
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents represents the text contents of a resource.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// ReadResourceParams represents parameters for a resources/read request.
+type ReadResourceParams struct {
+	URI string `json:"uri"`
 }
 
-// SearchUsersHelp contains help text for the search_users verb.
-const SearchUsersHelp = `Search for users by name or email. Param: search query
+// Prompt represents an MCP prompt template definition.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
 
-Example: search_users with param "John" or "john@example.com"
+// PromptArgument describes an argument accepted by a prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
 
-Returns matching users with:
-- Display name
-- Account ID
-- Ready-to-use mention format: @[Name](accountId:xxx)
+// PromptMessage represents a single message returned by prompts/get.
+type PromptMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
 
-Use the mention format in comments, descriptions, or page content.`
+// EmbeddedResource represents resource contents embedded in a prompt message.
+type EmbeddedResource struct {
+	Type     string           `json:"type"`
+	Resource ResourceContents `json:"resource"`
+}
+
+// GetPromptParams represents parameters for a prompts/get request.
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments"`
+}
+
+// CompleteParams represents parameters for a completion/complete request.
+type CompleteParams struct {
+	Ref      CompletionRef      `json:"ref"`
+	Argument CompletionArgument `json:"argument"`
+	Context  *CompletionContext `json:"context,omitempty"`
+}
+
+// CompletionRef identifies the prompt or resource template being completed.
+type CompletionRef struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+// CompletionArgument is the argument being completed and its partial value.
+type CompletionArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CompletionContext carries arguments the client has already filled in.
+type CompletionContext struct {
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// Completion represents the result of a completion/complete request.
+type Completion struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore"`
+}
+
+// Result is the output of a Jira or Confluence operation: markdown for the
+// agent plus structured fields for clients that accept structuredContent.
+type Result struct {
+	Text string
+	Data map[string]any
+}
+
+// VerbArgs represents verb-based dispatching arguments.
+type VerbArgs struct {
+	Verb  string `json:"verb"`
+	Param string `json:"param"`
+	Site  string `json:"site,omitempty"`
+}
 
 // FormatDocumentation contains the unified extended markdown syntax reference.
 const FormatDocumentation = `# Extended Markdown Format Reference
@@ -1,64 +1,131 @@
 package users
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
 
 	"atlassian-mcp/internal/client"
+	"atlassian-mcp/internal/types"
 )
 
-// SearchUsers searches for users by name or email and returns formatted results
-// with account IDs ready for mentions.
-func SearchUsers(query string) (string, error) {
+// User identifies an Atlassian account. On Data Center, AccountID holds the
+// username, which mentions and user fields take there.
+type User struct {
+	DisplayName string
+	AccountID   string
+}
+
+// Mention returns the extended markdown mention for the user.
+func (u User) Mention() string {
+	return fmt.Sprintf("@[%s](accountId:%s)", u.DisplayName, u.AccountID)
+}
+
+// FindUsers searches for users by name or email.
+func FindUsers(ctx context.Context, c *client.Client, query string) ([]User, error) {
 	if query == "" {
-		return "", fmt.Errorf("search query is required")
+		return nil, fmt.Errorf("search query is required")
 	}
 
 	// Use the user picker endpoint - designed for finding users to mention
-	endpoint := fmt.Sprintf("/rest/api/3/user/picker?query=%s&maxResults=10", url.QueryEscape(query))
+	endpoint := fmt.Sprintf("%s/user/picker?query=%s&maxResults=10", api(c), url.QueryEscape(query))
 
-	body, err := client.Request(client.Jira, endpoint)
+	body, err := c.Request(ctx, client.Jira, endpoint)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var result map[string]any
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse user search response")
+		return nil, fmt.Errorf("failed to parse user search response")
 	}
 
-	users, ok := result["users"].([]any)
-	if !ok || len(users) == 0 {
-		return "No users found matching: " + query + "\n", nil
-	}
-
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("# User Search Results (%d found)\n\n", len(users)))
-	sb.WriteString("| Name | Account ID | Mention Format |\n")
-	sb.WriteString("|------|------------|----------------|\n")
-
-	for _, u := range users {
+	entries, _ := result["users"].([]any)
+	var users []User
+	for _, u := range entries {
 		user, ok := u.(map[string]any)
 		if !ok {
 			continue
 		}
 
 		displayName, _ := user["displayName"].(string)
-		accountID, _ := user["accountId"].(string)
-
+		accountID := accountIDOf(c, user)
 		if displayName == "" || accountID == "" {
 			continue
 		}
+		users = append(users, User{DisplayName: displayName, AccountID: accountID})
+	}
+	return users, nil
+}
 
-		// Format: @[Name](accountId:xxx)
-		mentionFormat := fmt.Sprintf("@[%s](accountId:%s)", displayName, accountID)
+// SearchUsers searches for users by name or email and returns formatted results
+// with account IDs ready for mentions.
+func SearchUsers(ctx context.Context, c *client.Client, query string) (types.Result, error) {
+	users, err := FindUsers(ctx, c, query)
+	if err != nil {
+		return types.Result{}, err
+	}
+	if len(users) == 0 {
+		return types.Result{
+			Text: "No users found matching: " + query + "\n",
+			Data: map[string]any{"users": []any{}},
+		}, nil
+	}
 
-		sb.WriteString(fmt.Sprintf("| %s | %s | `%s` |\n", displayName, accountID, mentionFormat))
+	found := make([]map[string]any, 0, len(users))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# User Search Results (%d found)\n\n", len(users)))
+	sb.WriteString("| Name | Account ID | Mention Format |\n")
+	sb.WriteString("|------|------------|----------------|\n")
+
+	for _, u := range users {
+		sb.WriteString(fmt.Sprintf("| %s | %s | `%s` |\n", u.DisplayName, u.AccountID, u.Mention()))
+		found = append(found, map[string]any{"displayName": u.DisplayName, "accountId": u.AccountID, "mention": u.Mention()})
 	}
 
 	sb.WriteString("\n**Usage:** Copy the mention format into comments, descriptions, or page content.\n")
 
-	return sb.String(), nil
+	return types.Result{Text: sb.String(), Data: map[string]any{"users": found}}, nil
+}
+
+// CurrentUser returns the display name and account ID of the authenticated user.
+func CurrentUser(ctx context.Context, c *client.Client) (displayName, accountID string, err error) {
+	body, err := c.Request(ctx, client.Jira, api(c)+"/myself")
+	if err != nil {
+		return "", "", err
+	}
+
+	var user map[string]any
+	if err := json.Unmarshal(body, &user); err != nil {
+		return "", "", fmt.Errorf("failed to parse current user response")
+	}
+
+	displayName, _ = user["displayName"].(string)
+	accountID = accountIDOf(c, user)
+	if accountID == "" {
+		return "", "", fmt.Errorf("could not determine current user")
+	}
+	return displayName, accountID, nil
+}
+
+// api returns the Jira REST API root: v3 on Cloud, v2 on Data Center.
+func api(c *client.Client) string {
+	if c.DataCenter {
+		return "/rest/api/2"
+	}
+	return "/rest/api/3"
+}
+
+// accountIDOf returns the accountId of a Cloud user or the username of a
+// Data Center user.
+func accountIDOf(c *client.Client, user map[string]any) string {
+	key := "accountId"
+	if c.DataCenter {
+		key = "name"
+	}
+	id, _ := user[key].(string)
+	return id
 }